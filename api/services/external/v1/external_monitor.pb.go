@@ -23,6 +23,7 @@ package v1
 import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
 	emptypb "google.golang.org/protobuf/types/known/emptypb"
 	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
@@ -37,6 +38,65 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// Types of parameter values.
+type ParameterType int32
+
+const (
+	ParameterType_PARAMETER_TYPE_UNSPECIFIED ParameterType = 0 // Treated as a string
+	ParameterType_PARAMETER_TYPE_STRING      ParameterType = 1
+	ParameterType_PARAMETER_TYPE_INT         ParameterType = 2
+	ParameterType_PARAMETER_TYPE_FLOAT       ParameterType = 3
+	ParameterType_PARAMETER_TYPE_BOOL        ParameterType = 4
+	ParameterType_PARAMETER_TYPE_DURATION    ParameterType = 5 // Go duration syntax, e.g. "30s"
+)
+
+// Enum value maps for ParameterType.
+var (
+	ParameterType_name = map[int32]string{
+		0: "PARAMETER_TYPE_UNSPECIFIED",
+		1: "PARAMETER_TYPE_STRING",
+		2: "PARAMETER_TYPE_INT",
+		3: "PARAMETER_TYPE_FLOAT",
+		4: "PARAMETER_TYPE_BOOL",
+		5: "PARAMETER_TYPE_DURATION",
+	}
+	ParameterType_value = map[string]int32{
+		"PARAMETER_TYPE_UNSPECIFIED": 0,
+		"PARAMETER_TYPE_STRING":      1,
+		"PARAMETER_TYPE_INT":         2,
+		"PARAMETER_TYPE_FLOAT":       3,
+		"PARAMETER_TYPE_BOOL":        4,
+		"PARAMETER_TYPE_DURATION":    5,
+	}
+)
+
+func (x ParameterType) Enum() *ParameterType {
+	p := new(ParameterType)
+	*p = x
+	return p
+}
+
+func (x ParameterType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ParameterType) Descriptor() protoreflect.EnumDescriptor {
+	return file_api_services_external_v1_external_monitor_proto_enumTypes[0].Descriptor()
+}
+
+func (ParameterType) Type() protoreflect.EnumType {
+	return &file_api_services_external_v1_external_monitor_proto_enumTypes[0]
+}
+
+func (x ParameterType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ParameterType.Descriptor instead.
+func (ParameterType) EnumDescriptor() ([]byte, []int) {
+	return file_api_services_external_v1_external_monitor_proto_rawDescGZIP(), []int{0}
+}
+
 // Severity levels for events.
 type Severity int32
 
@@ -71,11 +131,11 @@ func (x Severity) String() string {
 }
 
 func (Severity) Descriptor() protoreflect.EnumDescriptor {
-	return file_api_services_external_v1_external_monitor_proto_enumTypes[0].Descriptor()
+	return file_api_services_external_v1_external_monitor_proto_enumTypes[1].Descriptor()
 }
 
 func (Severity) Type() protoreflect.EnumType {
-	return &file_api_services_external_v1_external_monitor_proto_enumTypes[0]
+	return &file_api_services_external_v1_external_monitor_proto_enumTypes[1]
 }
 
 func (x Severity) Number() protoreflect.EnumNumber {
@@ -84,7 +144,60 @@ func (x Severity) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use Severity.Descriptor instead.
 func (Severity) EnumDescriptor() ([]byte, []int) {
-	return file_api_services_external_v1_external_monitor_proto_rawDescGZIP(), []int{0}
+	return file_api_services_external_v1_external_monitor_proto_rawDescGZIP(), []int{1}
+}
+
+// Severity levels for conditions.
+type ConditionSeverity int32
+
+const (
+	ConditionSeverity_CONDITION_SEVERITY_UNSPECIFIED ConditionSeverity = 0
+	ConditionSeverity_CONDITION_SEVERITY_INFO        ConditionSeverity = 1
+	ConditionSeverity_CONDITION_SEVERITY_WARNING     ConditionSeverity = 2
+	ConditionSeverity_CONDITION_SEVERITY_CRITICAL    ConditionSeverity = 3
+)
+
+// Enum value maps for ConditionSeverity.
+var (
+	ConditionSeverity_name = map[int32]string{
+		0: "CONDITION_SEVERITY_UNSPECIFIED",
+		1: "CONDITION_SEVERITY_INFO",
+		2: "CONDITION_SEVERITY_WARNING",
+		3: "CONDITION_SEVERITY_CRITICAL",
+	}
+	ConditionSeverity_value = map[string]int32{
+		"CONDITION_SEVERITY_UNSPECIFIED": 0,
+		"CONDITION_SEVERITY_INFO":        1,
+		"CONDITION_SEVERITY_WARNING":     2,
+		"CONDITION_SEVERITY_CRITICAL":    3,
+	}
+)
+
+func (x ConditionSeverity) Enum() *ConditionSeverity {
+	p := new(ConditionSeverity)
+	*p = x
+	return p
+}
+
+func (x ConditionSeverity) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ConditionSeverity) Descriptor() protoreflect.EnumDescriptor {
+	return file_api_services_external_v1_external_monitor_proto_enumTypes[2].Descriptor()
+}
+
+func (ConditionSeverity) Type() protoreflect.EnumType {
+	return &file_api_services_external_v1_external_monitor_proto_enumTypes[2]
+}
+
+func (x ConditionSeverity) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ConditionSeverity.Descriptor instead.
+func (ConditionSeverity) EnumDescriptor() ([]byte, []int) {
+	return file_api_services_external_v1_external_monitor_proto_rawDescGZIP(), []int{2}
 }
 
 // Status values for conditions.
@@ -124,11 +237,11 @@ func (x ConditionStatus) String() string {
 }
 
 func (ConditionStatus) Descriptor() protoreflect.EnumDescriptor {
-	return file_api_services_external_v1_external_monitor_proto_enumTypes[1].Descriptor()
+	return file_api_services_external_v1_external_monitor_proto_enumTypes[3].Descriptor()
 }
 
 func (ConditionStatus) Type() protoreflect.EnumType {
-	return &file_api_services_external_v1_external_monitor_proto_enumTypes[1]
+	return &file_api_services_external_v1_external_monitor_proto_enumTypes[3]
 }
 
 func (x ConditionStatus) Number() protoreflect.EnumNumber {
@@ -137,7 +250,7 @@ func (x ConditionStatus) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use ConditionStatus.Descriptor instead.
 func (ConditionStatus) EnumDescriptor() ([]byte, []int) {
-	return file_api_services_external_v1_external_monitor_proto_rawDescGZIP(), []int{1}
+	return file_api_services_external_v1_external_monitor_proto_rawDescGZIP(), []int{3}
 }
 
 // HealthCheckRequest contains parameters for the health check.
@@ -147,7 +260,11 @@ type HealthCheckRequest struct {
 	// Can be used for dynamic reconfiguration without restart.
 	Parameters map[string]string `protobuf:"bytes,1,rep,name=parameters,proto3" json:"parameters,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
 	// Sequence number for this check (for debugging/correlation).
-	Sequence      int64 `protobuf:"varint,2,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	Sequence int64 `protobuf:"varint,2,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	// Snapshot of relevant node conditions, when enabled in the NPD config.
+	NodeConditions []*Condition `protobuf:"bytes,3,rep,name=node_conditions,json=nodeConditions,proto3" json:"node_conditions,omitempty"`
+	// Condition types to evaluate. Empty means all conditions.
+	Conditions    []string `protobuf:"bytes,4,rep,name=conditions,proto3" json:"conditions,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -196,6 +313,20 @@ func (x *HealthCheckRequest) GetSequence() int64 {
 	return 0
 }
 
+func (x *HealthCheckRequest) GetNodeConditions() []*Condition {
+	if x != nil {
+		return x.NodeConditions
+	}
+	return nil
+}
+
+func (x *HealthCheckRequest) GetConditions() []string {
+	if x != nil {
+		return x.Conditions
+	}
+	return nil
+}
+
 // Status represents the current health status from the monitor.
 // This mirrors the internal types.Status structure.
 type Status struct {
@@ -271,7 +402,16 @@ type Event struct {
 	// Reason is a machine-readable identifier for the event type.
 	Reason string `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
 	// Message is a human-readable description.
-	Message       string `protobuf:"bytes,4,opt,name=message,proto3" json:"message,omitempty"`
+	Message string `protobuf:"bytes,4,opt,name=message,proto3" json:"message,omitempty"`
+	// Optional key identifying repeats of the same event for deduplication.
+	// When empty, reason and message are used instead.
+	DedupKey string `protobuf:"bytes,5,opt,name=dedup_key,json=dedupKey,proto3" json:"dedup_key,omitempty"`
+	// Optional structured diagnostics captured with the event, such as raw
+	// tool output. Forwarded by NPD as part of the event message.
+	Details map[string]string `protobuf:"bytes,6,rep,name=details,proto3" json:"details,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Optional suggested action, e.g. "check cooling". Forwarded by NPD as
+	// part of the event message.
+	Remediation   string `protobuf:"bytes,7,opt,name=remediation,proto3" json:"remediation,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -334,6 +474,27 @@ func (x *Event) GetMessage() string {
 	return ""
 }
 
+func (x *Event) GetDedupKey() string {
+	if x != nil {
+		return x.DedupKey
+	}
+	return ""
+}
+
+func (x *Event) GetDetails() map[string]string {
+	if x != nil {
+		return x.Details
+	}
+	return nil
+}
+
+func (x *Event) GetRemediation() string {
+	if x != nil {
+		return x.Remediation
+	}
+	return ""
+}
+
 // Condition represents persistent node state.
 type Condition struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
@@ -346,9 +507,17 @@ type Condition struct {
 	// Reason is a machine-readable identifier for the condition reason.
 	Reason string `protobuf:"bytes,4,opt,name=reason,proto3" json:"reason,omitempty"`
 	// Message is a human-readable description.
-	Message       string `protobuf:"bytes,5,opt,name=message,proto3" json:"message,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	Message string `protobuf:"bytes,5,opt,name=message,proto3" json:"message,omitempty"`
+	// Optional severity of the problem, for alert routing.
+	// Does not affect the condition status.
+	Severity ConditionSeverity `protobuf:"varint,6,opt,name=severity,proto3,enum=npd.external.v1.ConditionSeverity" json:"severity,omitempty"`
+	// Optional magnitude of the threshold breach behind the condition,
+	// relative to the threshold: 0.1 means the value is 10% past it, -0.05
+	// that it is 5% short of it. Does not affect the condition status, but
+	// NPD uses it to keep conditions with a clear margin from flapping.
+	BreachMagnitude float64 `protobuf:"fixed64,7,opt,name=breach_magnitude,json=breachMagnitude,proto3" json:"breach_magnitude,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
 func (x *Condition) Reset() {
@@ -416,6 +585,20 @@ func (x *Condition) GetMessage() string {
 	return ""
 }
 
+func (x *Condition) GetSeverity() ConditionSeverity {
+	if x != nil {
+		return x.Severity
+	}
+	return ConditionSeverity_CONDITION_SEVERITY_UNSPECIFIED
+}
+
+func (x *Condition) GetBreachMagnitude() float64 {
+	if x != nil {
+		return x.BreachMagnitude
+	}
+	return 0
+}
+
 // MonitorMetadata provides information about the monitor plugin.
 type MonitorMetadata struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
@@ -427,12 +610,37 @@ type MonitorMetadata struct {
 	Description string `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
 	// List of condition types this monitor can report.
 	SupportedConditions []string `protobuf:"bytes,4,rep,name=supported_conditions,json=supportedConditions,proto3" json:"supported_conditions,omitempty"`
-	// Capabilities and features supported by this monitor.
+	// Capabilities and features supported by this monitor. "self_test" or
+	// "tail_logs" set to "false" tells NPD not to call SelfTest or TailLogs.
 	Capabilities map[string]string `protobuf:"bytes,5,rep,name=capabilities,proto3" json:"capabilities,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
 	// API version this monitor implements.
-	ApiVersion    string `protobuf:"bytes,6,opt,name=api_version,json=apiVersion,proto3" json:"api_version,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	ApiVersion string `protobuf:"bytes,6,opt,name=api_version,json=apiVersion,proto3" json:"api_version,omitempty"`
+	// Time the monitor process started. Used to detect plugin restarts.
+	StartedAt *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`
+	// Default values of the parameters the monitor accepts in HealthCheckRequest.
+	DefaultParameters map[string]string `protobuf:"bytes,8,rep,name=default_parameters,json=defaultParameters,proto3" json:"default_parameters,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Hash of the monitor's effective configuration, used to detect drift across nodes.
+	ConfigHash string `protobuf:"bytes,9,opt,name=config_hash,json=configHash,proto3" json:"config_hash,omitempty"`
+	// Parameters the monitor accepts in HealthCheckRequest, with their types.
+	// When set, the proxy validates and normalizes its configured parameters.
+	Parameters []*ParameterSpec `protobuf:"bytes,10,rep,name=parameters,proto3" json:"parameters,omitempty"`
+	// Number of devices the monitor watches, e.g. GPUs. NPD expands per-device
+	// condition definitions into one condition per device. Zero if the
+	// monitor does not report per device.
+	DeviceCount int32 `protobuf:"varint,11,opt,name=device_count,json=deviceCount,proto3" json:"device_count,omitempty"`
+	// How often the monitor is worth checking, e.g. the refresh rate of a
+	// slow sensor. NPD warns when its invoke_interval is far from it, and
+	// adopts it within configured bounds if asked to. Unset if the monitor
+	// has no recommendation.
+	RecommendedInterval *durationpb.Duration `protobuf:"bytes,12,opt,name=recommended_interval,json=recommendedInterval,proto3" json:"recommended_interval,omitempty"`
+	// X25519 public key (32 bytes) the monitor wants sensitive parameters
+	// encrypted to. NPD encrypts the parameters marked sensitive in its
+	// configuration to this key before sending them in HealthCheckRequest;
+	// see pkg/externalmonitor/paramcrypt for the format. Unset if the
+	// monitor takes no encrypted parameters.
+	ParameterEncryptionKey []byte `protobuf:"bytes,13,opt,name=parameter_encryption_key,json=parameterEncryptionKey,proto3" json:"parameter_encryption_key,omitempty"`
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
 }
 
 func (x *MonitorMetadata) Reset() {
@@ -507,16 +715,290 @@ func (x *MonitorMetadata) GetApiVersion() string {
 	return ""
 }
 
+func (x *MonitorMetadata) GetStartedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartedAt
+	}
+	return nil
+}
+
+func (x *MonitorMetadata) GetDefaultParameters() map[string]string {
+	if x != nil {
+		return x.DefaultParameters
+	}
+	return nil
+}
+
+func (x *MonitorMetadata) GetConfigHash() string {
+	if x != nil {
+		return x.ConfigHash
+	}
+	return ""
+}
+
+func (x *MonitorMetadata) GetParameters() []*ParameterSpec {
+	if x != nil {
+		return x.Parameters
+	}
+	return nil
+}
+
+func (x *MonitorMetadata) GetDeviceCount() int32 {
+	if x != nil {
+		return x.DeviceCount
+	}
+	return 0
+}
+
+func (x *MonitorMetadata) GetRecommendedInterval() *durationpb.Duration {
+	if x != nil {
+		return x.RecommendedInterval
+	}
+	return nil
+}
+
+func (x *MonitorMetadata) GetParameterEncryptionKey() []byte {
+	if x != nil {
+		return x.ParameterEncryptionKey
+	}
+	return nil
+}
+
+// ParameterSpec describes a parameter accepted by a monitor.
+type ParameterSpec struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Name of the parameter.
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// Type of the parameter value.
+	Type ParameterType `protobuf:"varint,2,opt,name=type,proto3,enum=npd.external.v1.ParameterType" json:"type,omitempty"`
+	// Default value used when the parameter is not sent.
+	DefaultValue string `protobuf:"bytes,3,opt,name=default_value,json=defaultValue,proto3" json:"default_value,omitempty"`
+	// Human-readable description of the parameter.
+	Description   string `protobuf:"bytes,4,opt,name=description,proto3" json:"description,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ParameterSpec) Reset() {
+	*x = ParameterSpec{}
+	mi := &file_api_services_external_v1_external_monitor_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ParameterSpec) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ParameterSpec) ProtoMessage() {}
+
+func (x *ParameterSpec) ProtoReflect() protoreflect.Message {
+	mi := &file_api_services_external_v1_external_monitor_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ParameterSpec.ProtoReflect.Descriptor instead.
+func (*ParameterSpec) Descriptor() ([]byte, []int) {
+	return file_api_services_external_v1_external_monitor_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ParameterSpec) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ParameterSpec) GetType() ParameterType {
+	if x != nil {
+		return x.Type
+	}
+	return ParameterType_PARAMETER_TYPE_UNSPECIFIED
+}
+
+func (x *ParameterSpec) GetDefaultValue() string {
+	if x != nil {
+		return x.DefaultValue
+	}
+	return ""
+}
+
+func (x *ParameterSpec) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+// SelfTestResult reports the outcome of a monitor self-test.
+type SelfTestResult struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Passed indicates whether the monitor is able to perform health checks.
+	Passed bool `protobuf:"varint,1,opt,name=passed,proto3" json:"passed,omitempty"`
+	// Message is a human-readable description of the result.
+	Message       string `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SelfTestResult) Reset() {
+	*x = SelfTestResult{}
+	mi := &file_api_services_external_v1_external_monitor_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SelfTestResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SelfTestResult) ProtoMessage() {}
+
+func (x *SelfTestResult) ProtoReflect() protoreflect.Message {
+	mi := &file_api_services_external_v1_external_monitor_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SelfTestResult.ProtoReflect.Descriptor instead.
+func (*SelfTestResult) Descriptor() ([]byte, []int) {
+	return file_api_services_external_v1_external_monitor_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *SelfTestResult) GetPassed() bool {
+	if x != nil {
+		return x.Passed
+	}
+	return false
+}
+
+func (x *SelfTestResult) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+// TailLogsRequest selects the log lines to stream.
+type TailLogsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Maximum number of most recent lines to stream. Zero lets the monitor
+	// choose.
+	MaxLines      int32 `protobuf:"varint,1,opt,name=max_lines,json=maxLines,proto3" json:"max_lines,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TailLogsRequest) Reset() {
+	*x = TailLogsRequest{}
+	mi := &file_api_services_external_v1_external_monitor_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TailLogsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TailLogsRequest) ProtoMessage() {}
+
+func (x *TailLogsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_services_external_v1_external_monitor_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TailLogsRequest.ProtoReflect.Descriptor instead.
+func (*TailLogsRequest) Descriptor() ([]byte, []int) {
+	return file_api_services_external_v1_external_monitor_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *TailLogsRequest) GetMaxLines() int32 {
+	if x != nil {
+		return x.MaxLines
+	}
+	return 0
+}
+
+// LogLine is a single line of monitor log output.
+type LogLine struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Line is the log line, without the trailing newline.
+	Line          string `protobuf:"bytes,1,opt,name=line,proto3" json:"line,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LogLine) Reset() {
+	*x = LogLine{}
+	mi := &file_api_services_external_v1_external_monitor_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogLine) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogLine) ProtoMessage() {}
+
+func (x *LogLine) ProtoReflect() protoreflect.Message {
+	mi := &file_api_services_external_v1_external_monitor_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogLine.ProtoReflect.Descriptor instead.
+func (*LogLine) Descriptor() ([]byte, []int) {
+	return file_api_services_external_v1_external_monitor_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *LogLine) GetLine() string {
+	if x != nil {
+		return x.Line
+	}
+	return ""
+}
+
 var File_api_services_external_v1_external_monitor_proto protoreflect.FileDescriptor
 
 const file_api_services_external_v1_external_monitor_proto_rawDesc = "" +
 	"\n" +
-	"/api/services/external/v1/external_monitor.proto\x12\x0fnpd.external.v1\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x1bgoogle/protobuf/empty.proto\"\xc4\x01\n" +
+	"/api/services/external/v1/external_monitor.proto\x12\x0fnpd.external.v1\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x1bgoogle/protobuf/empty.proto\x1a\x1egoogle/protobuf/duration.proto\"\xa9\x02\n" +
 	"\x12HealthCheckRequest\x12S\n" +
 	"\n" +
 	"parameters\x18\x01 \x03(\v23.npd.external.v1.HealthCheckRequest.ParametersEntryR\n" +
 	"parameters\x12\x1a\n" +
-	"\bsequence\x18\x02 \x01(\x03R\bsequence\x1a=\n" +
+	"\bsequence\x18\x02 \x01(\x03R\bsequence\x12C\n" +
+	"\x0fnode_conditions\x18\x03 \x03(\v2\x1a.npd.external.v1.ConditionR\x0enodeConditions\x12\x1e\n" +
+	"\n" +
+	"conditions\x18\x04 \x03(\tR\n" +
+	"conditions\x1a=\n" +
 	"\x0fParametersEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
 	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x8c\x01\n" +
@@ -525,12 +1007,18 @@ const file_api_services_external_v1_external_monitor_proto_rawDesc = "" +
 	"\x06events\x18\x02 \x03(\v2\x16.npd.external.v1.EventR\x06events\x12:\n" +
 	"\n" +
 	"conditions\x18\x03 \x03(\v2\x1a.npd.external.v1.ConditionR\n" +
-	"conditions\"\xaa\x01\n" +
+	"conditions\"\xe4\x02\n" +
 	"\x05Event\x125\n" +
 	"\bseverity\x18\x01 \x01(\x0e2\x19.npd.external.v1.SeverityR\bseverity\x128\n" +
 	"\ttimestamp\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12\x16\n" +
 	"\x06reason\x18\x03 \x01(\tR\x06reason\x12\x18\n" +
-	"\amessage\x18\x04 \x01(\tR\amessage\"\xc7\x01\n" +
+	"\amessage\x18\x04 \x01(\tR\amessage\x12\x1b\n" +
+	"\tdedup_key\x18\x05 \x01(\tR\bdedupKey\x12=\n" +
+	"\adetails\x18\x06 \x03(\v2#.npd.external.v1.Event.DetailsEntryR\adetails\x12 \n" +
+	"\vremediation\x18\a \x01(\tR\vremediation\x1a:\n" +
+	"\fDetailsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xb2\x02\n" +
 	"\tCondition\x12\x12\n" +
 	"\x04type\x18\x01 \x01(\tR\x04type\x128\n" +
 	"\x06status\x18\x02 \x01(\x0e2 .npd.external.v1.ConditionStatusR\x06status\x12:\n" +
@@ -538,7 +1026,9 @@ const file_api_services_external_v1_external_monitor_proto_rawDesc = "" +
 	"transition\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
 	"transition\x12\x16\n" +
 	"\x06reason\x18\x04 \x01(\tR\x06reason\x12\x18\n" +
-	"\amessage\x18\x05 \x01(\tR\amessage\"\xce\x02\n" +
+	"\amessage\x18\x05 \x01(\tR\amessage\x12>\n" +
+	"\bseverity\x18\x06 \x01(\x0e2\".npd.external.v1.ConditionSeverityR\bseverity\x12)\n" +
+	"\x10breach_magnitude\x18\a \x01(\x01R\x0fbreachMagnitude\"\xc3\x06\n" +
 	"\x0fMonitorMetadata\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x12\x18\n" +
 	"\aversion\x18\x02 \x01(\tR\aversion\x12 \n" +
@@ -546,23 +1036,64 @@ const file_api_services_external_v1_external_monitor_proto_rawDesc = "" +
 	"\x14supported_conditions\x18\x04 \x03(\tR\x13supportedConditions\x12V\n" +
 	"\fcapabilities\x18\x05 \x03(\v22.npd.external.v1.MonitorMetadata.CapabilitiesEntryR\fcapabilities\x12\x1f\n" +
 	"\vapi_version\x18\x06 \x01(\tR\n" +
-	"apiVersion\x1a?\n" +
+	"apiVersion\x129\n" +
+	"\n" +
+	"started_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tstartedAt\x12f\n" +
+	"\x12default_parameters\x18\b \x03(\v27.npd.external.v1.MonitorMetadata.DefaultParametersEntryR\x11defaultParameters\x12\x1f\n" +
+	"\vconfig_hash\x18\t \x01(\tR\n" +
+	"configHash\x12>\n" +
+	"\n" +
+	"parameters\x18\n" +
+	" \x03(\v2\x1e.npd.external.v1.ParameterSpecR\n" +
+	"parameters\x12!\n" +
+	"\fdevice_count\x18\v \x01(\x05R\vdeviceCount\x12L\n" +
+	"\x14recommended_interval\x18\f \x01(\v2\x19.google.protobuf.DurationR\x13recommendedInterval\x128\n" +
+	"\x18parameter_encryption_key\x18\r \x01(\fR\x16parameterEncryptionKey\x1a?\n" +
 	"\x11CapabilitiesEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01*J\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\x1aD\n" +
+	"\x16DefaultParametersEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x9e\x01\n" +
+	"\rParameterSpec\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x122\n" +
+	"\x04type\x18\x02 \x01(\x0e2\x1e.npd.external.v1.ParameterTypeR\x04type\x12#\n" +
+	"\rdefault_value\x18\x03 \x01(\tR\fdefaultValue\x12 \n" +
+	"\vdescription\x18\x04 \x01(\tR\vdescription\"B\n" +
+	"\x0eSelfTestResult\x12\x16\n" +
+	"\x06passed\x18\x01 \x01(\bR\x06passed\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\".\n" +
+	"\x0fTailLogsRequest\x12\x1b\n" +
+	"\tmax_lines\x18\x01 \x01(\x05R\bmaxLines\"\x1d\n" +
+	"\aLogLine\x12\x12\n" +
+	"\x04line\x18\x01 \x01(\tR\x04line*\xb2\x01\n" +
+	"\rParameterType\x12\x1e\n" +
+	"\x1aPARAMETER_TYPE_UNSPECIFIED\x10\x00\x12\x19\n" +
+	"\x15PARAMETER_TYPE_STRING\x10\x01\x12\x16\n" +
+	"\x12PARAMETER_TYPE_INT\x10\x02\x12\x18\n" +
+	"\x14PARAMETER_TYPE_FLOAT\x10\x03\x12\x17\n" +
+	"\x13PARAMETER_TYPE_BOOL\x10\x04\x12\x1b\n" +
+	"\x17PARAMETER_TYPE_DURATION\x10\x05*J\n" +
 	"\bSeverity\x12\x18\n" +
 	"\x14SEVERITY_UNSPECIFIED\x10\x00\x12\x11\n" +
 	"\rSEVERITY_INFO\x10\x01\x12\x11\n" +
-	"\rSEVERITY_WARN\x10\x02*\x88\x01\n" +
+	"\rSEVERITY_WARN\x10\x02*\x95\x01\n" +
+	"\x11ConditionSeverity\x12\"\n" +
+	"\x1eCONDITION_SEVERITY_UNSPECIFIED\x10\x00\x12\x1b\n" +
+	"\x17CONDITION_SEVERITY_INFO\x10\x01\x12\x1e\n" +
+	"\x1aCONDITION_SEVERITY_WARNING\x10\x02\x12\x1f\n" +
+	"\x1bCONDITION_SEVERITY_CRITICAL\x10\x03*\x88\x01\n" +
 	"\x0fConditionStatus\x12 \n" +
 	"\x1cCONDITION_STATUS_UNSPECIFIED\x10\x00\x12\x19\n" +
 	"\x15CONDITION_STATUS_TRUE\x10\x01\x12\x1a\n" +
 	"\x16CONDITION_STATUS_FALSE\x10\x02\x12\x1c\n" +
-	"\x18CONDITION_STATUS_UNKNOWN\x10\x032\xdf\x01\n" +
+	"\x18CONDITION_STATUS_UNKNOWN\x10\x032\xee\x02\n" +
 	"\x0fExternalMonitor\x12K\n" +
 	"\vCheckHealth\x12#.npd.external.v1.HealthCheckRequest\x1a\x17.npd.external.v1.Status\x12G\n" +
 	"\vGetMetadata\x12\x16.google.protobuf.Empty\x1a .npd.external.v1.MonitorMetadata\x126\n" +
-	"\x04Stop\x12\x16.google.protobuf.Empty\x1a\x16.google.protobuf.EmptyB)Z'k8s.io/npd-ext/api/services/external/v1b\x06proto3"
+	"\x04Stop\x12\x16.google.protobuf.Empty\x1a\x16.google.protobuf.Empty\x12C\n" +
+	"\bSelfTest\x12\x16.google.protobuf.Empty\x1a\x1f.npd.external.v1.SelfTestResult\x12H\n" +
+	"\bTailLogs\x12 .npd.external.v1.TailLogsRequest\x1a\x18.npd.external.v1.LogLine0\x01B)Z'k8s.io/npd-ext/api/services/external/v1b\x06proto3"
 
 var (
 	file_api_services_external_v1_external_monitor_proto_rawDescOnce sync.Once
@@ -576,41 +1107,62 @@ func file_api_services_external_v1_external_monitor_proto_rawDescGZIP() []byte {
 	return file_api_services_external_v1_external_monitor_proto_rawDescData
 }
 
-var file_api_services_external_v1_external_monitor_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
-var file_api_services_external_v1_external_monitor_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_api_services_external_v1_external_monitor_proto_enumTypes = make([]protoimpl.EnumInfo, 4)
+var file_api_services_external_v1_external_monitor_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
 var file_api_services_external_v1_external_monitor_proto_goTypes = []any{
-	(Severity)(0),                 // 0: npd.external.v1.Severity
-	(ConditionStatus)(0),          // 1: npd.external.v1.ConditionStatus
-	(*HealthCheckRequest)(nil),    // 2: npd.external.v1.HealthCheckRequest
-	(*Status)(nil),                // 3: npd.external.v1.Status
-	(*Event)(nil),                 // 4: npd.external.v1.Event
-	(*Condition)(nil),             // 5: npd.external.v1.Condition
-	(*MonitorMetadata)(nil),       // 6: npd.external.v1.MonitorMetadata
-	nil,                           // 7: npd.external.v1.HealthCheckRequest.ParametersEntry
-	nil,                           // 8: npd.external.v1.MonitorMetadata.CapabilitiesEntry
-	(*timestamppb.Timestamp)(nil), // 9: google.protobuf.Timestamp
-	(*emptypb.Empty)(nil),         // 10: google.protobuf.Empty
+	(ParameterType)(0),            // 0: npd.external.v1.ParameterType
+	(Severity)(0),                 // 1: npd.external.v1.Severity
+	(ConditionSeverity)(0),        // 2: npd.external.v1.ConditionSeverity
+	(ConditionStatus)(0),          // 3: npd.external.v1.ConditionStatus
+	(*HealthCheckRequest)(nil),    // 4: npd.external.v1.HealthCheckRequest
+	(*Status)(nil),                // 5: npd.external.v1.Status
+	(*Event)(nil),                 // 6: npd.external.v1.Event
+	(*Condition)(nil),             // 7: npd.external.v1.Condition
+	(*MonitorMetadata)(nil),       // 8: npd.external.v1.MonitorMetadata
+	(*ParameterSpec)(nil),         // 9: npd.external.v1.ParameterSpec
+	(*SelfTestResult)(nil),        // 10: npd.external.v1.SelfTestResult
+	(*TailLogsRequest)(nil),       // 11: npd.external.v1.TailLogsRequest
+	(*LogLine)(nil),               // 12: npd.external.v1.LogLine
+	nil,                           // 13: npd.external.v1.HealthCheckRequest.ParametersEntry
+	nil,                           // 14: npd.external.v1.Event.DetailsEntry
+	nil,                           // 15: npd.external.v1.MonitorMetadata.CapabilitiesEntry
+	nil,                           // 16: npd.external.v1.MonitorMetadata.DefaultParametersEntry
+	(*timestamppb.Timestamp)(nil), // 17: google.protobuf.Timestamp
+	(*durationpb.Duration)(nil),   // 18: google.protobuf.Duration
+	(*emptypb.Empty)(nil),         // 19: google.protobuf.Empty
 }
 var file_api_services_external_v1_external_monitor_proto_depIdxs = []int32{
-	7,  // 0: npd.external.v1.HealthCheckRequest.parameters:type_name -> npd.external.v1.HealthCheckRequest.ParametersEntry
-	4,  // 1: npd.external.v1.Status.events:type_name -> npd.external.v1.Event
-	5,  // 2: npd.external.v1.Status.conditions:type_name -> npd.external.v1.Condition
-	0,  // 3: npd.external.v1.Event.severity:type_name -> npd.external.v1.Severity
-	9,  // 4: npd.external.v1.Event.timestamp:type_name -> google.protobuf.Timestamp
-	1,  // 5: npd.external.v1.Condition.status:type_name -> npd.external.v1.ConditionStatus
-	9,  // 6: npd.external.v1.Condition.transition:type_name -> google.protobuf.Timestamp
-	8,  // 7: npd.external.v1.MonitorMetadata.capabilities:type_name -> npd.external.v1.MonitorMetadata.CapabilitiesEntry
-	2,  // 8: npd.external.v1.ExternalMonitor.CheckHealth:input_type -> npd.external.v1.HealthCheckRequest
-	10, // 9: npd.external.v1.ExternalMonitor.GetMetadata:input_type -> google.protobuf.Empty
-	10, // 10: npd.external.v1.ExternalMonitor.Stop:input_type -> google.protobuf.Empty
-	3,  // 11: npd.external.v1.ExternalMonitor.CheckHealth:output_type -> npd.external.v1.Status
-	6,  // 12: npd.external.v1.ExternalMonitor.GetMetadata:output_type -> npd.external.v1.MonitorMetadata
-	10, // 13: npd.external.v1.ExternalMonitor.Stop:output_type -> google.protobuf.Empty
-	11, // [11:14] is the sub-list for method output_type
-	8,  // [8:11] is the sub-list for method input_type
-	8,  // [8:8] is the sub-list for extension type_name
-	8,  // [8:8] is the sub-list for extension extendee
-	0,  // [0:8] is the sub-list for field type_name
+	13, // 0: npd.external.v1.HealthCheckRequest.parameters:type_name -> npd.external.v1.HealthCheckRequest.ParametersEntry
+	7,  // 1: npd.external.v1.HealthCheckRequest.node_conditions:type_name -> npd.external.v1.Condition
+	6,  // 2: npd.external.v1.Status.events:type_name -> npd.external.v1.Event
+	7,  // 3: npd.external.v1.Status.conditions:type_name -> npd.external.v1.Condition
+	1,  // 4: npd.external.v1.Event.severity:type_name -> npd.external.v1.Severity
+	17, // 5: npd.external.v1.Event.timestamp:type_name -> google.protobuf.Timestamp
+	14, // 6: npd.external.v1.Event.details:type_name -> npd.external.v1.Event.DetailsEntry
+	3,  // 7: npd.external.v1.Condition.status:type_name -> npd.external.v1.ConditionStatus
+	17, // 8: npd.external.v1.Condition.transition:type_name -> google.protobuf.Timestamp
+	2,  // 9: npd.external.v1.Condition.severity:type_name -> npd.external.v1.ConditionSeverity
+	15, // 10: npd.external.v1.MonitorMetadata.capabilities:type_name -> npd.external.v1.MonitorMetadata.CapabilitiesEntry
+	17, // 11: npd.external.v1.MonitorMetadata.started_at:type_name -> google.protobuf.Timestamp
+	16, // 12: npd.external.v1.MonitorMetadata.default_parameters:type_name -> npd.external.v1.MonitorMetadata.DefaultParametersEntry
+	9,  // 13: npd.external.v1.MonitorMetadata.parameters:type_name -> npd.external.v1.ParameterSpec
+	18, // 14: npd.external.v1.MonitorMetadata.recommended_interval:type_name -> google.protobuf.Duration
+	0,  // 15: npd.external.v1.ParameterSpec.type:type_name -> npd.external.v1.ParameterType
+	4,  // 16: npd.external.v1.ExternalMonitor.CheckHealth:input_type -> npd.external.v1.HealthCheckRequest
+	19, // 17: npd.external.v1.ExternalMonitor.GetMetadata:input_type -> google.protobuf.Empty
+	19, // 18: npd.external.v1.ExternalMonitor.Stop:input_type -> google.protobuf.Empty
+	19, // 19: npd.external.v1.ExternalMonitor.SelfTest:input_type -> google.protobuf.Empty
+	11, // 20: npd.external.v1.ExternalMonitor.TailLogs:input_type -> npd.external.v1.TailLogsRequest
+	5,  // 21: npd.external.v1.ExternalMonitor.CheckHealth:output_type -> npd.external.v1.Status
+	8,  // 22: npd.external.v1.ExternalMonitor.GetMetadata:output_type -> npd.external.v1.MonitorMetadata
+	19, // 23: npd.external.v1.ExternalMonitor.Stop:output_type -> google.protobuf.Empty
+	10, // 24: npd.external.v1.ExternalMonitor.SelfTest:output_type -> npd.external.v1.SelfTestResult
+	12, // 25: npd.external.v1.ExternalMonitor.TailLogs:output_type -> npd.external.v1.LogLine
+	21, // [21:26] is the sub-list for method output_type
+	16, // [16:21] is the sub-list for method input_type
+	16, // [16:16] is the sub-list for extension type_name
+	16, // [16:16] is the sub-list for extension extendee
+	0,  // [0:16] is the sub-list for field type_name
 }
 
 func init() { file_api_services_external_v1_external_monitor_proto_init() }
@@ -623,8 +1175,8 @@ func file_api_services_external_v1_external_monitor_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_api_services_external_v1_external_monitor_proto_rawDesc), len(file_api_services_external_v1_external_monitor_proto_rawDesc)),
-			NumEnums:      2,
-			NumMessages:   7,
+			NumEnums:      4,
+			NumMessages:   13,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -37,6 +37,8 @@ const (
 	ExternalMonitor_CheckHealth_FullMethodName = "/npd.external.v1.ExternalMonitor/CheckHealth"
 	ExternalMonitor_GetMetadata_FullMethodName = "/npd.external.v1.ExternalMonitor/GetMetadata"
 	ExternalMonitor_Stop_FullMethodName        = "/npd.external.v1.ExternalMonitor/Stop"
+	ExternalMonitor_SelfTest_FullMethodName    = "/npd.external.v1.ExternalMonitor/SelfTest"
+	ExternalMonitor_TailLogs_FullMethodName    = "/npd.external.v1.ExternalMonitor/TailLogs"
 )
 
 // ExternalMonitorClient is the client API for ExternalMonitor service.
@@ -55,6 +57,13 @@ type ExternalMonitorClient interface {
 	// Stop notifies the monitor to perform graceful shutdown.
 	// Called when NPD is shutting down or plugin is being disabled.
 	Stop(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// SelfTest verifies the monitor can reach its backend.
+	// Called once after each connection, before normal polling starts.
+	SelfTest(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*SelfTestResult, error)
+	// TailLogs streams the monitor's most recent log lines, oldest first,
+	// then ends the stream. Optional, for diagnostics only: NPD calls it on
+	// demand from its debug endpoint and never during normal operation.
+	TailLogs(ctx context.Context, in *TailLogsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[LogLine], error)
 }
 
 type externalMonitorClient struct {
@@ -95,6 +104,35 @@ func (c *externalMonitorClient) Stop(ctx context.Context, in *emptypb.Empty, opt
 	return out, nil
 }
 
+func (c *externalMonitorClient) SelfTest(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*SelfTestResult, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SelfTestResult)
+	err := c.cc.Invoke(ctx, ExternalMonitor_SelfTest_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *externalMonitorClient) TailLogs(ctx context.Context, in *TailLogsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[LogLine], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ExternalMonitor_ServiceDesc.Streams[0], ExternalMonitor_TailLogs_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[TailLogsRequest, LogLine]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ExternalMonitor_TailLogsClient = grpc.ServerStreamingClient[LogLine]
+
 // ExternalMonitorServer is the server API for ExternalMonitor service.
 // All implementations must embed UnimplementedExternalMonitorServer
 // for forward compatibility.
@@ -111,6 +149,13 @@ type ExternalMonitorServer interface {
 	// Stop notifies the monitor to perform graceful shutdown.
 	// Called when NPD is shutting down or plugin is being disabled.
 	Stop(context.Context, *emptypb.Empty) (*emptypb.Empty, error)
+	// SelfTest verifies the monitor can reach its backend.
+	// Called once after each connection, before normal polling starts.
+	SelfTest(context.Context, *emptypb.Empty) (*SelfTestResult, error)
+	// TailLogs streams the monitor's most recent log lines, oldest first,
+	// then ends the stream. Optional, for diagnostics only: NPD calls it on
+	// demand from its debug endpoint and never during normal operation.
+	TailLogs(*TailLogsRequest, grpc.ServerStreamingServer[LogLine]) error
 	mustEmbedUnimplementedExternalMonitorServer()
 }
 
@@ -130,6 +175,12 @@ func (UnimplementedExternalMonitorServer) GetMetadata(context.Context, *emptypb.
 func (UnimplementedExternalMonitorServer) Stop(context.Context, *emptypb.Empty) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Stop not implemented")
 }
+func (UnimplementedExternalMonitorServer) SelfTest(context.Context, *emptypb.Empty) (*SelfTestResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SelfTest not implemented")
+}
+func (UnimplementedExternalMonitorServer) TailLogs(*TailLogsRequest, grpc.ServerStreamingServer[LogLine]) error {
+	return status.Errorf(codes.Unimplemented, "method TailLogs not implemented")
+}
 func (UnimplementedExternalMonitorServer) mustEmbedUnimplementedExternalMonitorServer() {}
 func (UnimplementedExternalMonitorServer) testEmbeddedByValue()                         {}
 
@@ -205,6 +256,35 @@ func _ExternalMonitor_Stop_Handler(srv interface{}, ctx context.Context, dec fun
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ExternalMonitor_SelfTest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExternalMonitorServer).SelfTest(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ExternalMonitor_SelfTest_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExternalMonitorServer).SelfTest(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ExternalMonitor_TailLogs_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(TailLogsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ExternalMonitorServer).TailLogs(m, &grpc.GenericServerStream[TailLogsRequest, LogLine]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ExternalMonitor_TailLogsServer = grpc.ServerStreamingServer[LogLine]
+
 // ExternalMonitor_ServiceDesc is the grpc.ServiceDesc for ExternalMonitor service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -224,7 +304,17 @@ var ExternalMonitor_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Stop",
 			Handler:    _ExternalMonitor_Stop_Handler,
 		},
+		{
+			MethodName: "SelfTest",
+			Handler:    _ExternalMonitor_SelfTest_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "TailLogs",
+			Handler:       _ExternalMonitor_TailLogs_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "api/services/external/v1/external_monitor.proto",
 }
@@ -0,0 +1,64 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+
+	"k8s.io/npd-ext/pkg/externalmonitor"
+)
+
+// checkExternalMonitorConnectivity checks the external monitor plugins once
+// and exits instead of running the problem detector.
+var checkExternalMonitorConnectivity bool
+
+// checkConnectivity checks the plugin of every external monitor configuration
+// and prints the reports, failing if any plugin could not be checked.
+func checkConnectivity(configPaths []string) error {
+	if len(configPaths) == 0 {
+		return fmt.Errorf("no external monitor configuration to check, set --config.%s", externalmonitor.MonitorName)
+	}
+
+	failed := 0
+	for _, configPath := range configPaths {
+		config, err := externalmonitor.LoadConfiguration(configPath)
+		if err == nil {
+			err = config.ApplyConfiguration()
+		}
+		if err != nil {
+			fmt.Printf("%s: FAILED: %v\n\n", configPath, err)
+			failed++
+			continue
+		}
+
+		report, err := externalmonitor.CheckConnectivity(config)
+		if report != nil {
+			fmt.Print(report)
+		}
+		if err != nil {
+			fmt.Printf("%s: FAILED: %v\n\n", configPath, err)
+			failed++
+			continue
+		}
+		fmt.Printf("%s: OK\n\n", configPath)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d external monitors failed the connectivity check", failed, len(configPaths))
+	}
+	return nil
+}
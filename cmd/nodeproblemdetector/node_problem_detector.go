@@ -25,7 +25,7 @@ import (
 	_ "k8s.io/node-problem-detector/cmd/nodeproblemdetector/exporterplugins"
 	_ "k8s.io/node-problem-detector/cmd/nodeproblemdetector/problemdaemonplugins"
 	// Our external monitor plugin
-	_ "k8s.io/npd-ext/pkg/externalmonitor"
+	"k8s.io/npd-ext/pkg/externalmonitor"
 	"k8s.io/node-problem-detector/cmd/options"
 	"k8s.io/node-problem-detector/pkg/exporters"
 	"k8s.io/node-problem-detector/pkg/exporters/k8sexporter"
@@ -36,12 +36,25 @@ import (
 	"k8s.io/node-problem-detector/pkg/version"
 )
 
+// externalMonitorDebugAddress is the address to serve the external monitor
+// debug endpoint on. The endpoint is disabled when empty.
+var externalMonitorDebugAddress string
+
+// allowUnknownConfigFields makes external monitor configurations ignore keys
+// that match no field instead of failing to load.
+var allowUnknownConfigFields bool
+
 func npdMain(ctx context.Context, npdo *options.NodeProblemDetectorOptions) error {
 	if npdo.PrintVersion {
 		version.PrintVersion()
 		return nil
 	}
 
+	externalmonitor.AllowUnknownConfigFields = allowUnknownConfigFields
+	if checkExternalMonitorConnectivity {
+		return checkConnectivity(*npdo.MonitorConfigPaths[externalmonitor.MonitorName])
+	}
+
 	npdo.SetNodeNameOrDie()
 	npdo.SetConfigFromDeprecatedOptionsOrDie()
 	npdo.ValidOrDie()
@@ -52,6 +65,10 @@ func npdMain(ctx context.Context, npdo *options.NodeProblemDetectorOptions) erro
 		klog.Fatalf("No problem daemon is configured")
 	}
 
+	if externalMonitorDebugAddress != "" {
+		externalmonitor.StartDebugServer(externalMonitorDebugAddress)
+	}
+
 	// Initialize exporters.
 	defaultExporters := []types.Exporter{}
 	if ke := k8sexporter.NewExporterOrDie(ctx, npdo); ke != nil {
@@ -49,6 +49,13 @@ func main() {
 	if err := npdo.AddFlags(pflag.CommandLine); err != nil {
 		klog.Fatalf("Failed to add flags: %v", err)
 	}
+	pflag.CommandLine.StringVar(&externalMonitorDebugAddress, "external-monitor-debug-address", "",
+		"The address to serve the external monitor debug endpoint on, e.g. 127.0.0.1:20258. Disabled when empty.")
+
+	pflag.CommandLine.BoolVar(&allowUnknownConfigFields, "allow-unknown-config-fields", false,
+		"Ignore external monitor configuration keys that match no field, with a warning, instead of failing. Allows newer configurations on older binaries.")
+	pflag.CommandLine.BoolVar(&checkExternalMonitorConnectivity, "check-external-monitor-connectivity", false,
+		"Dial the plugin of each --config.external-monitor configuration, fetch its metadata and run one health check, print the results and exit.")
 
 	pflag.Parse()
 	if err := npdMain(context.Background(), npdo); err != nil {
@@ -0,0 +1,165 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "k8s.io/npd-ext/api/services/external/v1"
+)
+
+// versionConstraint is one comparison of a driver version range, e.g. ">=535.104".
+type versionConstraint struct {
+	op      string
+	version string
+}
+
+// versionOperators are the constraint operators, longest first so that ">="
+// is not read as ">".
+var versionOperators = []string{">=", "<=", "!=", ">", "<", "="}
+
+// driverVersionRange is the expected driver version given with
+// --expected-driver-version, a comma-separated list of constraints that must
+// all hold, e.g. ">=535.104,<550". A version without an operator matches
+// itself and every version it is a prefix of, so "535" matches "535.104.05".
+type driverVersionRange struct {
+	spec        string
+	constraints []versionConstraint
+}
+
+// parseDriverVersionRange parses the --expected-driver-version value.
+func parseDriverVersionRange(spec string) (*driverVersionRange, error) {
+	r := &driverVersionRange{spec: spec}
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		c := versionConstraint{version: part}
+		for _, op := range versionOperators {
+			if strings.HasPrefix(part, op) {
+				c = versionConstraint{op: op, version: strings.TrimSpace(part[len(op):])}
+				break
+			}
+		}
+		if !validVersion(c.version) {
+			return nil, fmt.Errorf("invalid driver version constraint %q", part)
+		}
+		r.constraints = append(r.constraints, c)
+	}
+	return r, nil
+}
+
+// validVersion reports whether a version is made of dot-separated numbers.
+func validVersion(version string) bool {
+	if version == "" {
+		return false
+	}
+	for _, component := range strings.Split(version, ".") {
+		if _, err := strconv.ParseUint(component, 10, 32); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// compareVersions compares two valid versions component by component, treating
+// missing components as zero. It returns -1, 0 or 1.
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < max(len(as), len(bs)); i++ {
+		var x, y uint64
+		if i < len(as) {
+			x, _ = strconv.ParseUint(as[i], 10, 32)
+		}
+		if i < len(bs) {
+			y, _ = strconv.ParseUint(bs[i], 10, 32)
+		}
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+	return 0
+}
+
+// matches reports whether a version satisfies every constraint of the range.
+func (r *driverVersionRange) matches(version string) bool {
+	if !validVersion(version) {
+		return false
+	}
+	for _, c := range r.constraints {
+		cmp := compareVersions(version, c.version)
+		var ok bool
+		switch c.op {
+		case "":
+			ok = version == c.version || strings.HasPrefix(version, c.version+".")
+		case "=":
+			ok = cmp == 0
+		case "!=":
+			ok = cmp != 0
+		case ">":
+			ok = cmp > 0
+		case ">=":
+			ok = cmp >= 0
+		case "<":
+			ok = cmp < 0
+		case "<=":
+			ok = cmp <= 0
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// parseDriverVersion parses the driver_version field of nvidia-smi. It
+// returns "" if the driver doesn't report it.
+func parseDriverVersion(value string) string {
+	value = strings.TrimSpace(value)
+	if value == "N/A" || strings.HasPrefix(value, "[") {
+		return ""
+	}
+	return value
+}
+
+// driverVersionCondition reports whether the driver version is in the
+// expected range.
+func driverVersionCondition(expected *driverVersionRange, version string) *pb.Condition {
+	condition := &pb.Condition{
+		Type:       "GPUDriverVersionMismatch",
+		Status:     pb.ConditionStatus_CONDITION_STATUS_FALSE,
+		Transition: timestamppb.Now(),
+		Reason:     "GPUDriverVersionMatches",
+		Message:    fmt.Sprintf("GPU driver version %s matches expected %s", version, expected.spec),
+	}
+	switch {
+	case version == "":
+		condition.Status = pb.ConditionStatus_CONDITION_STATUS_UNKNOWN
+		condition.Reason = "GPUDriverVersionUnknown"
+		condition.Message = "GPU driver version not reported by nvidia-smi"
+	case !expected.matches(version):
+		condition.Status = pb.ConditionStatus_CONDITION_STATUS_TRUE
+		condition.Reason = "GPUDriverVersionMismatch"
+		condition.Message = fmt.Sprintf("GPU driver version %s does not match expected %s", version, expected.spec)
+	}
+	return condition
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	pb "k8s.io/npd-ext/api/services/external/v1"
+)
+
+func TestParseDriverVersionRange(t *testing.T) {
+	testCases := []struct {
+		spec    string
+		wantErr bool
+	}{
+		{spec: "535.104.05"},
+		{spec: "535"},
+		{spec: ">=535.104,<550"},
+		{spec: " >= 535.104 , != 545.23.06 "},
+		{spec: "", wantErr: true},
+		{spec: ">=", wantErr: true},
+		{spec: ">=535,", wantErr: true},
+		{spec: "535.x", wantErr: true},
+		{spec: "~535", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.spec, func(t *testing.T) {
+			_, err := parseDriverVersionRange(tc.spec)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("parseDriverVersionRange(%q) error = %v, wantErr %v", tc.spec, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestDriverVersionRangeMatches(t *testing.T) {
+	testCases := []struct {
+		spec    string
+		version string
+		want    bool
+	}{
+		{spec: "535.104.05", version: "535.104.05", want: true},
+		{spec: "535.104.05", version: "535.104.12", want: false},
+		// A bare version matches whole components only
+		{spec: "535", version: "535.104.05", want: true},
+		{spec: "535.10", version: "535.104.05", want: false},
+		{spec: "=535.104", version: "535.104.0", want: true},
+		{spec: "=535.104", version: "535.104.05", want: false},
+		{spec: ">=535.104,<550", version: "535.104.05", want: true},
+		{spec: ">=535.104,<550", version: "545.23.06", want: true},
+		{spec: ">=535.104,<550", version: "550.54.14", want: false},
+		{spec: ">=535.104,<550", version: "535.86.10", want: false},
+		// Components compare numerically
+		{spec: ">535.9", version: "535.10", want: true},
+		{spec: "<=470.82", version: "470.82", want: true},
+		{spec: "!=545.23.06", version: "545.23.06", want: false},
+		{spec: "!=545.23.06", version: "545.23.08", want: true},
+		{spec: ">=0", version: "", want: false},
+		{spec: ">=0", version: "N/A", want: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.spec+" "+tc.version, func(t *testing.T) {
+			r, err := parseDriverVersionRange(tc.spec)
+			if err != nil {
+				t.Fatalf("parseDriverVersionRange(%q) failed: %v", tc.spec, err)
+			}
+			if got := r.matches(tc.version); got != tc.want {
+				t.Errorf("matches(%q) = %v, want %v", tc.version, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseDriverVersion(t *testing.T) {
+	testCases := map[string]string{
+		"535.104.05":      "535.104.05",
+		" 550.54.14 ":     "550.54.14",
+		"N/A":             "",
+		"[Not Supported]": "",
+		"":                "",
+	}
+	for value, want := range testCases {
+		if got := parseDriverVersion(value); got != want {
+			t.Errorf("parseDriverVersion(%q) = %q, want %q", value, got, want)
+		}
+	}
+}
+
+func TestDriverVersionCondition(t *testing.T) {
+	expected, err := parseDriverVersionRange(">=535.104,<550")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		version    string
+		wantStatus pb.ConditionStatus
+		wantReason string
+	}{
+		{version: "535.104.05", wantStatus: pb.ConditionStatus_CONDITION_STATUS_FALSE, wantReason: "GPUDriverVersionMatches"},
+		{version: "550.54.14", wantStatus: pb.ConditionStatus_CONDITION_STATUS_TRUE, wantReason: "GPUDriverVersionMismatch"},
+		{version: "", wantStatus: pb.ConditionStatus_CONDITION_STATUS_UNKNOWN, wantReason: "GPUDriverVersionUnknown"},
+	}
+
+	for _, tc := range testCases {
+		condition := driverVersionCondition(expected, tc.version)
+		if condition.Type != "GPUDriverVersionMismatch" {
+			t.Errorf("version %q: type = %q, want GPUDriverVersionMismatch", tc.version, condition.Type)
+		}
+		if condition.Status != tc.wantStatus || condition.Reason != tc.wantReason {
+			t.Errorf("version %q: got %v/%s, want %v/%s", tc.version, condition.Status, condition.Reason, tc.wantStatus, tc.wantReason)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// fakeStats are scripted GPU readings that replace nvidia-smi, so the
+// condition and event logic can be exercised without a GPU. For testing only.
+type fakeStats struct {
+	Temperature     int          `json:"temperature"`
+	MemoryUsed      int          `json:"memoryUsed"`
+	MemoryTotal     int          `json:"memoryTotal"`
+	PowerUsage      *float64     `json:"powerUsage,omitempty"`
+	ThrottleReasons []string     `json:"throttleReasons,omitempty"`
+	DriverVersion   string       `json:"driverVersion,omitempty"`
+	Processes       []GPUProcess `json:"processes,omitempty"`
+}
+
+// parseFakeStats parses the --fake-stats JSON value.
+func parseFakeStats(value string) (*fakeStats, error) {
+	fake := &fakeStats{}
+	if err := json.Unmarshal([]byte(value), fake); err != nil {
+		return nil, fmt.Errorf("invalid fake stats: %v", err)
+	}
+	if fake.MemoryTotal < 0 || fake.MemoryUsed < 0 || fake.MemoryUsed > fake.MemoryTotal {
+		return nil, fmt.Errorf("invalid fake stats: memoryUsed must be between 0 and memoryTotal")
+	}
+	return fake, nil
+}
+
+// stats returns the scripted readings as GPU statistics.
+func (f *fakeStats) stats() *GPUStats {
+	stats := &GPUStats{
+		Temperature:     f.Temperature,
+		MemoryUsed:      f.MemoryUsed,
+		MemoryTotal:     f.MemoryTotal,
+		Available:       true,
+		RawOutput:       "fake stats",
+		ThrottleReasons: f.ThrottleReasons,
+		DriverVersion:   f.DriverVersion,
+	}
+	if f.PowerUsage != nil {
+		stats.PowerUsage, stats.PowerAvailable = *f.PowerUsage, true
+	}
+	if f.MemoryTotal > 0 {
+		stats.MemoryPercent = float64(f.MemoryUsed) / float64(f.MemoryTotal) * 100.0
+	}
+	return stats
+}
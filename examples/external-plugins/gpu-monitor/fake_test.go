@@ -0,0 +1,99 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFakeStats(t *testing.T) {
+	testCases := []struct {
+		name    string
+		value   string
+		want    *GPUStats
+		wantErr bool
+	}{
+		{
+			name:  "memory and temperature",
+			value: `{"temperature":90,"memoryUsed":1000,"memoryTotal":2000}`,
+			want: &GPUStats{
+				Temperature:   90,
+				MemoryUsed:    1000,
+				MemoryTotal:   2000,
+				MemoryPercent: 50,
+				Available:     true,
+				RawOutput:     "fake stats",
+			},
+		},
+		{
+			name:  "all readings",
+			value: `{"temperature":70,"memoryUsed":0,"memoryTotal":4000,"powerUsage":150.5,"throttleReasons":["HwSlowdown"],"driverVersion":"535.104.05"}`,
+			want: &GPUStats{
+				Temperature:     70,
+				MemoryTotal:     4000,
+				Available:       true,
+				PowerUsage:      150.5,
+				PowerAvailable:  true,
+				ThrottleReasons: []string{"HwSlowdown"},
+				DriverVersion:   "535.104.05",
+				RawOutput:       "fake stats",
+			},
+		},
+		{
+			name:  "no memory total",
+			value: `{"temperature":40}`,
+			want:  &GPUStats{Temperature: 40, Available: true, RawOutput: "fake stats"},
+		},
+		{name: "more used than total", value: `{"memoryUsed":3000,"memoryTotal":2000}`, wantErr: true},
+		{name: "negative memory", value: `{"memoryUsed":-1,"memoryTotal":2000}`, wantErr: true},
+		{name: "invalid JSON", value: `{"temperature":`, wantErr: true},
+		{name: "wrong type", value: `{"temperature":"hot"}`, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fake, err := parseFakeStats(tc.value)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("parseFakeStats(%s) error = %v, wantErr %v", tc.value, err, tc.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := fake.stats(); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("stats() = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFakeProcesses(t *testing.T) {
+	fake, err := parseFakeStats(`{"memoryUsed":1000,"memoryTotal":2000,"processes":[{"pid":1234,"name":"python","usedMemoryMB":1000,"podUID":"0f3a7c1e-2b4d-4e6f-8a9b-1c2d3e4f5a6b"}]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := &GPUMonitor{fake: fake}
+	processes, err := m.gpuProcesses(t.Context())
+	if err != nil {
+		t.Fatalf("gpuProcesses() failed: %v", err)
+	}
+	want := []GPUProcess{{PID: 1234, Name: "python", UsedMemoryMB: 1000, PodUID: "0f3a7c1e-2b4d-4e6f-8a9b-1c2d3e4f5a6b"}}
+	if !reflect.DeepEqual(processes, want) {
+		t.Errorf("gpuProcesses() = %v, want %v", processes, want)
+	}
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"strings"
+	"sync"
+
+	pb "k8s.io/npd-ext/api/services/external/v1"
+)
+
+// logBufferLines is how many recent log lines TailLogs can return.
+const logBufferLines = 500
+
+// recentLogs keeps the recent output of the standard logger for TailLogs.
+var recentLogs = newLogBuffer(logBufferLines)
+
+// logBuffer is an io.Writer keeping the most recent log lines in a ring.
+type logBuffer struct {
+	mu    sync.Mutex
+	lines []string
+	next  int
+	full  bool
+}
+
+func newLogBuffer(size int) *logBuffer {
+	return &logBuffer{lines: make([]string, size)}
+}
+
+// Write records each line in p. The standard logger writes one line per call.
+func (b *logBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, line := range strings.Split(strings.TrimSuffix(string(p), "\n"), "\n") {
+		b.lines[b.next] = line
+		b.next = (b.next + 1) % len(b.lines)
+		if b.next == 0 {
+			b.full = true
+		}
+	}
+	return len(p), nil
+}
+
+// recent returns up to n of the most recent lines, oldest first. n <= 0
+// returns all buffered lines.
+func (b *logBuffer) recent(n int) []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var lines []string
+	if b.full {
+		lines = append(lines, b.lines[b.next:]...)
+	}
+	lines = append(lines, b.lines[:b.next]...)
+	if n > 0 && n < len(lines) {
+		lines = lines[len(lines)-n:]
+	}
+	return lines
+}
+
+// TailLogs implements the ExternalMonitor.TailLogs gRPC method.
+func (m *GPUMonitor) TailLogs(req *pb.TailLogsRequest, stream pb.ExternalMonitor_TailLogsServer) error {
+	logV(1, "TailLogs called (max lines: %d)", req.MaxLines)
+
+	for _, line := range recentLogs.recent(int(req.MaxLines)) {
+		if err := stream.Send(&pb.LogLine{Line: line}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
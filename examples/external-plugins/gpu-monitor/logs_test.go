@@ -0,0 +1,72 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+	"testing"
+)
+
+func TestLogBufferRecent(t *testing.T) {
+	testCases := []struct {
+		name   string
+		writes []string
+		n      int
+		want   []string
+	}{
+		{name: "empty", n: 10},
+		{name: "fewer than n", writes: []string{"a\n", "b\n"}, n: 10, want: []string{"a", "b"}},
+		{name: "last n", writes: []string{"a\n", "b\n", "c\n"}, n: 2, want: []string{"b", "c"}},
+		{name: "all", writes: []string{"a\n", "b\n", "c\n"}, n: 0, want: []string{"a", "b", "c"}},
+		{name: "wrapped", writes: []string{"a\n", "b\n", "c\n", "d\n", "e\n", "f\n"}, n: 0, want: []string{"c", "d", "e", "f"}},
+		{name: "exactly full", writes: []string{"a\n", "b\n", "c\n", "d\n"}, n: 0, want: []string{"a", "b", "c", "d"}},
+		{name: "several lines per write", writes: []string{"a\nb\n", "c"}, n: 0, want: []string{"a", "b", "c"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			b := newLogBuffer(4)
+			for _, write := range tc.writes {
+				b.Write([]byte(write))
+			}
+			if got := b.recent(tc.n); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("recent(%d) = %q, want %q", tc.n, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestLogBufferAsLoggerOutput(t *testing.T) {
+	b := newLogBuffer(logBufferLines)
+	logger := log.New(b, "", 0)
+	for i := 0; i < logBufferLines+10; i++ {
+		logger.Printf("line %d", i)
+	}
+
+	lines := b.recent(0)
+	if len(lines) != logBufferLines {
+		t.Fatalf("recent(0) returned %d lines, want %d", len(lines), logBufferLines)
+	}
+	if want := "line 10"; lines[0] != want {
+		t.Errorf("oldest line = %q, want %q", lines[0], want)
+	}
+	if want := fmt.Sprintf("line %d", logBufferLines+9); lines[len(lines)-1] != want {
+		t.Errorf("newest line = %q, want %q", lines[len(lines)-1], want)
+	}
+}
@@ -20,19 +20,29 @@ package main
 
 import (
 	"context"
+	"crypto/ecdh"
+	"crypto/sha256"
+	"encoding/hex"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"net"
 	"os"
 	"os/exec"
 	"os/signal"
-	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"syscall"
+	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/emptypb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
@@ -44,8 +54,23 @@ var (
 	temperatureThreshold = flag.Int("temp-threshold", 85, "Temperature threshold in Celsius")
 	memoryThreshold   = flag.Float64("memory-threshold", 95.0, "Memory usage threshold in percentage")
 	version           = flag.String("version", "1.0.0", "Monitor version")
+	enableReflection  = flag.Bool("enable-reflection", false, "Register gRPC server reflection for debugging with grpcurl (not for production)")
+	createSocketDir   = flag.Bool("create-socket-dir", false, "Create the socket directory if it does not exist")
+	verbosity         = flag.Int("v", 0, "Log verbosity: 1 logs every RPC, 2 also logs GPU stats. Warnings and errors are always logged")
+	fakeStatsJSON     = flag.String("fake-stats", "", `FOR TESTING ONLY: report these GPU readings instead of running nvidia-smi, as JSON, e.g. {"temperature":90,"memoryUsed":1000,"memoryTotal":2000,"powerUsage":150}`)
+	attributeProcesses = flag.Bool("attribute-processes", false, "Attribute GPU problems to the compute processes and pods using the GPU")
+	expectedDriverVersion = flag.String("expected-driver-version", "", `Report GPUDriverVersionMismatch unless the driver version is in this range, e.g. "535.104.05" or ">=535.104,<550"`)
+	recommendedInterval = flag.Duration("recommended-interval", 30*time.Second, "Check interval recommended to NPD in the metadata, 0 to recommend none")
+	parameterKeyFile  = flag.String("parameter-key-file", "", "File holding the hex encoded X25519 key NPD encrypts sensitive parameters to, generated if missing. Empty to accept plain parameters only")
 )
 
+// logV logs a routine message when the verbosity is at least level.
+func logV(level int, format string, args ...interface{}) {
+	if *verbosity >= level {
+		log.Printf(format, args...)
+	}
+}
+
 // GPUMonitor implements the ExternalMonitor gRPC service.
 type GPUMonitor struct {
 	pb.UnimplementedExternalMonitorServer
@@ -53,6 +78,28 @@ type GPUMonitor struct {
 	tempThreshold   int
 	memThreshold    float64
 	version         string
+	configHash      string
+	startedAt       time.Time
+
+	// attributeProcesses names the processes using the GPU in problem reports
+	attributeProcesses bool
+
+	// recommendedInterval is advertised in the metadata, zero for none
+	recommendedInterval time.Duration
+
+	// parameterKey decrypts parameters NPD encrypted to its public key, nil
+	// to accept plain parameters only
+	parameterKey *ecdh.PrivateKey
+
+	// expectedDriver is the driver version range checked by
+	// GPUDriverVersionMismatch, nil to skip the check
+	expectedDriver *driverVersionRange
+
+	// driverVersion is the driver version last read from nvidia-smi
+	driverVersion atomic.Value
+
+	// fake replaces nvidia-smi with scripted readings, for testing only
+	fake *fakeStats
 	shutdownChan    chan struct{}
 }
 
@@ -62,9 +109,26 @@ type GPUStats struct {
 	MemoryUsed    int
 	MemoryTotal   int
 	MemoryPercent float64
-	PowerUsage    int
+	PowerUsage    float64
 	Available     bool
 	ErrorMessage  string
+	RawOutput     string
+
+	// PowerAvailable is false if the GPU or driver doesn't report power draw
+	PowerAvailable bool
+
+	// DriverVersion is the GPU driver version, empty if not reported
+	DriverVersion string
+
+	// ThrottleReasons lists the active clock throttle reasons that degrade performance
+	ThrottleReasons []string
+}
+
+// details returns diagnostics attached to GPU problem events.
+func (s *GPUStats) details() map[string]string {
+	return map[string]string{
+		"nvidia_smi_output": s.RawOutput,
+	}
 }
 
 // NewGPUMonitor creates a new GPU monitor instance.
@@ -73,34 +137,52 @@ func NewGPUMonitor(tempThreshold int, memThreshold float64, version string) *GPU
 		tempThreshold: tempThreshold,
 		memThreshold:  memThreshold,
 		version:       version,
+		startedAt:     time.Now(),
 		shutdownChan:  make(chan struct{}),
 	}
 }
 
 // CheckHealth implements the ExternalMonitor.CheckHealth gRPC method.
 func (m *GPUMonitor) CheckHealth(ctx context.Context, req *pb.HealthCheckRequest) (*pb.Status, error) {
-	log.Printf("CheckHealth called (sequence: %d)", req.Sequence)
+	logV(1, "CheckHealth called (sequence: %d)", req.Sequence)
 
 	// Check for parameter overrides
+	parameters, err := m.requestParameters(req)
+	if err != nil {
+		return nil, err
+	}
 	tempThreshold := m.tempThreshold
 	memThreshold := m.memThreshold
 
-	if threshold, ok := req.Parameters["temperature_threshold"]; ok {
+	if threshold, ok := parameters["temperature_threshold"]; ok {
 		if val, err := strconv.Atoi(threshold); err == nil {
 			tempThreshold = val
 		}
 	}
 
-	if threshold, ok := req.Parameters["memory_threshold"]; ok {
+	if threshold, ok := parameters["memory_threshold"]; ok {
 		if val, err := strconv.ParseFloat(threshold, 64); err == nil {
 			memThreshold = val
 		}
 	}
 
+	// Only evaluate selected conditions, skipping nvidia-smi if none of ours are
+	selected := func(conditionType string) bool {
+		return len(req.Conditions) == 0 || slices.Contains(req.Conditions, conditionType)
+	}
+	checkDriver := m.expectedDriver != nil && selected("GPUDriverVersionMismatch")
+	if !selected("GPUHealthy") && !selected("GPUThrottled") && !checkDriver {
+		return &pb.Status{Source: "gpu-monitor"}, nil
+	}
+
 	// Get GPU statistics
-	stats, err := m.getGPUStats()
+	stats, err := m.gpuStats(ctx)
 	if err != nil {
 		log.Printf("Failed to get GPU stats: %v", err)
+		// The proxy gave up on this call, report the deadline instead of a status
+		if ctx.Err() != nil {
+			return nil, status.FromContextError(ctx.Err()).Err()
+		}
 		// Return status indicating monitoring error
 		return &pb.Status{
 			Source: "gpu-monitor",
@@ -118,7 +200,7 @@ func (m *GPUMonitor) CheckHealth(ctx context.Context, req *pb.HealthCheckRequest
 
 	// Check if GPU is available
 	if !stats.Available {
-		return &pb.Status{
+		unavailable := &pb.Status{
 			Source: "gpu-monitor",
 			Events: []*pb.Event{
 				{
@@ -137,14 +219,30 @@ func (m *GPUMonitor) CheckHealth(ctx context.Context, req *pb.HealthCheckRequest
 					Message:    "GPU not available for monitoring",
 				},
 			},
-		}, nil
+		}
+		if checkDriver {
+			unavailable.Conditions = append(unavailable.Conditions, &pb.Condition{
+				Type:       "GPUDriverVersionMismatch",
+				Status:     pb.ConditionStatus_CONDITION_STATUS_UNKNOWN,
+				Transition: timestamppb.Now(),
+				Reason:     "GPUNotAvailable",
+				Message:    "No GPU detected to check the driver version of",
+			})
+		}
+		return unavailable, nil
 	}
+	m.driverVersion.Store(stats.DriverVersion)
 
 	// Analyze GPU health
 	events := []*pb.Event{}
 	isHealthy := true
 	var reason, message string
 
+	// How far past its threshold the worst reading is, negative while every
+	// reading is below its threshold so that NPD can apply a clear margin
+	breach := max(breachMagnitude(float64(stats.Temperature), float64(tempThreshold)),
+		breachMagnitude(stats.MemoryPercent, memThreshold))
+
 	// Check temperature
 	if stats.Temperature > tempThreshold {
 		isHealthy = false
@@ -152,10 +250,12 @@ func (m *GPUMonitor) CheckHealth(ctx context.Context, req *pb.HealthCheckRequest
 		message = fmt.Sprintf("GPU temperature %d°C exceeds threshold %d°C", stats.Temperature, tempThreshold)
 
 		events = append(events, &pb.Event{
-			Severity:  pb.Severity_SEVERITY_WARN,
-			Timestamp: timestamppb.Now(),
-			Reason:    "GPUOverheating",
-			Message:   message,
+			Severity:    pb.Severity_SEVERITY_WARN,
+			Timestamp:   timestamppb.Now(),
+			Reason:      "GPUOverheating",
+			Message:     message,
+			Details:     stats.details(),
+			Remediation: "Reduce the GPU workload and check the node cooling and airflow",
 		})
 	}
 
@@ -175,14 +275,33 @@ func (m *GPUMonitor) CheckHealth(ctx context.Context, req *pb.HealthCheckRequest
 			Timestamp: timestamppb.Now(),
 			Reason:    "GPUMemoryHigh",
 			Message:   fmt.Sprintf("GPU memory usage %.1f%% exceeds threshold %.1f%%", stats.MemoryPercent, memThreshold),
+			Details:   stats.details(),
 		})
 	}
 
+	// Name the workloads using the GPU, to tell tenants of a shared GPU apart
+	if !isHealthy && m.attributeProcesses {
+		processes, err := m.gpuProcesses(ctx)
+		if err != nil {
+			log.Printf("Warning: failed to attribute GPU usage to processes: %v", err)
+		} else {
+			logV(2, "GPU processes: %v", processes)
+			if len(processes) > 0 {
+				message = fmt.Sprintf("%s; top consumer: %s", message, processes[0])
+			} else {
+				message = fmt.Sprintf("%s; no compute processes are using the GPU", message)
+			}
+			for _, event := range events {
+				event.Details["gpu_processes"] = describeProcesses(processes)
+			}
+		}
+	}
+
 	// Set healthy status
 	if isHealthy {
 		reason = "GPUIsHealthy"
-		message = fmt.Sprintf("GPU is healthy: temp=%d°C, memory=%.1f%%, power=%dW",
-			stats.Temperature, stats.MemoryPercent, stats.PowerUsage)
+		message = fmt.Sprintf("GPU is healthy: temp=%d°C, memory=%.1f%%, power=%s",
+			stats.Temperature, stats.MemoryPercent, formatPower(stats.PowerUsage, stats.PowerAvailable))
 	}
 
 	conditionStatus := pb.ConditionStatus_CONDITION_STATUS_FALSE // Healthy
@@ -190,38 +309,124 @@ func (m *GPUMonitor) CheckHealth(ctx context.Context, req *pb.HealthCheckRequest
 		conditionStatus = pb.ConditionStatus_CONDITION_STATUS_TRUE // Problem
 	}
 
-	return &pb.Status{
-		Source: "gpu-monitor",
-		Events: events,
-		Conditions: []*pb.Condition{
-			{
-				Type:       "GPUHealthy",
-				Status:     conditionStatus,
-				Transition: timestamppb.Now(),
-				Reason:     reason,
-				Message:    message,
-			},
+	conditions := []*pb.Condition{
+		{
+			Type:            "GPUHealthy",
+			Status:          conditionStatus,
+			Transition:      timestamppb.Now(),
+			Reason:          reason,
+			Message:         message,
+			BreachMagnitude: breach,
 		},
+	}
+
+	// Check clock throttling
+	throttled := &pb.Condition{
+		Type:       "GPUThrottled",
+		Status:     pb.ConditionStatus_CONDITION_STATUS_FALSE,
+		Transition: timestamppb.Now(),
+		Reason:     "GPUNotThrottled",
+		Message:    "GPU clocks are not throttled",
+	}
+	if len(stats.ThrottleReasons) > 0 {
+		throttled.Status = pb.ConditionStatus_CONDITION_STATUS_TRUE
+		throttled.Reason = "GPUThrottled"
+		throttled.Message = fmt.Sprintf("GPU clocks are throttled: %s", strings.Join(stats.ThrottleReasons, ", "))
+
+		events = append(events, &pb.Event{
+			Severity:  pb.Severity_SEVERITY_WARN,
+			Timestamp: timestamppb.Now(),
+			Reason:    "GPUThrottled",
+			Message:   throttled.Message,
+			Details:   stats.details(),
+		})
+	}
+	conditions = append(conditions, throttled)
+
+	// Catch nodes left on another driver by a partial upgrade
+	if checkDriver {
+		conditions = append(conditions, driverVersionCondition(m.expectedDriver, stats.DriverVersion))
+	}
+
+	conditions = slices.DeleteFunc(conditions, func(condition *pb.Condition) bool {
+		return !selected(condition.Type)
+	})
+
+	return &pb.Status{
+		Source:     "gpu-monitor",
+		Events:     events,
+		Conditions: conditions,
 	}, nil
 }
 
+// breachMagnitude returns how far value is past threshold, relative to the
+// threshold: 0.1 for a value 10% over its threshold, -0.1 for 10% under it.
+func breachMagnitude(value, threshold float64) float64 {
+	if threshold <= 0 {
+		return 0
+	}
+	return (value - threshold) / threshold
+}
+
 // GetMetadata implements the ExternalMonitor.GetMetadata gRPC method.
 func (m *GPUMonitor) GetMetadata(ctx context.Context, req *emptypb.Empty) (*pb.MonitorMetadata, error) {
-	log.Println("GetMetadata called")
+	logV(1, "GetMetadata called")
+
+	// GetMetadata is called once per connection, identify the client
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		log.Printf("Client connected with user-agent %q", strings.Join(md.Get("user-agent"), " "))
+	}
 
-	return &pb.MonitorMetadata{
+	supported := []string{"GPUHealthy", "GPUThrottled"}
+	if m.expectedDriver != nil {
+		supported = append(supported, "GPUDriverVersionMismatch")
+	}
+
+	meta := &pb.MonitorMetadata{
 		Name:        "gpu-monitor",
 		Version:     m.version,
 		Description: "Monitors NVIDIA GPU health including temperature and memory usage",
-		SupportedConditions: []string{"GPUHealthy"},
+		SupportedConditions: supported,
 		Capabilities: map[string]string{
 			"temperature_monitoring": "true",
 			"memory_monitoring":      "true",
 			"power_monitoring":       "true",
+			"throttle_monitoring":    "true",
 			"nvidia_smi_required":    "true",
 		},
+		DefaultParameters: map[string]string{
+			"temperature_threshold": strconv.Itoa(m.tempThreshold),
+			"memory_threshold":      strconv.FormatFloat(m.memThreshold, 'f', -1, 64),
+		},
+		Parameters: []*pb.ParameterSpec{
+			{
+				Name:         "temperature_threshold",
+				Type:         pb.ParameterType_PARAMETER_TYPE_INT,
+				DefaultValue: strconv.Itoa(m.tempThreshold),
+				Description:  "Temperature threshold in Celsius",
+			},
+			{
+				Name:         "memory_threshold",
+				Type:         pb.ParameterType_PARAMETER_TYPE_FLOAT,
+				DefaultValue: strconv.FormatFloat(m.memThreshold, 'f', -1, 64),
+				Description:  "Memory usage threshold in percentage",
+			},
+		},
 		ApiVersion: "v1",
-	}, nil
+		StartedAt:  timestamppb.New(m.startedAt),
+		ConfigHash: m.configHash,
+	}
+	if version := m.currentDriverVersion(ctx); version != "" {
+		meta.Capabilities["driver_version"] = version
+	}
+	if m.recommendedInterval > 0 {
+		// GPU temperature and memory change slowly, nvidia-smi is relatively costly
+		meta.RecommendedInterval = durationpb.New(m.recommendedInterval)
+	}
+	if m.parameterKey != nil {
+		meta.ParameterEncryptionKey = m.parameterKey.PublicKey().Bytes()
+	}
+	return meta, nil
 }
 
 // Stop implements the ExternalMonitor.Stop gRPC method.
@@ -232,20 +437,93 @@ func (m *GPUMonitor) Stop(ctx context.Context, req *emptypb.Empty) (*emptypb.Emp
 	return &emptypb.Empty{}, nil
 }
 
-// getGPUStats retrieves GPU statistics using nvidia-smi.
-func (m *GPUMonitor) getGPUStats() (*GPUStats, error) {
+// SelfTest implements the ExternalMonitor.SelfTest gRPC method.
+func (m *GPUMonitor) SelfTest(ctx context.Context, req *emptypb.Empty) (*pb.SelfTestResult, error) {
+	logV(1, "SelfTest called")
+
+	if m.fake != nil {
+		return &pb.SelfTestResult{
+			Passed:  true,
+			Message: "Using fake GPU stats",
+		}, nil
+	}
+
+	if _, err := exec.LookPath("nvidia-smi"); err != nil {
+		return &pb.SelfTestResult{
+			Passed:  false,
+			Message: "nvidia-smi not found in PATH",
+		}, nil
+	}
+
+	if output, err := exec.CommandContext(ctx, "nvidia-smi", "-L").CombinedOutput(); err != nil {
+		if ctx.Err() != nil {
+			return nil, status.FromContextError(ctx.Err()).Err()
+		}
+		return &pb.SelfTestResult{
+			Passed:  false,
+			Message: fmt.Sprintf("nvidia-smi -L failed: %v: %s", err, strings.TrimSpace(string(output))),
+		}, nil
+	}
+
+	return &pb.SelfTestResult{
+		Passed:  true,
+		Message: "nvidia-smi is available",
+	}, nil
+}
+
+// gpuStats returns the fake stats when configured and reads them with
+// nvidia-smi otherwise.
+func (m *GPUMonitor) gpuStats(ctx context.Context) (*GPUStats, error) {
+	if m.fake != nil {
+		return m.fake.stats(), nil
+	}
+	return m.getGPUStats(ctx)
+}
+
+// currentDriverVersion returns the driver version last read, reading the GPU
+// stats if none was read yet. It returns "" without a GPU.
+func (m *GPUMonitor) currentDriverVersion(ctx context.Context) string {
+	if version, _ := m.driverVersion.Load().(string); version != "" {
+		return version
+	}
+
+	stats, err := m.gpuStats(ctx)
+	if err != nil || !stats.Available {
+		return ""
+	}
+	m.driverVersion.Store(stats.DriverVersion)
+	return stats.DriverVersion
+}
+
+// gpuProcesses returns the fake processes when fake stats are configured and
+// lists them with nvidia-smi otherwise.
+func (m *GPUMonitor) gpuProcesses(ctx context.Context) ([]GPUProcess, error) {
+	if m.fake != nil {
+		return m.fake.Processes, nil
+	}
+	return getGPUProcesses(ctx)
+}
+
+// getGPUStats retrieves GPU statistics using nvidia-smi. The command is killed
+// when ctx is done.
+func (m *GPUMonitor) getGPUStats(ctx context.Context) (*GPUStats, error) {
 	// Check if nvidia-smi is available
 	if _, err := exec.LookPath("nvidia-smi"); err != nil {
 		return &GPUStats{Available: false}, nil
 	}
 
 	// Run nvidia-smi to get GPU stats
-	cmd := exec.Command("nvidia-smi",
-		"--query-gpu=temperature.gpu,memory.used,memory.total,power.draw",
+	cmd := exec.CommandContext(ctx, "nvidia-smi",
+		"--query-gpu=temperature.gpu,memory.used,memory.total,power.draw,clocks_throttle_reasons.active,driver_version",
 		"--format=csv,noheader,nounits")
+	// Don't wait on output pipes held open by children of a killed command
+	cmd.WaitDelay = 500 * time.Millisecond
 
 	output, err := cmd.Output()
 	if err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("nvidia-smi did not finish in time: %w", ctx.Err())
+		}
 		return nil, fmt.Errorf("nvidia-smi execution failed: %v", err)
 	}
 
@@ -261,7 +539,7 @@ func (m *GPUMonitor) getGPUStats() (*GPUStats, error) {
 		return nil, fmt.Errorf("unexpected nvidia-smi output format: %s", line)
 	}
 
-	stats := &GPUStats{Available: true}
+	stats := &GPUStats{Available: true, RawOutput: line}
 
 	// Parse temperature
 	if temp, err := strconv.Atoi(strings.TrimSpace(parts[0])); err == nil {
@@ -281,25 +559,46 @@ func (m *GPUMonitor) getGPUStats() (*GPUStats, error) {
 		stats.MemoryPercent = float64(stats.MemoryUsed) / float64(stats.MemoryTotal) * 100.0
 	}
 
-	// Parse power (might contain "N/A")
-	powerStr := strings.TrimSpace(parts[3])
-	if powerStr != "N/A" {
-		// Remove any non-digit characters except decimal point
-		re := regexp.MustCompile(`[^\d.]`)
-		powerStr = re.ReplaceAllString(powerStr, "")
-		if power, err := strconv.ParseFloat(powerStr, 64); err == nil {
-			stats.PowerUsage = int(power)
+	// Parse power, missing on GPUs that don't report it
+	power, available, err := parsePowerDraw(parts[3])
+	if err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	stats.PowerUsage, stats.PowerAvailable = power, available
+
+	// Parse throttle reasons, missing on drivers that don't report them
+	if len(parts) > 4 {
+		reasons, err := parseThrottleReasons(parts[4])
+		if err != nil {
+			log.Printf("Warning: %v", err)
 		}
+		stats.ThrottleReasons = reasons
+	}
+	if len(parts) > 5 {
+		stats.DriverVersion = parseDriverVersion(parts[5])
 	}
 
-	log.Printf("GPU stats: temp=%d°C, memory=%d/%dMB (%.1f%%), power=%dW",
-		stats.Temperature, stats.MemoryUsed, stats.MemoryTotal, stats.MemoryPercent, stats.PowerUsage)
+	logV(2, "GPU stats: temp=%d°C, memory=%d/%dMB (%.1f%%), power=%s, throttle=%v",
+		stats.Temperature, stats.MemoryUsed, stats.MemoryTotal, stats.MemoryPercent,
+		formatPower(stats.PowerUsage, stats.PowerAvailable),
+		stats.ThrottleReasons)
 
 	return stats, nil
 }
 
+// flagsHash returns a hash of the effective value of every flag.
+func flagsHash() string {
+	h := sha256.New()
+	// VisitAll visits flags in lexicographical order
+	flag.VisitAll(func(f *flag.Flag) {
+		fmt.Fprintf(h, "%s=%s\n", f.Name, f.Value.String())
+	})
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
 func main() {
 	flag.Parse()
+	log.SetOutput(io.MultiWriter(os.Stderr, recentLogs))
 
 	log.Printf("Starting GPU Monitor v%s", *version)
 	log.Printf("Socket: %s", *socketPath)
@@ -308,29 +607,70 @@ func main() {
 
 	// Create monitor instance
 	monitor := NewGPUMonitor(*temperatureThreshold, *memoryThreshold, *version)
-
-	// Remove existing socket file
-	if err := os.RemoveAll(*socketPath); err != nil {
-		log.Fatalf("Failed to remove existing socket: %v", err)
+	monitor.configHash = flagsHash()
+	monitor.attributeProcesses = *attributeProcesses
+	monitor.recommendedInterval = *recommendedInterval
+	if *parameterKeyFile != "" {
+		key, err := loadParameterKey(*parameterKeyFile)
+		if err != nil {
+			log.Fatalf("Cannot use --parameter-key-file: %v", err)
+		}
+		monitor.parameterKey = key
 	}
+	if *expectedDriverVersion != "" {
+		expected, err := parseDriverVersionRange(*expectedDriverVersion)
+		if err != nil {
+			log.Fatalf("Cannot use --expected-driver-version: %v", err)
+		}
+		log.Printf("Expected driver version: %s", *expectedDriverVersion)
+		monitor.expectedDriver = expected
+	}
+	if *fakeStatsJSON != "" {
+		fake, err := parseFakeStats(*fakeStatsJSON)
+		if err != nil {
+			log.Fatalf("Cannot use --fake-stats: %v", err)
+		}
+		log.Printf("WARNING: reporting fake GPU stats, for testing only: %s", *fakeStatsJSON)
+		monitor.fake = fake
+	}
+	log.Printf("Config hash: %s", monitor.configHash)
 
-	// Create Unix socket listener
-	listener, err := net.Listen("unix", *socketPath)
+	// Serve on the socket passed by systemd socket activation, if any
+	listener, err := systemdListener()
 	if err != nil {
-		log.Fatalf("Failed to listen on socket %s: %v", *socketPath, err)
+		log.Fatalf("Cannot serve on the socket passed by systemd: %v", err)
 	}
-	defer listener.Close()
+	activated := listener != nil
+	if activated {
+		log.Printf("Using socket %s passed by systemd", listener.Addr())
+	} else {
+		// Check the socket directory and remove a stale socket
+		if err := prepareSocket(*socketPath, *createSocketDir); err != nil {
+			log.Fatalf("Cannot serve on %s: %v", *socketPath, err)
+		}
 
-	// Set socket permissions (readable/writable by owner and group)
-	if err := os.Chmod(*socketPath, 0660); err != nil {
-		log.Printf("Warning: failed to set socket permissions: %v", err)
+		// Create Unix socket listener
+		listener, err = net.Listen("unix", *socketPath)
+		if err != nil {
+			log.Fatalf("Failed to listen on socket %s: %v", *socketPath, err)
+		}
+
+		// Set socket permissions (readable/writable by owner and group)
+		if err := os.Chmod(*socketPath, 0660); err != nil {
+			log.Printf("Warning: failed to set socket permissions: %v", err)
+		}
 	}
+	defer listener.Close()
 
 	// Create gRPC server
 	server := grpc.NewServer()
 	pb.RegisterExternalMonitorServer(server, monitor)
+	if *enableReflection {
+		log.Println("Registering gRPC server reflection")
+		reflection.Register(server)
+	}
 
-	log.Printf("GPU Monitor listening on %s", *socketPath)
+	log.Printf("GPU Monitor listening on %s", listener.Addr())
 
 	// Handle shutdown signals
 	sigChan := make(chan os.Signal, 1)
@@ -358,7 +698,9 @@ func main() {
 	log.Println("Shutting down GPU Monitor...")
 	server.GracefulStop()
 
-	// Clean up socket file
-	os.RemoveAll(*socketPath)
+	// Clean up socket file, unless systemd owns it
+	if !activated {
+		os.RemoveAll(*socketPath)
+	}
 	log.Println("GPU Monitor stopped")
 }
\ No newline at end of file
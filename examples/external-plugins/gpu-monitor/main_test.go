@@ -0,0 +1,166 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fakeNvidiaSMI puts an nvidia-smi running script first on the PATH.
+func fakeNvidiaSMI(t *testing.T, script string) {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "nvidia-smi"), []byte("#!/bin/sh\n"+script+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestGetGPUStats(t *testing.T) {
+	testCases := []struct {
+		name    string
+		output  string
+		want    *GPUStats
+		wantErr bool
+	}{
+		{
+			name:   "all fields",
+			output: "85, 1000, 4000, 150.25, 0x0000000000000020, 535.104.05",
+			want: &GPUStats{
+				Temperature:     85,
+				MemoryUsed:      1000,
+				MemoryTotal:     4000,
+				MemoryPercent:   25,
+				PowerUsage:      150.25,
+				PowerAvailable:  true,
+				ThrottleReasons: []string{"SwThermalSlowdown"},
+				DriverVersion:   "535.104.05",
+				Available:       true,
+				RawOutput:       "85, 1000, 4000, 150.25, 0x0000000000000020, 535.104.05",
+			},
+		},
+		{
+			name:   "unsupported fields",
+			output: "60, 0, 2000, [N/A], [Not Supported], N/A",
+			want: &GPUStats{
+				Temperature: 60,
+				MemoryTotal: 2000,
+				Available:   true,
+				RawOutput:   "60, 0, 2000, [N/A], [Not Supported], N/A",
+			},
+		},
+		{
+			name:   "older driver",
+			output: "60, 500, 2000, 70",
+			want: &GPUStats{
+				Temperature:    60,
+				MemoryUsed:     500,
+				MemoryTotal:    2000,
+				MemoryPercent:  25,
+				PowerUsage:     70,
+				PowerAvailable: true,
+				Available:      true,
+				RawOutput:      "60, 500, 2000, 70",
+			},
+		},
+		{name: "no GPU", output: "", want: &GPUStats{}},
+		{name: "truncated", output: "60, 500", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fakeNvidiaSMI(t, "echo '"+tc.output+"'")
+
+			m := NewGPUMonitor(80, 90, "test")
+			got, err := m.getGPUStats(t.Context())
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("getGPUStats() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("getGPUStats() = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestGetGPUStatsKilledOnDeadline(t *testing.T) {
+	// The child keeps stdout open after the shell is killed
+	fakeNvidiaSMI(t, "sleep 10 & sleep 10")
+
+	ctx, cancel := context.WithTimeout(t.Context(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := NewGPUMonitor(80, 90, "test").getGPUStats(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("getGPUStats() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("getGPUStats() returned after %v, want shortly after the deadline", elapsed)
+	}
+}
+
+func TestFlagsHash(t *testing.T) {
+	defer flag.Set("temp-threshold", flag.Lookup("temp-threshold").Value.String())
+
+	before := flagsHash()
+	if again := flagsHash(); again != before {
+		t.Fatalf("flagsHash() = %s then %s, want the same hash for the same flags", before, again)
+	}
+	if err := flag.Set("temp-threshold", "99"); err != nil {
+		t.Fatal(err)
+	}
+	if after := flagsHash(); after == before {
+		t.Errorf("flagsHash() = %s after changing a flag, want a different hash", after)
+	}
+}
+
+func TestLogV(t *testing.T) {
+	var output bytes.Buffer
+	log.SetOutput(&output)
+	defer log.SetOutput(os.Stderr)
+	defer func(v int) { *verbosity = v }(*verbosity)
+
+	testCases := []struct {
+		verbosity int
+		level     int
+		want      bool
+	}{
+		{verbosity: 0, level: 1, want: false},
+		{verbosity: 1, level: 1, want: true},
+		{verbosity: 1, level: 2, want: false},
+		{verbosity: 2, level: 1, want: true},
+		{verbosity: 2, level: 2, want: true},
+	}
+	for _, tc := range testCases {
+		output.Reset()
+		*verbosity = tc.verbosity
+		logV(tc.level, "routine message")
+		if got := bytes.Contains(output.Bytes(), []byte("routine message")); got != tc.want {
+			t.Errorf("logV(%d) at verbosity %d logged = %v, want %v", tc.level, tc.verbosity, got, tc.want)
+		}
+	}
+}
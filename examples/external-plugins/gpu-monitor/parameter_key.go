@@ -0,0 +1,74 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"crypto/ecdh"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "k8s.io/npd-ext/api/services/external/v1"
+	"k8s.io/npd-ext/pkg/externalmonitor/paramcrypt"
+)
+
+// loadParameterKey reads the hex encoded X25519 private key that parameters
+// are encrypted to. A new key is generated and saved if the file doesn't
+// exist yet, so that the key survives restarts.
+func loadParameterKey(path string) (*ecdh.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		key, err := paramcrypt.GenerateKey()
+		if err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(path, []byte(hex.EncodeToString(key.Bytes())+"\n"), 0600); err != nil {
+			return nil, err
+		}
+		log.Printf("Generated parameter encryption key %s", path)
+		return key, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("%s is not hex encoded: %v", path, err)
+	}
+	return paramcrypt.ParsePrivateKey(raw)
+}
+
+// requestParameters returns the request parameters with any encrypted values
+// decrypted. A value that can't be decrypted fails the check with
+// InvalidArgument rather than being silently ignored.
+func (m *GPUMonitor) requestParameters(req *pb.HealthCheckRequest) (map[string]string, error) {
+	if m.parameterKey == nil {
+		return req.Parameters, nil
+	}
+	parameters, err := paramcrypt.DecryptParameters(m.parameterKey, req.Parameters)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return parameters, nil
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "k8s.io/npd-ext/api/services/external/v1"
+	"k8s.io/npd-ext/pkg/externalmonitor/paramcrypt"
+)
+
+func TestLoadParameterKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "parameter.key")
+
+	generated, err := loadParameterKey(path)
+	if err != nil {
+		t.Fatalf("loadParameterKey() failed to generate a key: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("key was not saved: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("key saved with mode %v, want 0600", info.Mode().Perm())
+	}
+
+	loaded, err := loadParameterKey(path)
+	if err != nil {
+		t.Fatalf("loadParameterKey() failed to reload the key: %v", err)
+	}
+	if !loaded.Equal(generated) {
+		t.Error("reloaded key differs from the generated key")
+	}
+}
+
+func TestLoadParameterKeyInvalid(t *testing.T) {
+	testCases := map[string]string{
+		"not hex":   "not a key\n",
+		"too short": "0011223344\n",
+	}
+	for name, content := range testCases {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "parameter.key")
+			if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := loadParameterKey(path); err == nil {
+				t.Errorf("loadParameterKey() accepted %q", content)
+			}
+		})
+	}
+}
+
+func TestRequestParameters(t *testing.T) {
+	key, err := paramcrypt.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted, err := paramcrypt.Encrypt(key.PublicKey().Bytes(), "token", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := &GPUMonitor{parameterKey: key}
+	parameters, err := m.requestParameters(&pb.HealthCheckRequest{
+		Parameters: map[string]string{"token": encrypted, "gpu": "0"},
+	})
+	if err != nil {
+		t.Fatalf("requestParameters() failed: %v", err)
+	}
+	if parameters["token"] != "secret" || parameters["gpu"] != "0" {
+		t.Errorf("requestParameters() = %v, want the token decrypted and gpu unchanged", parameters)
+	}
+
+	// A value encrypted under another parameter name is rejected
+	_, err = m.requestParameters(&pb.HealthCheckRequest{
+		Parameters: map[string]string{"password": encrypted},
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("requestParameters() error = %v, want InvalidArgument", err)
+	}
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// powerDrawPattern matches a power.draw reading in watts, with or without
+// the unit, e.g. "150", "150.25" or "150.25 W".
+var powerDrawPattern = regexp.MustCompile(`^(\d+(?:\.\d+)?)\s*(?:W)?$`)
+
+// parsePowerDraw parses the power.draw field of nvidia-smi. It returns false
+// if the GPU or driver doesn't report power, e.g. "N/A" or "[Not Supported]".
+func parsePowerDraw(value string) (float64, bool, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "N/A" || strings.HasPrefix(value, "[") {
+		// Not supported by this GPU or driver
+		return 0, false, nil
+	}
+
+	match := powerDrawPattern.FindStringSubmatch(value)
+	if match == nil {
+		return 0, false, fmt.Errorf("invalid power draw %q", value)
+	}
+	power, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid power draw %q: %v", value, err)
+	}
+	return power, true, nil
+}
+
+// formatPower formats a power reading for messages and logs.
+func formatPower(power float64, available bool) string {
+	if !available {
+		return "N/A"
+	}
+	return strconv.FormatFloat(power, 'f', -1, 64) + "W"
+}
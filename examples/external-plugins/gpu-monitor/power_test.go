@@ -0,0 +1,70 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import "testing"
+
+func TestParsePowerDraw(t *testing.T) {
+	testCases := []struct {
+		value         string
+		want          float64
+		wantAvailable bool
+		wantErr       bool
+	}{
+		{value: "150.25", want: 150.25, wantAvailable: true},
+		{value: " 70 ", want: 70, wantAvailable: true},
+		{value: "300.00 W", want: 300, wantAvailable: true},
+		{value: "42W", want: 42, wantAvailable: true},
+		{value: "N/A"},
+		{value: "[Not Supported]"},
+		{value: "[Unknown Error]"},
+		{value: ""},
+		{value: "-5", wantErr: true},
+		{value: "150 mW", wantErr: true},
+		{value: "high", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			got, available, err := parsePowerDraw(tc.value)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("parsePowerDraw(%q) error = %v, wantErr %v", tc.value, err, tc.wantErr)
+			}
+			if got != tc.want || available != tc.wantAvailable {
+				t.Errorf("parsePowerDraw(%q) = %v, %v, want %v, %v", tc.value, got, available, tc.want, tc.wantAvailable)
+			}
+		})
+	}
+}
+
+func TestFormatPower(t *testing.T) {
+	testCases := []struct {
+		power     float64
+		available bool
+		want      string
+	}{
+		{power: 150.25, available: true, want: "150.25W"},
+		{power: 70, available: true, want: "70W"},
+		{power: 0, available: true, want: "0W"},
+		{power: 0, available: false, want: "N/A"},
+	}
+	for _, tc := range testCases {
+		if got := formatPower(tc.power, tc.available); got != tc.want {
+			t.Errorf("formatPower(%v, %v) = %q, want %q", tc.power, tc.available, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GPUProcess is a compute process using the GPU.
+type GPUProcess struct {
+	PID          int    `json:"pid"`
+	Name         string `json:"name"`
+	UsedMemoryMB int    `json:"usedMemoryMB"`
+
+	// PodUID is the UID of the Kubernetes pod running the process, empty if
+	// the process does not belong to a pod
+	PodUID string `json:"podUID,omitempty"`
+}
+
+// String describes the process for condition messages and event details.
+func (p GPUProcess) String() string {
+	s := fmt.Sprintf("pid %d (%s) using %dMB", p.PID, p.Name, p.UsedMemoryMB)
+	if p.PodUID != "" {
+		s += fmt.Sprintf(" in pod %s", p.PodUID)
+	}
+	return s
+}
+
+// podUIDPattern matches the pod UID in kubepods cgroup paths, which use either
+// dashes (cgroupfs driver) or underscores (systemd driver) as separators.
+var podUIDPattern = regexp.MustCompile(`pod([0-9a-f]{8}[-_][0-9a-f]{4}[-_][0-9a-f]{4}[-_][0-9a-f]{4}[-_][0-9a-f]{12})`)
+
+// getGPUProcesses lists the compute processes using the GPU, sorted by GPU
+// memory use, largest first. The command is killed when ctx is done.
+func getGPUProcesses(ctx context.Context) ([]GPUProcess, error) {
+	cmd := exec.CommandContext(ctx, "nvidia-smi",
+		"--query-compute-apps=pid,process_name,used_memory",
+		"--format=csv,noheader,nounits")
+	cmd.WaitDelay = 500 * time.Millisecond
+
+	output, err := cmd.Output()
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("nvidia-smi did not finish in time: %w", ctx.Err())
+		}
+		return nil, fmt.Errorf("nvidia-smi execution failed: %v", err)
+	}
+
+	processes := parseComputeApps(string(output))
+	for i := range processes {
+		processes[i].PodUID = podUID(processes[i].PID)
+	}
+	return processes, nil
+}
+
+// parseComputeApps parses the output of nvidia-smi --query-compute-apps. No
+// output, or the "No running processes found" message, means no processes.
+func parseComputeApps(output string) []GPUProcess {
+	var processes []GPUProcess
+	for _, line := range strings.Split(output, "\n") {
+		parts := strings.Split(line, ",")
+		if len(parts) < 3 {
+			continue
+		}
+
+		pid, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+		if err != nil {
+			continue
+		}
+		// Memory is "[N/A]" when the driver cannot attribute it
+		memory, _ := strconv.Atoi(strings.TrimSpace(parts[2]))
+
+		processes = append(processes, GPUProcess{
+			PID:          pid,
+			Name:         strings.TrimSpace(parts[1]),
+			UsedMemoryMB: memory,
+		})
+	}
+
+	sort.SliceStable(processes, func(i, j int) bool {
+		return processes[i].UsedMemoryMB > processes[j].UsedMemoryMB
+	})
+	return processes
+}
+
+// podUID returns the UID of the pod a process runs in from its cgroup, or an
+// empty string if it is not in a pod or has exited.
+func podUID(pid int) string {
+	cgroup, err := os.ReadFile(fmt.Sprintf("/proc/%d/cgroup", pid))
+	if err != nil {
+		return ""
+	}
+	return podUIDFromCgroup(string(cgroup))
+}
+
+// podUIDFromCgroup returns the pod UID in the contents of a cgroup file, or an
+// empty string if there is none.
+func podUIDFromCgroup(cgroup string) string {
+	match := podUIDPattern.FindStringSubmatch(cgroup)
+	if match == nil {
+		return ""
+	}
+	return strings.ReplaceAll(match[1], "_", "-")
+}
+
+// describeProcesses summarizes the GPU processes, one per line.
+func describeProcesses(processes []GPUProcess) string {
+	if len(processes) == 0 {
+		return "no compute processes"
+	}
+
+	lines := make([]string, len(processes))
+	for i, process := range processes {
+		lines[i] = process.String()
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseComputeApps(t *testing.T) {
+	testCases := []struct {
+		name   string
+		output string
+		want   []GPUProcess
+	}{
+		{name: "no output"},
+		{name: "no processes", output: "No running processes found\n"},
+		{
+			name:   "sorted by memory",
+			output: "1234, python, 1024\n5678, /usr/bin/trainer, 8192\n42, nvidia-cuda-mps-server, 25\n",
+			want: []GPUProcess{
+				{PID: 5678, Name: "/usr/bin/trainer", UsedMemoryMB: 8192},
+				{PID: 1234, Name: "python", UsedMemoryMB: 1024},
+				{PID: 42, Name: "nvidia-cuda-mps-server", UsedMemoryMB: 25},
+			},
+		},
+		{
+			name:   "memory not attributed",
+			output: "1234, python, [N/A]\n",
+			want:   []GPUProcess{{PID: 1234, Name: "python"}},
+		},
+		{
+			name:   "malformed lines skipped",
+			output: "pid, process_name, used_memory\n1234, python\n\n5678, trainer, 512\n",
+			want:   []GPUProcess{{PID: 5678, Name: "trainer", UsedMemoryMB: 512}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := parseComputeApps(tc.output); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("parseComputeApps(%q) = %v, want %v", tc.output, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPodUIDFromCgroup(t *testing.T) {
+	testCases := []struct {
+		name   string
+		cgroup string
+		want   string
+	}{
+		{
+			name:   "cgroupfs driver",
+			cgroup: "0::/kubepods/burstable/pod0f3a7c1e-2b4d-4e6f-8a9b-1c2d3e4f5a6b/3c1f0e",
+			want:   "0f3a7c1e-2b4d-4e6f-8a9b-1c2d3e4f5a6b",
+		},
+		{
+			name:   "systemd driver",
+			cgroup: "0::/kubepods.slice/kubepods-besteffort.slice/kubepods-besteffort-pod0f3a7c1e_2b4d_4e6f_8a9b_1c2d3e4f5a6b.slice/cri-containerd-3c1f0e.scope",
+			want:   "0f3a7c1e-2b4d-4e6f-8a9b-1c2d3e4f5a6b",
+		},
+		{name: "not in a pod", cgroup: "0::/system.slice/sshd.service"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := podUIDFromCgroup(tc.cgroup); got != tc.want {
+				t.Errorf("pod UID of %q = %q, want %q", tc.cgroup, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDescribeProcesses(t *testing.T) {
+	if got := describeProcesses(nil); got != "no compute processes" {
+		t.Errorf("describeProcesses(nil) = %q, want %q", got, "no compute processes")
+	}
+
+	processes := []GPUProcess{
+		{PID: 5678, Name: "trainer", UsedMemoryMB: 8192, PodUID: "0f3a7c1e-2b4d-4e6f-8a9b-1c2d3e4f5a6b"},
+		{PID: 1234, Name: "python", UsedMemoryMB: 1024},
+	}
+	want := "pid 5678 (trainer) using 8192MB in pod 0f3a7c1e-2b4d-4e6f-8a9b-1c2d3e4f5a6b\npid 1234 (python) using 1024MB"
+	if got := describeProcesses(processes); got != want {
+		t.Errorf("describeProcesses() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// prepareSocket checks that the socket can be created before listening: the
+// parent directory must exist, or is created when createDir is set, and must be
+// writable. A stale socket left by a previous run is removed, but a socket that
+// is still served or a path that is not a socket is left alone.
+func prepareSocket(path string, createDir bool) error {
+	dir := filepath.Dir(path)
+
+	info, err := os.Stat(dir)
+	if os.IsNotExist(err) {
+		if !createDir {
+			return fmt.Errorf("socket directory %s does not exist, create it or pass --create-socket-dir", dir)
+		}
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create socket directory %s: %v", dir, err)
+		}
+	} else if err != nil {
+		return fmt.Errorf("failed to check socket directory %s: %v", dir, err)
+	} else if !info.IsDir() {
+		return fmt.Errorf("socket directory %s is not a directory", dir)
+	}
+
+	// Check writability by creating a file, which also honors ACLs and read-only mounts
+	probe, err := os.CreateTemp(dir, ".gpu-monitor-probe-")
+	if err != nil {
+		return fmt.Errorf("socket directory %s is not writable: %v", dir, err)
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+
+	return removeStaleSocket(path)
+}
+
+// removeStaleSocket removes a socket file that no process is serving anymore.
+func removeStaleSocket(path string) error {
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check socket %s: %v", path, err)
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%s exists and is not a socket", path)
+	}
+
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		conn.Close()
+		return fmt.Errorf("socket %s is in use by another process", path)
+	}
+
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove stale socket %s: %v", path, err)
+	}
+	return nil
+}
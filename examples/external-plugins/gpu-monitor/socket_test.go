@@ -0,0 +1,139 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// staleSocket leaves a socket file at path that no process serves.
+func staleSocket(t *testing.T, path string) {
+	t.Helper()
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("failed to listen on %s: %v", path, err)
+	}
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	listener.Close()
+}
+
+func TestPrepareSocket(t *testing.T) {
+	testCases := []struct {
+		name      string
+		setup     func(t *testing.T, path string)
+		createDir bool
+		wantErr   string
+		wantGone  bool
+		wantKept  bool
+	}{
+		{
+			name:     "no socket",
+			setup:    func(t *testing.T, path string) {},
+			wantGone: true,
+		},
+		{
+			name: "missing directory",
+			setup: func(t *testing.T, path string) {
+				os.Remove(filepath.Dir(path))
+			},
+			wantErr: "does not exist",
+		},
+		{
+			name: "missing directory created",
+			setup: func(t *testing.T, path string) {
+				os.Remove(filepath.Dir(path))
+			},
+			createDir: true,
+			wantGone:  true,
+		},
+		{
+			name: "directory is a file",
+			setup: func(t *testing.T, path string) {
+				os.Remove(filepath.Dir(path))
+				if err := os.WriteFile(filepath.Dir(path), nil, 0644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			createDir: true,
+			wantErr:   "is not a directory",
+		},
+		{
+			name: "stale socket removed",
+			setup: func(t *testing.T, path string) {
+				staleSocket(t, path)
+			},
+			wantGone: true,
+		},
+		{
+			name: "socket in use",
+			setup: func(t *testing.T, path string) {
+				listener, err := net.Listen("unix", path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				t.Cleanup(func() { listener.Close() })
+			},
+			wantErr:  "in use",
+			wantKept: true,
+		},
+		{
+			name: "not a socket",
+			setup: func(t *testing.T, path string) {
+				if err := os.WriteFile(path, []byte("data"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantErr:  "is not a socket",
+			wantKept: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "run", "gpu.sock")
+			if err := os.Mkdir(filepath.Dir(path), 0755); err != nil {
+				t.Fatal(err)
+			}
+			tc.setup(t, path)
+
+			err := prepareSocket(path, tc.createDir)
+			if tc.wantErr == "" && err != nil {
+				t.Fatalf("prepareSocket() failed: %v", err)
+			}
+			if tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)) {
+				t.Fatalf("prepareSocket() error = %v, want an error containing %q", err, tc.wantErr)
+			}
+			if _, err := os.Lstat(path); tc.wantGone && !os.IsNotExist(err) {
+				t.Errorf("%s was not removed: %v", path, err)
+			}
+			if _, err := os.Lstat(path); tc.wantKept && err != nil {
+				t.Errorf("%s was removed: %v", path, err)
+			}
+			if tc.wantErr == "" {
+				// The writability probe is cleaned up
+				entries, _ := os.ReadDir(filepath.Dir(path))
+				if len(entries) != 0 {
+					t.Errorf("socket directory left with %d entries", len(entries))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+//go:build linux
+
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"syscall"
+)
+
+// systemdListenFDsStart is the first file descriptor passed by systemd.
+const systemdListenFDsStart = 3
+
+// systemdListener returns the socket passed by systemd socket activation, as
+// announced by LISTEN_PID and LISTEN_FDS, or nil if there is none.
+func systemdListener() (net.Listener, error) {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+	fds, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || fds == 0 {
+		return nil, nil
+	}
+	if fds != 1 {
+		return nil, fmt.Errorf("systemd passed %d sockets, expected one", fds)
+	}
+
+	// Don't hand the socket on to nvidia-smi
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+	syscall.CloseOnExec(systemdListenFDsStart)
+
+	file := os.NewFile(systemdListenFDsStart, "systemd-socket")
+	defer file.Close()
+
+	listener, err := net.FileListener(file)
+	if err != nil {
+		return nil, fmt.Errorf("socket passed by systemd is not a listening socket: %v", err)
+	}
+	return listener, nil
+}
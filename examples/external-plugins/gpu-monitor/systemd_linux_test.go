@@ -0,0 +1,63 @@
+//go:build linux
+
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestSystemdListenerNotActivated(t *testing.T) {
+	pid := strconv.Itoa(os.Getpid())
+	testCases := []struct {
+		name      string
+		listenPID string
+		listenFDs string
+	}{
+		{name: "not set"},
+		{name: "other process", listenPID: strconv.Itoa(os.Getpid() + 1), listenFDs: "1"},
+		{name: "invalid pid", listenPID: "self", listenFDs: "1"},
+		{name: "no sockets", listenPID: pid, listenFDs: "0"},
+		{name: "invalid count", listenPID: pid, listenFDs: "one"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("LISTEN_PID", tc.listenPID)
+			t.Setenv("LISTEN_FDS", tc.listenFDs)
+
+			listener, err := systemdListener()
+			if err != nil || listener != nil {
+				t.Errorf("systemdListener() = %v, %v, want no listener", listener, err)
+			}
+		})
+	}
+}
+
+func TestSystemdListenerSeveralSockets(t *testing.T) {
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+	t.Setenv("LISTEN_FDS", "2")
+
+	listener, err := systemdListener()
+	if err == nil || !strings.Contains(err.Error(), "expected one") {
+		t.Fatalf("systemdListener() = %v, %v, want an error about the socket count", listener, err)
+	}
+}
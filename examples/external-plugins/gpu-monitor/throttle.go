@@ -0,0 +1,68 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// throttleReason is a bit of the clocks_throttle_reasons.active bitmask.
+type throttleReason struct {
+	mask uint64
+	name string
+	// degrading is true if the reason lowers clocks below what the workload asked for
+	degrading bool
+}
+
+// throttleReasons lists the bits reported by nvidia-smi, in bit order.
+var throttleReasons = []throttleReason{
+	{0x0000000000000001, "GpuIdle", false},
+	{0x0000000000000002, "ApplicationsClocksSetting", false},
+	{0x0000000000000004, "SwPowerCap", true},
+	{0x0000000000000008, "HwSlowdown", true},
+	{0x0000000000000010, "SyncBoost", false},
+	{0x0000000000000020, "SwThermalSlowdown", true},
+	{0x0000000000000040, "HwThermalSlowdown", true},
+	{0x0000000000000080, "HwPowerBrakeSlowdown", true},
+	{0x0000000000000100, "DisplayClockSetting", false},
+}
+
+// parseThrottleReasons parses the clocks_throttle_reasons.active value, a hex
+// bitmask such as "0x0000000000000004". It returns the names of the active
+// reasons that degrade performance.
+func parseThrottleReasons(value string) ([]string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "N/A" || strings.HasPrefix(value, "[") {
+		// Not supported by this GPU or driver
+		return nil, nil
+	}
+
+	mask, err := strconv.ParseUint(strings.TrimPrefix(strings.ToLower(value), "0x"), 16, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid throttle reasons %q: %v", value, err)
+	}
+
+	var active []string
+	for _, reason := range throttleReasons {
+		if reason.degrading && mask&reason.mask != 0 {
+			active = append(active, reason.name)
+		}
+	}
+	return active, nil
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseThrottleReasons(t *testing.T) {
+	testCases := []struct {
+		name    string
+		value   string
+		want    []string
+		wantErr bool
+	}{
+		{name: "none active", value: "0x0000000000000000"},
+		{name: "idle only", value: "0x0000000000000001"},
+		{name: "power cap", value: "0x0000000000000004", want: []string{"SwPowerCap"}},
+		{
+			name:  "several in table order",
+			value: "0x00000000000000E8",
+			want:  []string{"HwSlowdown", "SwThermalSlowdown", "HwThermalSlowdown", "HwPowerBrakeSlowdown"},
+		},
+		{name: "non-degrading bits ignored", value: "0x0000000000000131", want: []string{"SwThermalSlowdown"}},
+		{name: "without prefix", value: "40", want: []string{"HwThermalSlowdown"}},
+		{name: "padded", value: " 0x0000000000000008 ", want: []string{"HwSlowdown"}},
+		{name: "not reported", value: "N/A"},
+		{name: "not supported", value: "[Not Supported]"},
+		{name: "empty", value: ""},
+		{name: "invalid", value: "Active", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseThrottleReasons(tc.value)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("parseThrottleReasons(%q) error = %v, wantErr %v", tc.value, err, tc.wantErr)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("parseThrottleReasons(%q) = %v, want %v", tc.value, got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"slices"
+
+	npdt "k8s.io/node-problem-detector/pkg/types"
+
+	"k8s.io/npd-ext/pkg/externalmonitor/types"
+)
+
+// conditionRank orders condition statuses from least to most severe for
+// worst-wins aggregation.
+var conditionRank = map[npdt.ConditionStatus]int{
+	npdt.False:   0,
+	npdt.Unknown: 1,
+	npdt.True:    2,
+}
+
+// recordReportedConditions stores the conditions this monitor reported so that
+// other monitors can aggregate them.
+func (p *ExternalMonitorProxy) recordReportedConditions(conditions []npdt.Condition) {
+	p.reportedMutex.Lock()
+	defer p.reportedMutex.Unlock()
+
+	for _, condition := range conditions {
+		p.reportedConditions[condition.Type] = condition
+	}
+}
+
+// reportedCondition returns the condition of the given type last reported by this monitor.
+func (p *ExternalMonitorProxy) reportedCondition(conditionType string) (npdt.Condition, bool) {
+	p.reportedMutex.RLock()
+	defer p.reportedMutex.RUnlock()
+
+	condition, ok := p.reportedConditions[conditionType]
+	return condition, ok
+}
+
+// aggregationOwner returns the monitor emitting the rollup of a condition
+// type: the first aggregating monitor by source that reported it, nil if none
+// did.
+func aggregationOwner(conditionType string) *ExternalMonitorProxy {
+	for _, peer := range registry.list() {
+		if peer.config.ConditionAggregation == "" {
+			continue
+		}
+		if _, ok := peer.reportedCondition(conditionType); ok {
+			return peer
+		}
+	}
+	return nil
+}
+
+// rollUp returns the conditions this monitor emits out of its own: the rollup
+// across all aggregating monitors of the types it owns, and unchanged the
+// types no aggregating monitor reported. Types owned by another monitor are
+// left out, and their owners are returned so they can update their rollup.
+func (p *ExternalMonitorProxy) rollUp(conditions []npdt.Condition) ([]npdt.Condition, []*ExternalMonitorProxy) {
+	rule := p.config.ConditionAggregation
+	peers := registry.list()
+
+	rolled := make([]npdt.Condition, 0, len(conditions))
+	var owners []*ExternalMonitorProxy
+	for _, condition := range conditions {
+		if owner := aggregationOwner(condition.Type); owner != nil && owner != p {
+			if !slices.Contains(owners, owner) {
+				owners = append(owners, owner)
+			}
+			continue
+		}
+		for _, peer := range peers {
+			if peer == p || peer.config.ConditionAggregation == "" {
+				continue
+			}
+			if other, ok := peer.reportedCondition(condition.Type); ok {
+				condition = combineConditions(rule, condition, other)
+			}
+		}
+		rolled = append(rolled, condition)
+	}
+	return rolled, owners
+}
+
+// aggregateConditions records the conditions of a status and returns a copy of
+// the status carrying the conditions this monitor emits under aggregation, so
+// that each rolled-up condition is emitted by a single monitor. Owners of the
+// conditions left out are asked to emit their updated rollup.
+func (p *ExternalMonitorProxy) aggregateConditions(status *npdt.Status) *npdt.Status {
+	// Record even without aggregation, for derived conditions of other monitors
+	p.recordReportedConditions(status.Conditions)
+
+	if p.config.ConditionAggregation == "" || len(status.Conditions) == 0 {
+		return status
+	}
+
+	rolled, owners := p.rollUp(status.Conditions)
+	for _, owner := range owners {
+		owner.requestRollup()
+	}
+	p.rolledUp = rolled
+
+	aggregated := *status
+	aggregated.Conditions = rolled
+	return &aggregated
+}
+
+// requestRollup asks monitorLoop to emit the rollup again if another monitor
+// changed it.
+func (p *ExternalMonitorProxy) requestRollup() {
+	select {
+	case p.rollupChan <- struct{}{}:
+	default:
+	}
+}
+
+// sendRollup re-sends the last status when the rollup of its conditions no
+// longer matches the one last emitted, e.g. because another aggregating
+// monitor reported a worse status or stopped.
+func (p *ExternalMonitorProxy) sendRollup() {
+	if p.lastStatus == nil || p.paused.Load() {
+		return
+	}
+	if rolled, _ := p.rollUp(p.lastStatus.Conditions); p.conditionsEqual(rolled, p.rolledUp) {
+		return
+	}
+
+	status := &npdt.Status{
+		Source:     p.lastStatus.Source,
+		Conditions: p.lastStatus.Conditions,
+	}
+	if p.sendStatus(status) {
+		p.logf(4, "Sent updated rollup from %s: %d conditions", p.name, len(p.rolledUp))
+		p.recordConditionsSent(status.Conditions)
+	}
+}
+
+// releaseRollups asks the other aggregating monitors to take over the rollups
+// of a stopped monitor.
+func releaseRollups() {
+	for _, peer := range registry.list() {
+		if peer.config.ConditionAggregation != "" {
+			peer.requestRollup()
+		}
+	}
+}
+
+// combineConditions returns the condition that wins under the aggregation rule.
+func combineConditions(rule string, current, other npdt.Condition) npdt.Condition {
+	if rule == types.ConditionAggregationWorstWins {
+		if conditionRank[other.Status] != conditionRank[current.Status] {
+			if conditionRank[other.Status] > conditionRank[current.Status] {
+				return other
+			}
+			return current
+		}
+	}
+
+	// latest-wins, and the tie-break for worst-wins
+	if other.Transition.After(current.Transition) {
+		return other
+	}
+	return current
+}
@@ -0,0 +1,196 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"testing"
+	"time"
+
+	npdt "k8s.io/node-problem-detector/pkg/types"
+
+	"k8s.io/npd-ext/pkg/externalmonitor/types"
+)
+
+func TestCombineConditions(t *testing.T) {
+	earlier := time.Now().Add(-time.Minute)
+	later := time.Now()
+	condition := func(status npdt.ConditionStatus, transition time.Time) npdt.Condition {
+		return npdt.Condition{Type: "GPUHealthy", Status: status, Transition: transition, Reason: string(status)}
+	}
+
+	testCases := []struct {
+		name    string
+		rule    string
+		current npdt.Condition
+		other   npdt.Condition
+		want    npdt.Condition
+	}{
+		{
+			name:    "worst-wins prefers True over False",
+			rule:    types.ConditionAggregationWorstWins,
+			current: condition(npdt.False, later),
+			other:   condition(npdt.True, earlier),
+			want:    condition(npdt.True, earlier),
+		},
+		{
+			name:    "worst-wins prefers Unknown over False",
+			rule:    types.ConditionAggregationWorstWins,
+			current: condition(npdt.Unknown, earlier),
+			other:   condition(npdt.False, later),
+			want:    condition(npdt.Unknown, earlier),
+		},
+		{
+			name:    "worst-wins prefers True over Unknown",
+			rule:    types.ConditionAggregationWorstWins,
+			current: condition(npdt.Unknown, later),
+			other:   condition(npdt.True, earlier),
+			want:    condition(npdt.True, earlier),
+		},
+		{
+			name:    "worst-wins breaks ties by transition",
+			rule:    types.ConditionAggregationWorstWins,
+			current: condition(npdt.True, earlier),
+			other:   condition(npdt.True, later),
+			want:    condition(npdt.True, later),
+		},
+		{
+			name:    "latest-wins ignores severity",
+			rule:    types.ConditionAggregationLatestWins,
+			current: condition(npdt.True, earlier),
+			other:   condition(npdt.False, later),
+			want:    condition(npdt.False, later),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := combineConditions(tc.rule, tc.current, tc.other)
+			if got.Status != tc.want.Status || !got.Transition.Equal(tc.want.Transition) {
+				t.Errorf("combineConditions = %s at %v, want %s at %v",
+					got.Status, got.Transition, tc.want.Status, tc.want.Transition)
+			}
+		})
+	}
+}
+
+// aggregatingTestProxy registers a proxy for source rolling up conditions
+// with worst-wins until the test ends.
+func aggregatingTestProxy(t *testing.T, source string) *ExternalMonitorProxy {
+	t.Helper()
+
+	p := newTestProxy(t, testConfig(t, "/unused.sock", func(config *types.ExternalMonitorConfig) {
+		config.Source = source
+		config.ConditionAggregation = types.ConditionAggregationWorstWins
+	}))
+	registry.register(p)
+	t.Cleanup(func() { registry.unregister(p) })
+	return p
+}
+
+// fakeConditionStatus returns a status of source with the condition "Fake".
+func fakeConditionStatus(source string, status npdt.ConditionStatus) *npdt.Status {
+	return &npdt.Status{
+		Source: source,
+		Conditions: []npdt.Condition{{
+			Type: "Fake", Status: status, Transition: time.Now(), Reason: "Fake" + string(status),
+		}},
+	}
+}
+
+// rolledUpStatus returns the status of the condition "Fake" in conditions.
+func rolledUpStatus(conditions []npdt.Condition) (npdt.ConditionStatus, bool) {
+	condition, ok := findCondition(conditions, "Fake")
+	return condition.Status, ok
+}
+
+func TestAggregateConditionsWorstWins(t *testing.T) {
+	primary := aggregatingTestProxy(t, "gpu-primary")
+	fallback := aggregatingTestProxy(t, "gpu-fallback")
+
+	// "gpu-fallback" sorts first, so it owns the rollup
+	status := fallback.aggregateConditions(fakeConditionStatus("gpu-fallback", npdt.True))
+	if got, ok := rolledUpStatus(status.Conditions); !ok || got != npdt.True {
+		t.Fatalf("fallback alone emits Fake %q (reported %v), want True", got, ok)
+	}
+
+	// The primary's conflicting False loses to True and is left to the owner
+	status = primary.aggregateConditions(fakeConditionStatus("gpu-primary", npdt.False))
+	if _, ok := rolledUpStatus(status.Conditions); ok {
+		t.Errorf("primary emits Fake owned by the fallback: %+v", status.Conditions)
+	}
+	select {
+	case <-fallback.rollupChan:
+	default:
+		t.Fatal("fallback not asked to update its rollup")
+	}
+	fallback.lastStatus = fakeConditionStatus("gpu-fallback", npdt.True)
+	fallback.sendRollup()
+	select {
+	case s := <-fallback.statusChan:
+		t.Errorf("unchanged rollup re-sent: %+v", s.Conditions)
+	default:
+	}
+
+	// Once the fallback clears, a primary going Unknown makes the owner emit
+	// its updated rollup
+	status = fallback.aggregateConditions(fakeConditionStatus("gpu-fallback", npdt.False))
+	if got, _ := rolledUpStatus(status.Conditions); got != npdt.False {
+		t.Errorf("rollup of False and False = %q, want False", got)
+	}
+	primary.aggregateConditions(fakeConditionStatus("gpu-primary", npdt.Unknown))
+	<-fallback.rollupChan
+	fallback.lastStatus = fakeConditionStatus("gpu-fallback", npdt.False)
+	fallback.sendRollup()
+	select {
+	case s := <-fallback.statusChan:
+		if got, _ := rolledUpStatus(s.Conditions); got != npdt.Unknown {
+			t.Errorf("updated rollup of False and Unknown = %q, want Unknown", got)
+		}
+	default:
+		t.Error("updated rollup not sent")
+	}
+	select {
+	case s := <-primary.statusChan:
+		t.Errorf("primary sent a status: %+v", s.Conditions)
+	default:
+	}
+}
+
+func TestAggregateConditionsOwnerStops(t *testing.T) {
+	first := aggregatingTestProxy(t, "gpu-a")
+	second := aggregatingTestProxy(t, "gpu-b")
+
+	first.aggregateConditions(fakeConditionStatus("gpu-a", npdt.False))
+	second.lastStatus = fakeConditionStatus("gpu-b", npdt.True)
+	status := second.aggregateConditions(second.lastStatus)
+	if _, ok := rolledUpStatus(status.Conditions); ok {
+		t.Fatalf("second monitor emits Fake owned by the first: %+v", status.Conditions)
+	}
+
+	registry.unregister(first)
+	releaseRollups()
+	<-second.rollupChan
+	second.sendRollup()
+	select {
+	case s := <-second.statusChan:
+		if got, _ := rolledUpStatus(s.Conditions); got != npdt.True {
+			t.Errorf("rollup taken over = %q, want True", got)
+		}
+	default:
+		t.Error("second monitor did not take over the rollup")
+	}
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"fmt"
+	"time"
+
+	npdt "k8s.io/node-problem-detector/pkg/types"
+)
+
+// autoResolvedReason is the reason of conditions resolved by autoResolveAfter.
+const autoResolvedReason = "AutoResolved"
+
+// recordAssertions notes when the plugin reported each condition True. Must be
+// called with the status as converted from the plugin response, before
+// conditions the plugin omitted are carried over.
+func (p *ExternalMonitorProxy) recordAssertions(status *npdt.Status) {
+	now := time.Now()
+	for _, condition := range status.Conditions {
+		if condition.Status == npdt.True {
+			p.lastAsserted[condition.Type] = now
+		} else {
+			delete(p.lastAsserted, condition.Type)
+		}
+	}
+}
+
+// applyAutoResolve resolves a condition to False once it has been True for its
+// autoResolveAfter without the plugin reporting it True, whether the proxy
+// kept its last value or the plugin stopped returning it altogether.
+func (p *ExternalMonitorProxy) applyAutoResolve(status *npdt.Status) {
+	for _, condDef := range p.declaredConditions() {
+		after := condDef.AutoResolveAfter
+		if after <= 0 {
+			continue
+		}
+
+		index := -1
+		for i := range status.Conditions {
+			if status.Conditions[i].Type == condDef.Type {
+				index = i
+				break
+			}
+		}
+
+		current, ok := npdt.Condition{}, false
+		if index >= 0 {
+			current, ok = status.Conditions[index], true
+		} else if p.lastStatus != nil {
+			current, ok = findCondition(p.lastStatus.Conditions, condDef.Type)
+		}
+		if !ok || current.Status != npdt.True {
+			// Keep a resolved condition the plugin still omits
+			if ok && index < 0 && current.Reason == autoResolvedReason {
+				status.Conditions = append(status.Conditions, current)
+			}
+			delete(p.lastAsserted, condDef.Type)
+			continue
+		}
+
+		asserted, ok := p.lastAsserted[condDef.Type]
+		if !ok {
+			// True without a recorded assertion, e.g. restored at startup
+			asserted = time.Now()
+			p.lastAsserted[condDef.Type] = asserted
+		}
+		if time.Since(asserted) < after {
+			// Keep the last value so the condition is still tracked next check
+			if index < 0 {
+				status.Conditions = append(status.Conditions, current)
+			}
+			continue
+		}
+
+		p.logf(4, "Condition %s of %s not asserted for %v, resolving it", condDef.Type, p.name, after)
+		delete(p.lastAsserted, condDef.Type)
+		resolved := npdt.Condition{
+			Type:       condDef.Type,
+			Status:     npdt.False,
+			Transition: time.Now(),
+			Reason:     autoResolvedReason,
+			Message:    fmt.Sprintf("%s was not reported True by %s for %v", condDef.Type, p.name, after),
+		}
+		if index >= 0 {
+			status.Conditions[index] = resolved
+		} else {
+			status.Conditions = append(status.Conditions, resolved)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"testing"
+	"time"
+
+	npdt "k8s.io/node-problem-detector/pkg/types"
+
+	"k8s.io/npd-ext/pkg/externalmonitor/types"
+)
+
+func TestApplyAutoResolve(t *testing.T) {
+	const after = time.Minute
+
+	testCases := []struct {
+		name string
+		// committed is the last status, empty for none
+		committed npdt.ConditionStatus
+		// reported is the status the plugin reported, empty if it omitted it
+		reported npdt.ConditionStatus
+		// assertedAgo is how long ago the plugin last reported True, zero
+		// for never
+		assertedAgo time.Duration
+		want        npdt.ConditionStatus
+		wantReason  string
+	}{
+		{
+			name:        "reported True recently",
+			reported:    npdt.True,
+			assertedAgo: time.Second,
+			want:        npdt.True,
+			wantReason:  "Reported",
+		},
+		{
+			name:        "kept True without assertion for too long",
+			reported:    npdt.True,
+			assertedAgo: 2 * after,
+			want:        npdt.False,
+			wantReason:  autoResolvedReason,
+		},
+		{
+			name:        "omitted after a recent assertion",
+			committed:   npdt.True,
+			assertedAgo: time.Second,
+			want:        npdt.True,
+			wantReason:  "Committed",
+		},
+		{
+			name:        "omitted for too long",
+			committed:   npdt.True,
+			assertedAgo: 2 * after,
+			want:        npdt.False,
+			wantReason:  autoResolvedReason,
+		},
+		{
+			name:       "True without a recorded assertion",
+			committed:  npdt.True,
+			want:       npdt.True,
+			wantReason: "Committed",
+		},
+		{
+			name:       "reported False",
+			committed:  npdt.True,
+			reported:   npdt.False,
+			want:       npdt.False,
+			wantReason: "Reported",
+		},
+		{
+			name: "omitted and never reported",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := newTestProxy(t, testConfig(t, "/unused.sock", func(config *types.ExternalMonitorConfig) {
+				config.Conditions[0].AutoResolveAfter = after
+			}))
+			if tc.committed != "" {
+				p.lastStatus = &npdt.Status{Conditions: []npdt.Condition{{Type: "Fake", Status: tc.committed, Reason: "Committed"}}}
+			}
+			if tc.assertedAgo > 0 {
+				p.lastAsserted["Fake"] = time.Now().Add(-tc.assertedAgo)
+			}
+
+			status := &npdt.Status{}
+			if tc.reported != "" {
+				status.Conditions = []npdt.Condition{{Type: "Fake", Status: tc.reported, Reason: "Reported"}}
+			}
+			p.applyAutoResolve(status)
+
+			condition, ok := findCondition(status.Conditions, "Fake")
+			if tc.want == "" {
+				if ok {
+					t.Errorf("got condition %+v, want none", condition)
+				}
+				return
+			}
+			if !ok || condition.Status != tc.want || condition.Reason != tc.wantReason {
+				t.Errorf("got condition %+v (present %v), want %s with reason %s", condition, ok, tc.want, tc.wantReason)
+			}
+		})
+	}
+}
+
+func TestAutoResolvedConditionKept(t *testing.T) {
+	p := newTestProxy(t, testConfig(t, "/unused.sock", func(config *types.ExternalMonitorConfig) {
+		config.Conditions[0].AutoResolveAfter = time.Minute
+	}))
+	p.lastStatus = &npdt.Status{Conditions: []npdt.Condition{{Type: "Fake", Status: npdt.True, Reason: "Committed"}}}
+	p.lastAsserted["Fake"] = time.Now().Add(-time.Hour)
+
+	status := &npdt.Status{}
+	p.applyAutoResolve(status)
+	p.lastStatus = status
+
+	// The plugin still omits the condition, which stays resolved
+	next := &npdt.Status{}
+	p.applyAutoResolve(next)
+	if condition, ok := findCondition(next.Conditions, "Fake"); !ok || condition.Reason != autoResolvedReason {
+		t.Errorf("got condition %+v (present %v), want it kept resolved", condition, ok)
+	}
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"math"
+	"math/rand"
+	"time"
+
+	"k8s.io/npd-ext/pkg/externalmonitor/types"
+)
+
+// BackoffStrategy computes the delay before a reconnection attempt.
+type BackoffStrategy interface {
+	// Next returns the delay before the given (zero-based) reconnection attempt.
+	Next(attempt int) time.Duration
+
+	// Reset clears any state accumulated across attempts.
+	Reset()
+}
+
+// NewBackoffStrategy creates the backoff strategy selected by the retry policy.
+func NewBackoffStrategy(policy types.RetryPolicy) BackoffStrategy {
+	switch policy.Strategy {
+	case types.BackoffStrategyConstant:
+		return &constantBackoff{delay: policy.InitialBackoff}
+	case types.BackoffStrategyDecorrelatedJitter:
+		return &decorrelatedJitterBackoff{
+			base: policy.InitialBackoff,
+			max:  policy.MaxBackoff,
+			prev: policy.InitialBackoff,
+		}
+	default:
+		return &exponentialBackoff{
+			initial:    policy.InitialBackoff,
+			multiplier: policy.BackoffMultiplier,
+			max:        policy.MaxBackoff,
+		}
+	}
+}
+
+// exponentialBackoff grows the delay by a fixed multiplier on each attempt.
+type exponentialBackoff struct {
+	initial    time.Duration
+	multiplier float64
+	max        time.Duration
+}
+
+func (b *exponentialBackoff) Next(attempt int) time.Duration {
+	backoff := time.Duration(float64(b.initial) * math.Pow(b.multiplier, float64(attempt)))
+	if backoff > b.max {
+		backoff = b.max
+	}
+	return backoff
+}
+
+func (b *exponentialBackoff) Reset() {}
+
+// constantBackoff waits the same delay before every attempt.
+type constantBackoff struct {
+	delay time.Duration
+}
+
+func (b *constantBackoff) Next(attempt int) time.Duration {
+	return b.delay
+}
+
+func (b *constantBackoff) Reset() {}
+
+// decorrelatedJitterBackoff picks a random delay between the base and three
+// times the previous delay, capped at max.
+type decorrelatedJitterBackoff struct {
+	base time.Duration
+	max  time.Duration
+	prev time.Duration
+}
+
+func (b *decorrelatedJitterBackoff) Next(attempt int) time.Duration {
+	upper := b.prev * 3
+	if upper <= b.base {
+		upper = b.base + 1
+	}
+
+	backoff := b.base + time.Duration(rand.Int63n(int64(upper-b.base)))
+	if backoff > b.max {
+		backoff = b.max
+	}
+
+	b.prev = backoff
+	return backoff
+}
+
+func (b *decorrelatedJitterBackoff) Reset() {
+	b.prev = b.base
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"k8s.io/npd-ext/pkg/externalmonitor/types"
+)
+
+func TestExponentialBackoff(t *testing.T) {
+	testCases := []struct {
+		name   string
+		policy types.RetryPolicy
+	}{
+		{
+			name:   "defaults",
+			policy: types.RetryPolicy{InitialBackoff: time.Second, BackoffMultiplier: 2, MaxBackoff: 5 * time.Minute},
+		},
+		{
+			name:   "fractional multiplier",
+			policy: types.RetryPolicy{InitialBackoff: 500 * time.Millisecond, BackoffMultiplier: 1.5, MaxBackoff: 10 * time.Second},
+		},
+		{
+			name:   "capped from the start",
+			policy: types.RetryPolicy{InitialBackoff: time.Minute, BackoffMultiplier: 2, MaxBackoff: 30 * time.Second},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			backoff := NewBackoffStrategy(tc.policy)
+			for attempt := 0; attempt < 12; attempt++ {
+				// The delay used before backoff strategies were configurable
+				want := time.Duration(float64(tc.policy.InitialBackoff) * math.Pow(tc.policy.BackoffMultiplier, float64(attempt)))
+				if want > tc.policy.MaxBackoff {
+					want = tc.policy.MaxBackoff
+				}
+				if got := backoff.Next(attempt); got != want {
+					t.Errorf("Next(%d) = %v, want %v", attempt, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestConstantBackoff(t *testing.T) {
+	backoff := NewBackoffStrategy(types.RetryPolicy{
+		Strategy:       types.BackoffStrategyConstant,
+		InitialBackoff: 3 * time.Second,
+		MaxBackoff:     time.Minute,
+	})
+	for attempt := 0; attempt < 5; attempt++ {
+		if got := backoff.Next(attempt); got != 3*time.Second {
+			t.Errorf("Next(%d) = %v, want 3s", attempt, got)
+		}
+	}
+}
+
+func TestDecorrelatedJitterBackoff(t *testing.T) {
+	base, maxBackoff := time.Second, 20*time.Second
+	backoff := NewBackoffStrategy(types.RetryPolicy{
+		Strategy:       types.BackoffStrategyDecorrelatedJitter,
+		InitialBackoff: base,
+		MaxBackoff:     maxBackoff,
+	})
+
+	prev := base
+	for attempt := 0; attempt < 50; attempt++ {
+		got := backoff.Next(attempt)
+		if got < base || got > maxBackoff || got >= 3*prev && got != maxBackoff {
+			t.Fatalf("Next(%d) = %v, want within [%v, min(3*%v, %v)]", attempt, got, base, prev, maxBackoff)
+		}
+		prev = got
+	}
+
+	backoff.Reset()
+	if got := backoff.Next(0); got < base || got >= 3*base {
+		t.Errorf("Next(0) after Reset = %v, want within [%v, %v)", got, base, 3*base)
+	}
+}
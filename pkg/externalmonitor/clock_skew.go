@@ -0,0 +1,66 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"time"
+
+	"k8s.io/klog/v2"
+
+	npdt "k8s.io/node-problem-detector/pkg/types"
+)
+
+// clampTransition corrects the transition time of a condition stamped by a
+// plugin whose clock is skewed from the proxy's. A transition more than
+// TransitionSkewTolerance in the future, or before the previous transition of
+// the condition, is replaced: with the previous transition if the status is
+// unchanged, since the condition didn't transition, and with now otherwise.
+// Clamping is logged as a warning once until the condition's transitions are
+// sane again.
+func (p *ExternalMonitorProxy) clampTransition(condition *npdt.Condition, now time.Time) {
+	var previous npdt.Condition
+	hasPrevious := false
+	if p.lastStatus != nil {
+		previous, hasPrevious = findCondition(p.lastStatus.Conditions, condition.Type)
+	}
+
+	problem := ""
+	switch {
+	case condition.Transition.After(now.Add(p.config.PluginConfig.TransitionSkewTolerance)):
+		problem = "in the future"
+	case hasPrevious && condition.Transition.Before(previous.Transition):
+		problem = "before the previous transition"
+	default:
+		delete(p.skewedConditions, condition.Type)
+		return
+	}
+
+	clamped := now
+	if hasPrevious && previous.Status == condition.Status && !previous.Transition.After(now) {
+		clamped = previous.Transition
+	}
+
+	if !p.skewedConditions[condition.Type] {
+		p.skewedConditions[condition.Type] = true
+		klog.Warningf("Transition of %s from %s at %s is %s, clamped to %s; check the clocks of the plugin and node",
+			condition.Type, p.name, condition.Transition.Format(time.RFC3339), problem, clamped.Format(time.RFC3339))
+	} else {
+		p.logf(4, "Clamped transition of %s from %s at %s to %s", condition.Type, p.name,
+			condition.Transition.Format(time.RFC3339), clamped.Format(time.RFC3339))
+	}
+	condition.Transition = clamped
+}
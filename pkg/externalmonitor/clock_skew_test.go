@@ -0,0 +1,106 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"testing"
+	"time"
+
+	npdt "k8s.io/node-problem-detector/pkg/types"
+)
+
+func TestClampTransition(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	previousTransition := now.Add(-time.Hour)
+
+	testCases := []struct {
+		name string
+		// previous is the status of the last reported condition, empty for none
+		previous   npdt.ConditionStatus
+		status     npdt.ConditionStatus
+		transition time.Time
+		want       time.Time
+	}{
+		{
+			name:       "sane transition kept",
+			previous:   npdt.False,
+			status:     npdt.True,
+			transition: now.Add(-time.Minute),
+			want:       now.Add(-time.Minute),
+		},
+		{
+			name:       "future transition within tolerance kept",
+			status:     npdt.True,
+			transition: now.Add(3 * time.Second),
+			want:       now.Add(3 * time.Second),
+		},
+		{
+			name:       "future transition of a new condition",
+			status:     npdt.True,
+			transition: now.Add(time.Hour),
+			want:       now,
+		},
+		{
+			name:       "future transition of a changed condition",
+			previous:   npdt.False,
+			status:     npdt.True,
+			transition: now.Add(time.Hour),
+			want:       now,
+		},
+		{
+			name:       "future transition of an unchanged condition",
+			previous:   npdt.True,
+			status:     npdt.True,
+			transition: now.Add(time.Hour),
+			want:       previousTransition,
+		},
+		{
+			name:       "transition before the previous one of a changed condition",
+			previous:   npdt.False,
+			status:     npdt.True,
+			transition: previousTransition.Add(-time.Minute),
+			want:       now,
+		},
+		{
+			name:       "transition before the previous one of an unchanged condition",
+			previous:   npdt.True,
+			status:     npdt.True,
+			transition: previousTransition.Add(-time.Minute),
+			want:       previousTransition,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := newTestProxy(t, testConfig(t, "/unused.sock", nil))
+			if tc.previous != "" {
+				p.lastStatus = &npdt.Status{Conditions: []npdt.Condition{
+					{Type: "Fake", Status: tc.previous, Transition: previousTransition},
+				}}
+			}
+
+			condition := npdt.Condition{Type: "Fake", Status: tc.status, Transition: tc.transition}
+			p.clampTransition(&condition, now)
+			if !condition.Transition.Equal(tc.want) {
+				t.Errorf("clamped transition = %v, want %v", condition.Transition, tc.want)
+			}
+			if got, want := p.skewedConditions["Fake"], !tc.want.Equal(tc.transition); got != want {
+				t.Errorf("condition marked skewed = %v, want %v", got, want)
+			}
+		})
+	}
+}
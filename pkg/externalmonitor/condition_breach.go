@@ -0,0 +1,63 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"k8s.io/klog/v2"
+)
+
+// setConditionBreach records the latest threshold breach magnitude reported
+// for a condition type. NPD conditions carry no magnitude, so it is tracked
+// alongside them and exported as a metric.
+func (p *ExternalMonitorProxy) setConditionBreach(conditionType string, magnitude float64) {
+	p.breachMutex.Lock()
+	p.conditionBreaches[conditionType] = magnitude
+	p.breachMutex.Unlock()
+
+	if !p.config.MetricsReporting || conditionBreachMetric == nil {
+		return
+	}
+	label, ok := p.conditionLabel(conditionType)
+	if !ok {
+		return
+	}
+	if err := conditionBreachMetric.Record(map[string]string{"source": p.name, "condition": label}, magnitude); err != nil {
+		klog.Warningf("Failed to record breach magnitude of %s/%s: %v", p.name, conditionType, err)
+	}
+}
+
+// ConditionBreaches returns the latest threshold breach magnitude reported for
+// each condition type.
+func (p *ExternalMonitorProxy) ConditionBreaches() map[string]float64 {
+	p.breachMutex.RLock()
+	defer p.breachMutex.RUnlock()
+
+	breaches := make(map[string]float64, len(p.conditionBreaches))
+	for conditionType, magnitude := range p.conditionBreaches {
+		breaches[conditionType] = magnitude
+	}
+	return breaches
+}
+
+// conditionBreach returns the latest breach magnitude reported for a condition type.
+func (p *ExternalMonitorProxy) conditionBreach(conditionType string) (float64, bool) {
+	p.breachMutex.RLock()
+	defer p.breachMutex.RUnlock()
+
+	magnitude, ok := p.conditionBreaches[conditionType]
+	return magnitude, ok
+}
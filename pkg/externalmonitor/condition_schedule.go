@@ -0,0 +1,168 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"maps"
+	"slices"
+	"sort"
+	"time"
+
+	"k8s.io/klog/v2"
+	npdt "k8s.io/node-problem-detector/pkg/types"
+
+	"k8s.io/npd-ext/pkg/externalmonitor/types"
+)
+
+// startConditionSchedules starts a ticker for every distinct per-condition
+// invoke interval. It returns the condition selector for the regular checks,
+// which lists the conditions without their own interval when any are scheduled
+// separately, and a channel delivering the selector of each scheduled check
+// that is due. The channel is nil when no conditions are scheduled separately.
+func (p *ExternalMonitorProxy) startConditionSchedules() ([]string, <-chan []string) {
+	var selector []string
+	scheduled := make(map[time.Duration][]string)
+	for _, condDef := range p.config.Conditions {
+		if condDef.InvokeInterval > 0 {
+			scheduled[condDef.InvokeInterval] = append(scheduled[condDef.InvokeInterval], condDef.Type)
+		} else {
+			selector = append(selector, condDef.Type)
+		}
+	}
+	if len(scheduled) == 0 {
+		return nil, nil
+	}
+
+	checks := make(chan []string)
+	for interval, conditions := range scheduled {
+		p.logf(4, "Checking conditions %v of %s every %v", conditions, p.name, interval)
+		go p.runConditionSchedule(interval, conditions, checks)
+	}
+	return selector, checks
+}
+
+// runConditionSchedule requests a check of the given conditions every interval
+// until the monitor stops.
+func (p *ExternalMonitorProxy) runConditionSchedule(interval time.Duration, conditions []string, checks chan<- []string) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			select {
+			case checks <- conditions:
+			case <-p.tomb.Stopping():
+				return
+			}
+		case <-p.tomb.Stopping():
+			return
+		}
+	}
+}
+
+// mergeConditions fills in the conditions a selective check did not evaluate
+// from the last status, keeping the order of the last status so that unchanged
+// conditions compare equal.
+func (p *ExternalMonitorProxy) mergeConditions(status *npdt.Status) {
+	if p.lastStatus == nil {
+		return
+	}
+
+	checked := make(map[string]npdt.Condition, len(status.Conditions))
+	for _, condition := range status.Conditions {
+		checked[condition.Type] = condition
+	}
+
+	merged := make([]npdt.Condition, 0, len(p.lastStatus.Conditions)+len(checked))
+	for _, condition := range p.lastStatus.Conditions {
+		if update, ok := checked[condition.Type]; ok {
+			condition = update
+			delete(checked, condition.Type)
+		}
+		merged = append(merged, condition)
+	}
+
+	// Conditions reported for the first time go last, in a stable order
+	added := make([]string, 0, len(checked))
+	for conditionType := range checked {
+		added = append(added, conditionType)
+	}
+	sort.Strings(added)
+	for _, conditionType := range added {
+		merged = append(merged, checked[conditionType])
+	}
+
+	status.Conditions = merged
+}
+
+// conditionParameters returns the request parameters for a check of the given
+// conditions: parameters with the parameters of the selected conditions added.
+// Of several conditions overriding the timeout the largest override wins.
+func (p *ExternalMonitorProxy) conditionParameters(parameters map[string]string, conditions []string) map[string]string {
+	var merged map[string]string
+	var timeout time.Duration
+	for _, condDef := range p.config.Conditions {
+		if len(condDef.Parameters) == 0 || !slices.Contains(conditions, condDef.Type) {
+			continue
+		}
+		if merged == nil {
+			merged = maps.Clone(parameters)
+			if merged == nil {
+				merged = make(map[string]string, len(condDef.Parameters))
+			}
+		}
+		for name, value := range condDef.Parameters {
+			if name == types.TimeoutParameter {
+				override, _ := types.ParseTimeoutParameter(condDef.Parameters)
+				if override <= timeout {
+					continue
+				}
+				timeout = override
+			}
+			merged[name] = value
+		}
+	}
+	if merged == nil {
+		return parameters
+	}
+	return merged
+}
+
+// checkTimeout returns the call timeout for a check of the given conditions
+// sent with the given request parameters: the _timeout parameter, else the
+// largest timeout override among the conditions, else the monitor timeout,
+// clamped to maxTimeout.
+func (p *ExternalMonitorProxy) checkTimeout(conditions []string, parameters map[string]string) time.Duration {
+	timeout := p.config.PluginConfig.Timeout
+	for _, condDef := range p.config.Conditions {
+		if condDef.Timeout > timeout && slices.Contains(conditions, condDef.Type) {
+			timeout = condDef.Timeout
+		}
+	}
+	if override, err := types.ParseTimeoutParameter(parameters); err != nil {
+		klog.Warningf("Ignoring timeout override of %s: %v", p.name, err)
+	} else if override > 0 {
+		timeout = override
+	}
+
+	if maxTimeout := p.config.PluginConfig.MaxTimeout; maxTimeout > 0 && timeout > maxTimeout {
+		p.logf(4, "Clamping timeout of %v for %s to maxTimeout %v", conditions, p.name, maxTimeout)
+		timeout = maxTimeout
+	}
+	return timeout
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pb "k8s.io/npd-ext/api/services/external/v1"
+	"k8s.io/npd-ext/pkg/externalmonitor/types"
+)
+
+func TestCheckTimeout(t *testing.T) {
+	testCases := []struct {
+		name       string
+		maxTimeout time.Duration
+		conditions []string
+		parameters map[string]string
+		want       time.Duration
+	}{
+		{
+			name: "monitor timeout",
+			want: time.Second,
+		},
+		{
+			name:       "condition override",
+			maxTimeout: time.Minute,
+			conditions: []string{"Scrub"},
+			want:       30 * time.Second,
+		},
+		{
+			name:       "timeout parameter wins over condition override",
+			maxTimeout: time.Minute,
+			conditions: []string{"Scrub"},
+			parameters: map[string]string{types.TimeoutParameter: "45s"},
+			want:       45 * time.Second,
+		},
+		{
+			name:       "timeout parameter clamped to maxTimeout",
+			maxTimeout: time.Minute,
+			parameters: map[string]string{types.TimeoutParameter: "5m"},
+			want:       time.Minute,
+		},
+		{
+			name:       "timeout parameter clamped to default maxTimeout",
+			parameters: map[string]string{types.TimeoutParameter: "5m"},
+			want:       time.Second,
+		},
+		{
+			name:       "invalid timeout parameter ignored",
+			maxTimeout: time.Minute,
+			parameters: map[string]string{types.TimeoutParameter: "soon"},
+			want:       time.Second,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := newTestProxy(t, testConfig(t, "/unused.sock", func(config *types.ExternalMonitorConfig) {
+				config.PluginConfig.MaxTimeout = tc.maxTimeout
+				config.Conditions = append(config.Conditions, types.ConditionDefinition{
+					Type: "Scrub", Reason: "ScrubPassed", Message: "ok",
+					InvokeInterval: time.Hour, Timeout: 30 * time.Second,
+				})
+			}))
+
+			if got := p.checkTimeout(tc.conditions, tc.parameters); got != tc.want {
+				t.Errorf("checkTimeout(%v, %v) = %v, want %v", tc.conditions, tc.parameters, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestConditionParameters(t *testing.T) {
+	p := newTestProxy(t, testConfig(t, "/unused.sock", func(config *types.ExternalMonitorConfig) {
+		config.Conditions = append(config.Conditions,
+			types.ConditionDefinition{
+				Type: "Scrub", Reason: "ScrubPassed", Message: "ok", InvokeInterval: time.Hour,
+				Parameters: map[string]string{"mode": "full", types.TimeoutParameter: "2m"},
+			},
+			types.ConditionDefinition{
+				Type: "Burn", Reason: "BurnPassed", Message: "ok", InvokeInterval: time.Hour,
+				Parameters: map[string]string{types.TimeoutParameter: "1m"},
+			})
+	}))
+	base := map[string]string{"mode": "quick", "threshold": "85"}
+
+	got := p.conditionParameters(base, []string{"Burn", "Scrub"})
+	want := map[string]string{"mode": "full", "threshold": "85", types.TimeoutParameter: "2m"}
+	if len(got) != len(want) {
+		t.Fatalf("conditionParameters = %v, want %v", got, want)
+	}
+	for name, value := range want {
+		if got[name] != value {
+			t.Errorf("conditionParameters[%q] = %q, want %q", name, got[name], value)
+		}
+	}
+	if base["mode"] != "quick" {
+		t.Errorf("conditionParameters modified the request parameters: %v", base)
+	}
+
+	if got := p.conditionParameters(base, nil); got["mode"] != "quick" || len(got) != len(base) {
+		t.Errorf("conditionParameters for the regular check = %v, want %v", got, base)
+	}
+}
+
+func TestTimeoutParameterExtendsCall(t *testing.T) {
+	testCases := []struct {
+		name       string
+		parameters map[string]string
+		wantDone   bool
+	}{
+		{
+			name:     "monitor timeout",
+			wantDone: false,
+		},
+		{
+			name:       "timeout parameter",
+			parameters: map[string]string{types.TimeoutParameter: "3s"},
+			wantDone:   true,
+		},
+		{
+			name:       "timeout parameter above maxTimeout",
+			parameters: map[string]string{types.TimeoutParameter: "10s"},
+			wantDone:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// A scrub takes longer than the monitor timeout of one second
+			var done atomic.Bool
+			plugin := &fakePlugin{checkHealth: func(ctx context.Context, req *pb.HealthCheckRequest) (*pb.Status, error) {
+				select {
+				case <-time.After(1500 * time.Millisecond):
+					done.Store(true)
+					return healthyStatus("fake"), nil
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				}
+			}}
+			server := startFakePlugin(t, plugin, nil)
+			p := connectedTestProxy(t, server.socket, func(config *types.ExternalMonitorConfig) {
+				config.PluginConfig.MaxTimeout = 2 * time.Second
+				config.Conditions = append(config.Conditions, types.ConditionDefinition{
+					Type: "Scrub", Reason: "ScrubPassed", Message: "ok",
+					InvokeInterval: time.Hour, Parameters: tc.parameters,
+				})
+			})
+
+			p.checkHealth([]string{"Scrub"})
+
+			if got := done.Load(); got != tc.wantDone {
+				t.Errorf("scrub completed = %v, want %v", got, tc.wantDone)
+			}
+			if req := plugin.lastRequest(); req == nil || req.Parameters[types.TimeoutParameter] != tc.parameters[types.TimeoutParameter] {
+				t.Errorf("got request %v, want parameters %v", req, tc.parameters)
+			}
+		})
+	}
+}
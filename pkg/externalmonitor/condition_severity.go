@@ -0,0 +1,66 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	pb "k8s.io/npd-ext/api/services/external/v1"
+)
+
+// ConditionSeverity is the alert routing severity of a condition. NPD conditions
+// carry no severity, so it is tracked alongside them by the proxy.
+type ConditionSeverity string
+
+const (
+	// ConditionSeverityInfo is the neutral severity used when a plugin sets none.
+	ConditionSeverityInfo ConditionSeverity = "info"
+	// ConditionSeverityWarning marks a minor problem.
+	ConditionSeverityWarning ConditionSeverity = "warning"
+	// ConditionSeverityCritical marks a severe problem.
+	ConditionSeverityCritical ConditionSeverity = "critical"
+)
+
+// convertConditionSeverity converts protobuf ConditionSeverity to ConditionSeverity.
+func convertConditionSeverity(pbSeverity pb.ConditionSeverity) ConditionSeverity {
+	switch pbSeverity {
+	case pb.ConditionSeverity_CONDITION_SEVERITY_WARNING:
+		return ConditionSeverityWarning
+	case pb.ConditionSeverity_CONDITION_SEVERITY_CRITICAL:
+		return ConditionSeverityCritical
+	default:
+		return ConditionSeverityInfo
+	}
+}
+
+// setConditionSeverity records the latest severity reported for a condition type.
+func (p *ExternalMonitorProxy) setConditionSeverity(conditionType string, severity ConditionSeverity) {
+	p.severityMutex.Lock()
+	defer p.severityMutex.Unlock()
+
+	p.conditionSeverities[conditionType] = severity
+}
+
+// ConditionSeverities returns the latest severity reported for each condition type.
+func (p *ExternalMonitorProxy) ConditionSeverities() map[string]ConditionSeverity {
+	p.severityMutex.RLock()
+	defer p.severityMutex.RUnlock()
+
+	severities := make(map[string]ConditionSeverity, len(p.conditionSeverities))
+	for conditionType, severity := range p.conditionSeverities {
+		severities[conditionType] = severity
+	}
+	return severities
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"k8s.io/npd-ext/pkg/externalmonitor/types"
+)
+
+var (
+	configType   = reflect.TypeOf(types.ExternalMonitorConfig{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// configKeyNormalizer normalizes the keys of a configuration file decoded as
+// generic JSON.
+type configKeyNormalizer struct {
+	configPath string
+
+	// unknown are the JSON paths of the keys that match no field.
+	unknown []string
+}
+
+// normalizeConfigKeys renames alternate spellings of configuration keys, such
+// as "invokeInterval" for "invoke_interval", to the spelling
+// ExternalMonitorConfig expects. Spellings match when they are equal ignoring
+// case and underscores. Each renamed key is logged as deprecated, and setting
+// both spellings of a key is an error. Durations given as strings, such as
+// "30s", are replaced by the nanoseconds time.Duration decodes from JSON. It
+// returns the sorted JSON paths of the keys that match no field.
+func normalizeConfigKeys(configPath string, config map[string]interface{}) ([]string, error) {
+	n := &configKeyNormalizer{configPath: configPath}
+	if err := n.normalizeObject("", config, configType); err != nil {
+		return nil, err
+	}
+	sort.Strings(n.unknown)
+	return n.unknown, nil
+}
+
+// normalizeObject normalizes the keys of object, decoded for the struct type
+// t, and of the objects nested in it. prefix is the JSON path of object.
+func (n *configKeyNormalizer) normalizeObject(prefix string, object map[string]interface{}, t reflect.Type) error {
+	fields := make(map[string]reflect.StructField)
+	spellings := make(map[string]string)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := jsonFieldName(field)
+		if name == "" {
+			continue
+		}
+		fields[name] = field
+		spellings[configKeySpelling(name)] = name
+	}
+
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	for _, key := range keys {
+		value := object[key]
+		name := key
+		if _, ok := fields[key]; !ok {
+			canonical, ok := spellings[configKeySpelling(key)]
+			if !ok {
+				n.unknown = append(n.unknown, prefix+key)
+				continue
+			}
+			if _, ok := object[canonical]; ok {
+				return &types.ConfigParseError{Err: fmt.Errorf("%s sets both %s and %s, remove %s",
+					n.configPath, prefix+key, prefix+canonical, prefix+key)}
+			}
+			klog.Warningf("Configuration key %s in %s is deprecated, use %s instead",
+				prefix+key, n.configPath, prefix+canonical)
+			delete(object, key)
+			object[canonical] = value
+			name = canonical
+		}
+
+		if fields[name].Type == durationType {
+			if err := n.normalizeDuration(prefix+name, object, name); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := n.normalizeValue(prefix+name, value, fields[name].Type); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// normalizeDuration replaces the duration string under key in object, if any,
+// by its nanoseconds. path is the JSON path of the key.
+func (n *configKeyNormalizer) normalizeDuration(path string, object map[string]interface{}, key string) error {
+	value, ok := object[key].(string)
+	if !ok {
+		return nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return &types.ConfigParseError{Err: fmt.Errorf("%s in %s is not a duration: %v", path, n.configPath, err)}
+	}
+	object[key] = json.Number(strconv.FormatInt(int64(d), 10))
+	return nil
+}
+
+// normalizeValue normalizes the keys of the objects in value, decoded for type
+// t. Objects decoded into maps hold user-defined keys and are left as is.
+func (n *configKeyNormalizer) normalizeValue(path string, value interface{}, t reflect.Type) error {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		object, ok := value.(map[string]interface{})
+		if !ok || t == reflect.TypeOf(time.Time{}) {
+			return nil
+		}
+		return n.normalizeObject(path+".", object, t)
+	case reflect.Slice:
+		items, ok := value.([]interface{})
+		if !ok {
+			return nil
+		}
+		for i, item := range items {
+			if err := n.normalizeValue(fmt.Sprintf("%s[%d]", path, i), item, t.Elem()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// jsonFieldName returns the JSON key of a struct field, or "" if the field is
+// not decoded from JSON.
+func jsonFieldName(field reflect.StructField) string {
+	if field.PkgPath != "" {
+		return ""
+	}
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return field.Name
+	}
+	return name
+}
+
+// configKeySpelling returns the spelling of a configuration key ignoring case
+// and underscores.
+func configKeySpelling(key string) string {
+	return strings.ToLower(strings.ReplaceAll(key, "_", ""))
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeConfigKeys(t *testing.T) {
+	testCases := []struct {
+		name        string
+		config      string
+		want        string
+		wantUnknown []string
+		wantErr     bool
+	}{
+		{
+			name:   "canonical keys",
+			config: `{"source": "gpu", "pluginConfig": {"invoke_interval": 1}}`,
+			want:   `{"source": "gpu", "pluginConfig": {"invoke_interval": 1}}`,
+		},
+		{
+			name:   "alternate spellings",
+			config: `{"Source": "gpu", "plugin_config": {"invokeInterval": 1, "retry_policy": {"max_attempts": 3}}}`,
+			want:   `{"source": "gpu", "pluginConfig": {"invoke_interval": 1, "retryPolicy": {"maxAttempts": 3}}}`,
+		},
+		{
+			name:   "keys of objects in arrays",
+			config: `{"conditions": [{"type": "A", "debounce_count": 2}]}`,
+			want:   `{"conditions": [{"type": "A", "debounceCount": 2}]}`,
+		},
+		{
+			name:   "user-defined map keys left as is",
+			config: `{"pluginConfig": {"pluginParameters": {"Temperature_Threshold": "85"}}}`,
+			want:   `{"pluginConfig": {"pluginParameters": {"Temperature_Threshold": "85"}}}`,
+		},
+		{
+			name:        "unknown keys",
+			config:      `{"sourse": "gpu", "pluginConfig": {"sockAddress": "/x.sock"}, "conditions": [{"kind": "A"}]}`,
+			want:        `{"sourse": "gpu", "pluginConfig": {"sockAddress": "/x.sock"}, "conditions": [{"kind": "A"}]}`,
+			wantUnknown: []string{"conditions[0].kind", "pluginConfig.sockAddress", "sourse"},
+		},
+		{
+			name:    "both spellings",
+			config:  `{"pluginConfig": {"invoke_interval": 1, "invokeInterval": 2}}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var config map[string]interface{}
+			if err := json.Unmarshal([]byte(tc.config), &config); err != nil {
+				t.Fatal(err)
+			}
+
+			unknown, err := normalizeConfigKeys("test.json", config)
+			if tc.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "sets both") {
+					t.Errorf("normalizeConfigKeys error = %v, want one about setting both spellings", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("normalizeConfigKeys: %v", err)
+			}
+			if !reflect.DeepEqual(unknown, tc.wantUnknown) {
+				t.Errorf("unknown keys = %v, want %v", unknown, tc.wantUnknown)
+			}
+
+			var want map[string]interface{}
+			if err := json.Unmarshal([]byte(tc.want), &want); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(config, want) {
+				t.Errorf("normalized config = %v, want %v", config, want)
+			}
+		})
+	}
+}
+
+func TestNormalizeConfigKeysDurations(t *testing.T) {
+	testCases := []struct {
+		name    string
+		config  string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "duration strings",
+			config: `{"pluginConfig": {"timeout": "10s", "retryPolicy": {"initialBackoff": "1m30s"}}}`,
+			want:   `{"pluginConfig":{"retryPolicy":{"initialBackoff":90000000000},"timeout":10000000000}}`,
+		},
+		{
+			name:   "durations of objects in arrays",
+			config: `{"conditions": [{"type": "A", "invokeInterval": "2m"}]}`,
+			want:   `{"conditions":[{"invokeInterval":120000000000,"type":"A"}]}`,
+		},
+		{
+			name:   "nanoseconds left as is",
+			config: `{"pluginConfig": {"timeout": 5000000000}}`,
+			want:   `{"pluginConfig":{"timeout":5000000000}}`,
+		},
+		{
+			name:   "renamed key",
+			config: `{"pluginConfig": {"invokeInterval": "30s"}}`,
+			want:   `{"pluginConfig":{"invoke_interval":30000000000}}`,
+		},
+		{
+			name:    "not a duration",
+			config:  `{"pluginConfig": {"timeout": "ten seconds"}}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			decoder := json.NewDecoder(strings.NewReader(tc.config))
+			decoder.UseNumber()
+			var config map[string]interface{}
+			if err := decoder.Decode(&config); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := normalizeConfigKeys("test.json", config)
+			if tc.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "pluginConfig.timeout") {
+					t.Errorf("normalizeConfigKeys error = %v, want one naming pluginConfig.timeout", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("normalizeConfigKeys: %v", err)
+			}
+
+			got, err := json.Marshal(config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tc.want {
+				t.Errorf("normalized config = %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
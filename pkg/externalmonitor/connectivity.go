@@ -0,0 +1,147 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	npdt "k8s.io/node-problem-detector/pkg/types"
+	pb "k8s.io/npd-ext/api/services/external/v1"
+	"k8s.io/npd-ext/pkg/externalmonitor/types"
+)
+
+// ConnectivityReport is the result of CheckConnectivity.
+type ConnectivityReport struct {
+	Source string
+	Socket string
+
+	// Latency of each step that completed
+	DialLatency     time.Duration
+	MetadataLatency time.Duration
+	CheckLatency    time.Duration
+
+	// Metadata and Status returned by the plugin, nil if the call failed
+	Metadata *pb.MonitorMetadata
+	Status   *pb.Status
+}
+
+// CheckConnectivity dials the plugin of an applied configuration, fetches its
+// metadata and runs one health check, without starting a monitor. It lets
+// operators verify a plugin before enabling it. The report is returned even
+// on error, holding the steps that completed.
+func CheckConnectivity(config *types.ExternalMonitorConfig) (*ConnectivityReport, error) {
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	// Dialing may set proxy conditions, which are discarded
+	p := &ExternalMonitorProxy{
+		name:            config.Source,
+		config:          config,
+		statusChan:      make(chan *npdt.Status, 10),
+		proxyConditions: make(map[string]npdt.Condition),
+	}
+	report := &ConnectivityReport{Source: config.Source}
+
+	report.Socket = p.selectSocket()
+	if report.Socket == "" {
+		return report, fmt.Errorf("no socket of %s is available: %s",
+			p.name, strings.Join(config.PluginConfig.Sockets(), ", "))
+	}
+
+	start := time.Now()
+	conn, err := p.dial(report.Socket)
+	if err != nil {
+		return report, fmt.Errorf("failed to connect to %s: %v", p.name, err)
+	}
+	defer conn.Close()
+	report.DialLatency = time.Since(start)
+	client := pb.NewExternalMonitorClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), config.PluginConfig.Timeout)
+	defer cancel()
+
+	start = time.Now()
+	metadata, err := client.GetMetadata(ctx, &emptypb.Empty{})
+	if err != nil {
+		return report, fmt.Errorf("GetMetadata failed with %s: %s", status.Code(err), errorMessage(err))
+	}
+	report.MetadataLatency = time.Since(start)
+	report.Metadata = metadata
+
+	// Send the parameters the monitor would send
+	p.metadata = metadata
+	if p.parameters, err = p.coerceParameters(metadata.Parameters); err != nil {
+		return report, err
+	}
+	if p.parameters, err = p.encryptParameters(p.parameters, metadata.ParameterEncryptionKey); err != nil {
+		return report, err
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), config.PluginConfig.Timeout)
+	defer cancel()
+
+	start = time.Now()
+	resp, err := client.CheckHealth(ctx, &pb.HealthCheckRequest{
+		Parameters: p.requestParameters(),
+		Sequence:   1,
+	})
+	if err != nil {
+		return report, fmt.Errorf("CheckHealth failed with %s: %s", status.Code(err), errorMessage(err))
+	}
+	report.CheckLatency = time.Since(start)
+	report.Status = resp
+
+	return report, nil
+}
+
+// String renders the report for operators, one line per fact.
+func (r *ConnectivityReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "External monitor: %s\n", r.Source)
+	if r.Socket != "" {
+		fmt.Fprintf(&b, "Socket: %s\n", r.Socket)
+	}
+	if r.DialLatency > 0 {
+		fmt.Fprintf(&b, "Connected in %v\n", r.DialLatency.Round(time.Microsecond))
+	}
+	if r.Metadata != nil {
+		fmt.Fprintf(&b, "Metadata in %v: name=%s, version=%s, api_version=%s\n",
+			r.MetadataLatency.Round(time.Microsecond), r.Metadata.Name, r.Metadata.Version, r.Metadata.ApiVersion)
+		if len(r.Metadata.SupportedConditions) > 0 {
+			fmt.Fprintf(&b, "  Supported conditions: %s\n", strings.Join(r.Metadata.SupportedConditions, ", "))
+		}
+	}
+	if r.Status != nil {
+		fmt.Fprintf(&b, "CheckHealth in %v: %d conditions, %d events\n",
+			r.CheckLatency.Round(time.Microsecond), len(r.Status.Conditions), len(r.Status.Events))
+		for _, condition := range r.Status.Conditions {
+			fmt.Fprintf(&b, "  %s=%s %s: %s\n", condition.Type,
+				strings.TrimPrefix(condition.Status.String(), "CONDITION_STATUS_"), condition.Reason, condition.Message)
+		}
+		for _, event := range r.Status.Events {
+			fmt.Fprintf(&b, "  event %s: %s\n", event.Reason, event.Message)
+		}
+	}
+	return b.String()
+}
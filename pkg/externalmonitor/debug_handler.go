@@ -0,0 +1,277 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"k8s.io/klog/v2"
+)
+
+// NewDebugHandler returns an HTTP handler exposing administrative operations
+// on the registered external monitor proxies.
+func NewDebugHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", handleHelp)
+	mux.HandleFunc("/monitors", handleMonitors)
+	mux.HandleFunc("/healthz", handleHealthz)
+	mux.HandleFunc("/reconnect", handleReconnect)
+	mux.HandleFunc("/pause", handlePause)
+	mux.HandleFunc("/resume", handleResume)
+	mux.HandleFunc("/maintenance", handleMaintenance)
+	mux.HandleFunc("/logs", handleLogs)
+	return mux
+}
+
+// debugHelp describes the debug endpoints.
+const debugHelp = `External monitor debug endpoints:
+
+  GET  /monitors                  state of every external monitor
+  GET  /healthz                   overall health of the external monitors
+  POST /reconnect?source=S        reconnect monitor S to its plugin
+  POST /pause?source=S            stop sending statuses of monitor S; checks keep running
+  POST /resume?source=S           send statuses of monitor S again, starting with the
+                                  conditions of its latest check; plugin events raised
+                                  while paused are dropped, and their number logged
+  POST /maintenance?source=S&enabled=true|false
+                                  suppress problem conditions of monitor S
+  GET  /logs?source=S&lines=N     recent log lines of the plugin of monitor S
+`
+
+// handleHelp lists the debug endpoints.
+func handleHelp(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprint(w, debugHelp)
+}
+
+// StartDebugServer serves the debug handler on the given address in the background.
+func StartDebugServer(address string) {
+	go func() {
+		klog.Infof("Starting external monitor debug server on %s", address)
+		if err := http.ListenAndServe(address, NewDebugHandler()); err != nil {
+			klog.Errorf("External monitor debug server failed: %v", err)
+		}
+	}()
+}
+
+// MonitorState is a snapshot of an external monitor proxy's state.
+type MonitorState struct {
+	Source    string `json:"source"`
+	Connected bool   `json:"connected"`
+	Ready     bool   `json:"ready"`
+	Paused    bool   `json:"paused"`
+
+	// PluginReady is false while the plugin can't serve requests, even if
+	// its channel is connected.
+	PluginReady bool `json:"pluginReady"`
+
+	// Maintenance is true while conditions are suppressed for maintenance.
+	Maintenance bool `json:"maintenance"`
+
+	// Sequence is the number of health checks issued, ErrorCount the number
+	// of consecutive failed calls.
+	Sequence   int64 `json:"sequence"`
+	ErrorCount int64 `json:"errorCount"`
+
+	// ConfigHash is the plugin's advertised configuration hash.
+	ConfigHash string `json:"configHash,omitempty"`
+
+	ConditionSeverities map[string]ConditionSeverity `json:"conditionSeverities,omitempty"`
+	ConditionBreaches   map[string]float64           `json:"conditionBreaches,omitempty"`
+}
+
+// ListMonitors returns the state of every registered external monitor proxy.
+func ListMonitors() []MonitorState {
+	var states []MonitorState
+	for _, p := range registry.list() {
+		states = append(states, p.state())
+	}
+	return states
+}
+
+// state returns a snapshot of the proxy's state.
+func (p *ExternalMonitorProxy) state() MonitorState {
+	return MonitorState{
+		Source:    p.name,
+		Connected: p.isConnected(),
+		Ready:     p.IsReady(),
+		Paused:    p.IsPaused(),
+
+		PluginReady: p.isPluginReady(),
+
+		Maintenance: p.InMaintenance(),
+
+		Sequence:   p.sequenceNumber.Load(),
+		ErrorCount: p.errorCount.Load(),
+		ConfigHash: p.configHash(),
+
+		ConditionSeverities: p.ConditionSeverities(),
+		ConditionBreaches:   p.ConditionBreaches(),
+	}
+}
+
+// configHash returns the configuration hash from the plugin metadata.
+func (p *ExternalMonitorProxy) configHash() string {
+	p.connectionMutex.RLock()
+	defer p.connectionMutex.RUnlock()
+
+	if p.metadata == nil {
+		return ""
+	}
+	return p.metadata.ConfigHash
+}
+
+// handleMonitors lists the registered monitors and their state.
+func handleMonitors(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(ListMonitors()); err != nil {
+		klog.Errorf("Failed to encode monitor list: %v", err)
+	}
+}
+
+// handleReconnect forces an immediate reconnection for the monitor named by
+// the "source" query parameter.
+func handleReconnect(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	p, ok := lookupSource(w, r)
+	if !ok {
+		return
+	}
+
+	if err := p.ForceReconnect(); err != nil {
+		http.Error(w, fmt.Sprintf("reconnect failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	fmt.Fprintf(w, "reconnected %s\n", p.name)
+}
+
+// handlePause pauses status reporting for the monitor named by the "source" query parameter.
+func handlePause(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	p, ok := lookupSource(w, r)
+	if !ok {
+		return
+	}
+
+	p.Pause()
+	fmt.Fprintf(w, "paused %s, plugin events are dropped until it resumes\n", p.name)
+}
+
+// handleResume resumes status reporting for the monitor named by the "source" query parameter.
+func handleResume(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	p, ok := lookupSource(w, r)
+	if !ok {
+		return
+	}
+
+	p.Resume()
+	fmt.Fprintf(w, "resumed %s\n", p.name)
+}
+
+// handleMaintenance enables or disables maintenance for the monitor named by
+// the "source" query parameter, according to the "enabled" query parameter.
+func handleMaintenance(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	p, ok := lookupSource(w, r)
+	if !ok {
+		return
+	}
+
+	enabled, err := strconv.ParseBool(r.URL.Query().Get("enabled"))
+	if err != nil {
+		http.Error(w, "enabled query parameter must be true or false", http.StatusBadRequest)
+		return
+	}
+
+	p.SetMaintenance(enabled)
+	fmt.Fprintf(w, "maintenance for %s set to %v\n", p.name, enabled)
+}
+
+// handleLogs writes the most recent log lines of the monitor named by the
+// "source" query parameter, at most the "lines" query parameter if set.
+func handleLogs(w http.ResponseWriter, r *http.Request) {
+	p, ok := lookupSource(w, r)
+	if !ok {
+		return
+	}
+
+	maxLines := 0
+	if value := r.URL.Query().Get("lines"); value != "" {
+		var err error
+		if maxLines, err = strconv.Atoi(value); err != nil || maxLines <= 0 {
+			http.Error(w, "lines query parameter must be a positive integer", http.StatusBadRequest)
+			return
+		}
+	}
+
+	lines, err := p.TailLogs(r.Context(), maxLines)
+	switch {
+	case errors.Is(err, errLogTailUnimplemented):
+		http.Error(w, err.Error(), http.StatusNotImplemented)
+		return
+	case errors.Is(err, errLogTailRateLimited):
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+		return
+	case err != nil:
+		http.Error(w, fmt.Sprintf("tailing logs failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	for _, line := range lines {
+		fmt.Fprintln(w, line)
+	}
+}
+
+// lookupSource resolves the proxy named by the "source" query parameter,
+// writing an error response if it is missing or unknown.
+func lookupSource(w http.ResponseWriter, r *http.Request) (*ExternalMonitorProxy, bool) {
+	source := r.URL.Query().Get("source")
+	if source == "" {
+		http.Error(w, "source query parameter is required", http.StatusBadRequest)
+		return nil, false
+	}
+
+	p, ok := registry.get(source)
+	if !ok {
+		http.Error(w, fmt.Sprintf("unknown source %q", source), http.StatusNotFound)
+		return nil, false
+	}
+
+	return p, true
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	npdt "k8s.io/node-problem-detector/pkg/types"
+
+	"k8s.io/npd-ext/pkg/externalmonitor/types"
+)
+
+// checkDerivedSources verifies that the inputs of the derived conditions
+// reference configured external monitors.
+func (p *ExternalMonitorProxy) checkDerivedSources() error {
+	for _, derived := range p.config.DerivedConditions {
+		for _, input := range derived.Inputs {
+			if input.Source != p.name && !registry.isDeclared(input.Source) {
+				return fmt.Errorf("derived condition %s of %s references unknown source %q",
+					derived.Type, p.name, input.Source)
+			}
+		}
+	}
+	return nil
+}
+
+// appendDerivedConditions returns a copy of the status with the derived
+// conditions added after the reported ones.
+func (p *ExternalMonitorProxy) appendDerivedConditions(status *npdt.Status) *npdt.Status {
+	if len(p.config.DerivedConditions) == 0 {
+		return status
+	}
+
+	derived := *status
+	derived.Conditions = append([]npdt.Condition(nil), status.Conditions...)
+	for _, config := range p.config.DerivedConditions {
+		derived.Conditions = append(derived.Conditions, p.deriveCondition(config))
+	}
+	return &derived
+}
+
+// deriveCondition evaluates a derived condition over the last conditions
+// reported by its input monitors.
+func (p *ExternalMonitorProxy) deriveCondition(config types.DerivedConditionConfig) npdt.Condition {
+	var problems, unknown []string
+	for _, input := range config.Inputs {
+		name := input.Source + "/" + input.Type
+		switch p.inputStatus(input) {
+		case npdt.True:
+			problems = append(problems, name)
+		case npdt.Unknown:
+			unknown = append(unknown, name)
+		}
+	}
+
+	condition := npdt.Condition{Type: config.Type}
+	switch {
+	case config.Operator == types.DerivedOperatorOr && len(problems) > 0,
+		config.Operator == types.DerivedOperatorAnd && len(problems) == len(config.Inputs):
+		condition.Status = npdt.True
+		condition.Reason = config.Reason
+		if condition.Reason == "" {
+			condition.Reason = "DerivedConditionTrue"
+		}
+		condition.Message = config.Message
+		if condition.Message == "" {
+			condition.Message = fmt.Sprintf("Problem reported by %s", strings.Join(problems, ", "))
+		}
+	case len(unknown) > 0 && (config.Operator == types.DerivedOperatorOr ||
+		len(problems)+len(unknown) == len(config.Inputs)):
+		condition.Status = npdt.Unknown
+		condition.Reason = "DerivedConditionUnknown"
+		condition.Message = fmt.Sprintf("Status unknown for %s", strings.Join(unknown, ", "))
+	default:
+		condition.Status = npdt.False
+		condition.Reason = "DerivedConditionFalse"
+		condition.Message = fmt.Sprintf("No problem combined from %d conditions", len(config.Inputs))
+	}
+
+	p.reportedMutex.Lock()
+	defer p.reportedMutex.Unlock()
+
+	last, ok := p.derivedConditions[config.Type]
+	if ok && last.Status != condition.Status && config.DebouncePeriod > 0 {
+		// Hold the change back until the inputs agree for the debounce period
+		pending, held := p.pendingDerived[config.Type]
+		if !held || pending.status != condition.Status {
+			pending = pendingDerivedChange{status: condition.Status, since: time.Now()}
+			p.pendingDerived[config.Type] = pending
+		}
+		if time.Since(pending.since) < config.DebouncePeriod {
+			p.logf(4, "Holding derived condition %s of %s at %s, %s for %v",
+				config.Type, p.name, last.Status, condition.Status, time.Since(pending.since).Round(time.Millisecond))
+			return last
+		}
+	}
+	delete(p.pendingDerived, config.Type)
+
+	// Keep the transition time while the status is unchanged
+	condition.Transition = time.Now()
+	if ok && last.Status == condition.Status {
+		condition.Transition = last.Transition
+	}
+	p.derivedConditions[config.Type] = condition
+	return condition
+}
+
+// derivedChangePending reports whether a derived status change is waiting out
+// its debounce period.
+func (p *ExternalMonitorProxy) derivedChangePending() bool {
+	p.reportedMutex.RLock()
+	defer p.reportedMutex.RUnlock()
+
+	return len(p.pendingDerived) > 0
+}
+
+// pendingDerivedChange is a derived status change waiting out its debounce period.
+type pendingDerivedChange struct {
+	status npdt.ConditionStatus
+	since  time.Time
+}
+
+// inputStatus returns the status of an input condition, Unknown when its
+// monitor is not running or has not reported it.
+func (p *ExternalMonitorProxy) inputStatus(input types.DerivedConditionInput) npdt.ConditionStatus {
+	source := p
+	if input.Source != p.name {
+		peer, ok := registry.get(input.Source)
+		if !ok {
+			return npdt.Unknown
+		}
+		source = peer
+	}
+
+	condition, ok := source.reportedCondition(input.Type)
+	if !ok {
+		return npdt.Unknown
+	}
+	return condition.Status
+}
@@ -0,0 +1,51 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"os"
+	"strings"
+
+	"k8s.io/npd-ext/pkg/externalmonitor/types"
+)
+
+// roundRobinServiceConfig balances calls across all resolved endpoints of a
+// network target instead of gRPC's default of using the first one.
+const roundRobinServiceConfig = `{"loadBalancingConfig": [{"round_robin": {}}]}`
+
+// dialTarget returns the gRPC target for a socket address. Unix socket paths
+// use the unix resolver, network addresses the DNS resolver.
+func dialTarget(socket string) string {
+	switch {
+	case strings.HasPrefix(socket, "unix:"), strings.HasPrefix(socket, "dns:"):
+		return socket
+	case types.IsNetworkAddress(socket):
+		return "dns:///" + socket
+	default:
+		return "unix://" + socket
+	}
+}
+
+// socketAvailable returns true if the socket may be dialed. Unix sockets must
+// exist, network addresses are always tried.
+func socketAvailable(socket string) error {
+	if types.IsNetworkAddress(socket) {
+		return nil
+	}
+	_, err := os.Stat(strings.TrimPrefix(socket, "unix://"))
+	return err
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/status"
+)
+
+// PluginCheckFailedCondition is reported when CheckHealth returns an error
+// from the plugin itself, rather than from the transport, and is cleared by
+// the next successful check.
+const PluginCheckFailedCondition = "PluginCheckFailed"
+
+// errorMessage returns the message of a gRPC status error followed by the
+// reason, domain and metadata of any ErrorInfo details it carries, so a
+// condition built from it names the precise cause.
+func errorMessage(err error) string {
+	st := status.Convert(err)
+	message := st.Message()
+
+	for _, detail := range st.Details() {
+		info, ok := detail.(*errdetails.ErrorInfo)
+		if !ok {
+			continue
+		}
+
+		parts := []string{"reason " + info.Reason}
+		if info.Domain != "" {
+			parts = append(parts, "domain "+info.Domain)
+		}
+		if len(info.Metadata) > 0 {
+			keys := make([]string, 0, len(info.Metadata))
+			for key := range info.Metadata {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+
+			pairs := make([]string, len(keys))
+			for i, key := range keys {
+				pairs[i] = key + "=" + info.Metadata[key]
+			}
+			parts = append(parts, "metadata "+strings.Join(pairs, ", "))
+		}
+		message += " (" + strings.Join(parts, "; ") + ")"
+	}
+
+	return message
+}
+
+// setCheckFailed reports PluginCheckFailed with the cause of a CheckHealth error.
+func (p *ExternalMonitorProxy) setCheckFailed(err error) {
+	p.setProxyCondition(PluginCheckFailedCondition, true, "CheckHealthError",
+		p.limitMessage("CheckHealth error", fmt.Sprintf("CheckHealth call to %s failed with %s: %s",
+			p.name, status.Code(err), errorMessage(err))))
+}
+
+// setCheckSucceeded clears PluginCheckFailed after a successful check.
+func (p *ExternalMonitorProxy) setCheckSucceeded() {
+	p.setProxyCondition(PluginCheckFailedCondition, false, "CheckHealthSucceeded",
+		fmt.Sprintf("External monitor %s completes health checks", p.name))
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc/status"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// PluginHighErrorRateCondition is reported when the share of failed
+	// CheckHealth calls within the error rate window exceeds the threshold.
+	PluginHighErrorRateCondition = "PluginHighErrorRate"
+
+	// errorRateMinSamples is how many calls the window must hold before the
+	// error rate is trusted.
+	errorRateMinSamples = 4
+)
+
+// callOutcome is the result of one CheckHealth call.
+type callOutcome struct {
+	at     time.Time
+	failed bool
+}
+
+// errorRateTracker keeps the outcomes of recent calls within a window.
+type errorRateTracker struct {
+	outcomes []callOutcome
+}
+
+// observe adds an outcome and drops those older than window.
+func (t *errorRateTracker) observe(now time.Time, failed bool, window time.Duration) {
+	t.outcomes = append(t.outcomes, callOutcome{at: now, failed: failed})
+
+	cutoff := now.Add(-window)
+	expired := 0
+	for expired < len(t.outcomes) && t.outcomes[expired].at.Before(cutoff) {
+		expired++
+	}
+	t.outcomes = t.outcomes[expired:]
+}
+
+// rate returns the share of failed calls in the window.
+func (t *errorRateTracker) rate() float64 {
+	if len(t.outcomes) == 0 {
+		return 0
+	}
+
+	failed := 0
+	for _, outcome := range t.outcomes {
+		if outcome.failed {
+			failed++
+		}
+	}
+	return float64(failed) / float64(len(t.outcomes))
+}
+
+// observeCallOutcome records the outcome of a CheckHealth call. When the error
+// rate over the window reaches the threshold, the plugin is marked unhealthy
+// and a reconnection is requested even without a streak of consecutive errors.
+func (p *ExternalMonitorProxy) observeCallOutcome(err error) {
+	window := p.config.PluginConfig.HealthCheck.ErrorRateWindow
+	if window <= 0 {
+		return
+	}
+
+	failed := err != nil && !p.isBenignError("CheckHealth", status.Code(err))
+	p.errorRate.observe(time.Now(), failed, window)
+	rate := p.errorRate.rate()
+	p.recordErrorRate(rate)
+
+	if len(p.errorRate.outcomes) < errorRateMinSamples {
+		return
+	}
+
+	// Messages don't carry the rate itself, so the condition is only sent
+	// when it changes
+	threshold := p.config.PluginConfig.HealthCheck.ErrorRateThreshold
+	if rate < threshold {
+		p.setProxyCondition(PluginHighErrorRateCondition, false, "ErrorRateNormal",
+			fmt.Sprintf("Fewer than %.0f%% of CheckHealth calls to %s failed in the last %v",
+				threshold*100, p.name, window))
+		return
+	}
+
+	p.setProxyCondition(PluginHighErrorRateCondition, true, "ErrorRateAboveThreshold",
+		fmt.Sprintf("At least %.0f%% of CheckHealth calls to %s failed in the last %v",
+			threshold*100, p.name, window))
+	if failed {
+		klog.Warningf("Error rate for %s is %.0f%% over %v, requesting reconnection", p.name, rate*100, window)
+		p.setReady(false)
+		p.requestReconnect()
+	}
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "k8s.io/npd-ext/api/services/external/v1"
+	"k8s.io/npd-ext/pkg/externalmonitor/types"
+)
+
+func TestErrorRateTracker(t *testing.T) {
+	start := time.Now()
+	type call struct {
+		after  time.Duration
+		failed bool
+	}
+
+	testCases := []struct {
+		name      string
+		calls     []call
+		wantCalls int
+		wantRate  float64
+	}{
+		{
+			name:     "no calls",
+			wantRate: 0,
+		},
+		{
+			name:      "all succeeded",
+			calls:     []call{{0, false}, {time.Second, false}},
+			wantCalls: 2,
+			wantRate:  0,
+		},
+		{
+			name:      "half failed",
+			calls:     []call{{0, true}, {time.Second, false}, {2 * time.Second, true}, {3 * time.Second, false}},
+			wantCalls: 4,
+			wantRate:  0.5,
+		},
+		{
+			name:      "failures outside the window expire",
+			calls:     []call{{0, true}, {time.Second, true}, {90 * time.Second, false}, {91 * time.Second, true}},
+			wantCalls: 2,
+			wantRate:  0.5,
+		},
+		{
+			name:      "call at the window edge kept",
+			calls:     []call{{0, true}, {time.Minute, false}},
+			wantCalls: 2,
+			wantRate:  0.5,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var tracker errorRateTracker
+			for _, c := range tc.calls {
+				tracker.observe(start.Add(c.after), c.failed, time.Minute)
+			}
+			if got := len(tracker.outcomes); got != tc.wantCalls {
+				t.Errorf("tracked %d calls, want %d", got, tc.wantCalls)
+			}
+			if got := tracker.rate(); got != tc.wantRate {
+				t.Errorf("rate() = %v, want %v", got, tc.wantRate)
+			}
+		})
+	}
+}
+
+func TestHighErrorRateRequestsReconnection(t *testing.T) {
+	plugin := &fakePlugin{checkHealth: func(context.Context, *pb.HealthCheckRequest) (*pb.Status, error) {
+		return nil, status.Error(codes.Internal, "backend broken")
+	}}
+	server := startFakePlugin(t, plugin, nil)
+	p := connectedTestProxy(t, server.socket, func(config *types.ExternalMonitorConfig) {
+		config.PluginConfig.HealthCheck.ErrorRateWindow = time.Minute
+		config.PluginConfig.HealthCheck.ErrorThreshold = 100
+	})
+
+	for i := 0; i < errorRateMinSamples; i++ {
+		p.checkHealth(nil)
+	}
+
+	// The check doesn't reconnect itself, the health check loop does
+	select {
+	case <-p.reconnectChan:
+	default:
+		t.Fatal("no reconnection requested at a high error rate")
+	}
+	if n := server.accepted.Load(); n != 1 {
+		t.Errorf("got %d connections, want the check not to reconnect", n)
+	}
+	if p.IsReady() {
+		t.Error("monitor with a high error rate is ready")
+	}
+
+	// A new connection answers the request
+	p.requestReconnect()
+	if err := p.connectSocket(server.socket); err != nil {
+		t.Fatalf("connectSocket: %v", err)
+	}
+	select {
+	case <-p.reconnectChan:
+		t.Error("reconnection still requested after reconnecting")
+	default:
+	}
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"time"
+
+	pb "k8s.io/npd-ext/api/services/external/v1"
+)
+
+// eventDedupKey returns the key identifying repeats of an event. Plugins may
+// supply an explicit key; otherwise the reason and message are used.
+func eventDedupKey(event *pb.Event) string {
+	if event.DedupKey != "" {
+		return event.DedupKey
+	}
+	return event.Reason + "/" + event.Message
+}
+
+// isDuplicateEvent reports whether an event with the same dedup key was
+// forwarded within the dedup window, recording the event otherwise.
+func (p *ExternalMonitorProxy) isDuplicateEvent(event *pb.Event, now time.Time) bool {
+	window := p.config.PluginConfig.EventDedupWindow
+	if window <= 0 {
+		return false
+	}
+
+	// Forget events that have aged out of the window
+	for key, seen := range p.recentEvents {
+		if now.Sub(seen) >= window {
+			delete(p.recentEvents, key)
+		}
+	}
+
+	key := eventDedupKey(event)
+	if _, ok := p.recentEvents[key]; ok {
+		return true
+	}
+
+	p.recentEvents[key] = now
+	return false
+}
@@ -17,9 +17,14 @@ limitations under the License.
 package externalmonitor
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"k8s.io/klog/v2"
 
@@ -31,6 +36,9 @@ import (
 const (
 	// MonitorName is the name used for registering the external monitor.
 	MonitorName = "external-monitor"
+
+	// StdinConfigPath is the config path that reads the configuration from stdin.
+	StdinConfigPath = "-"
 )
 
 func init() {
@@ -56,8 +64,8 @@ func NewExternalMonitorOrDie(configPath string) npdt.Monitor {
 		klog.Fatalf("Failed to apply external monitor configuration: %v", err)
 	}
 
-	if err := config.Validate(); err != nil {
-		klog.Fatalf("Invalid external monitor configuration: %v", err)
+	if errs := config.ValidateAll(); len(errs) > 0 {
+		klog.Fatalf("Invalid external monitor configuration in %s:\n%s", configPath, formatValidationErrors(errs))
 	}
 
 	monitor, err := NewExternalMonitorProxy(config)
@@ -66,29 +74,151 @@ func NewExternalMonitorOrDie(configPath string) npdt.Monitor {
 	}
 
 	klog.Infof("Created external monitor: %s (socket: %s)",
-		config.Source, config.PluginConfig.SocketAddress)
+		config.Source, strings.Join(config.PluginConfig.Sockets(), ","))
 
 	return monitor
 }
 
-// LoadConfiguration loads and parses the external monitor configuration from a file.
+// AllowUnknownConfigFields makes LoadConfiguration ignore configuration keys
+// that match no field, with a warning, instead of failing. It allows a newer
+// configuration to be loaded by an older binary.
+var AllowUnknownConfigFields bool
+
+// formatValidationErrors renders validation errors one per line, naming the
+// invalid field of each.
+func formatValidationErrors(errs []error) string {
+	lines := make([]string, 0, len(errs))
+	for _, err := range errs {
+		var validationErr *types.ConfigValidationError
+		if errors.As(err, &validationErr) {
+			lines = append(lines, fmt.Sprintf("  field %s: %v", validationErr.Field, err))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("  %v", err))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// maxExtendsDepth bounds the chain of configuration files extending each other,
+// which also stops cycles.
+const maxExtendsDepth = 10
+
+// LoadConfiguration loads and parses the external monitor configuration from a file,
+// or from stdin when configPath is StdinConfigPath. Errors are a
+// *types.ConfigReadError, *types.ConfigParseError or
+// *types.ConfigUnknownFieldError.
+//
+// A configuration may set "extends" to the path of a base configuration,
+// relative to the extending file. The configuration is deep-merged over the
+// base: objects are merged key by key, while any other value, including
+// arrays, replaces the base value.
+//
+// Keys may be spelled ignoring case and underscores, e.g. "invokeInterval"
+// for "invoke_interval"; such spellings are deprecated and logged. A key
+// that matches no field is an error unless AllowUnknownConfigFields is set.
 func LoadConfiguration(configPath string) (*types.ExternalMonitorConfig, error) {
-	// Read configuration file (reusing pattern from custompluginmonitor)
-	configBytes, err := readFile(configPath)
+	merged, err := loadConfigTree(configPath, 0)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read config file %s: %v", configPath, err)
+		return nil, err
+	}
+
+	configBytes, err := json.Marshal(merged)
+	if err != nil {
+		return nil, &types.ConfigParseError{Err: err}
 	}
 
 	// Parse JSON configuration
 	var config types.ExternalMonitorConfig
-	if err := json.Unmarshal(configBytes, &config); err != nil {
-		return nil, fmt.Errorf("failed to parse configuration: %v", err)
+	decoder := json.NewDecoder(bytes.NewReader(configBytes))
+	if !AllowUnknownConfigFields {
+		decoder.DisallowUnknownFields()
+	}
+	if err := decoder.Decode(&config); err != nil {
+		return nil, &types.ConfigParseError{Err: err}
 	}
 
 	return &config, nil
 }
 
-// readFile reads the content of a file - abstracted for testing.
+// loadConfigTree reads a configuration as generic JSON and merges it over the
+// configuration it extends, if any.
+func loadConfigTree(configPath string, depth int) (map[string]interface{}, error) {
+	// Read configuration file (reusing pattern from custompluginmonitor)
+	configBytes, err := readFile(configPath)
+	if err != nil {
+		return nil, &types.ConfigReadError{Path: configPath, Err: err}
+	}
+
+	var config map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(configBytes))
+	decoder.UseNumber()
+	if err := decoder.Decode(&config); err != nil {
+		return nil, &types.ConfigParseError{Err: err}
+	}
+
+	extends, hasExtends := config["extends"]
+	delete(config, "extends")
+	unknown, err := normalizeConfigKeys(configPath, config)
+	if err != nil {
+		return nil, err
+	}
+	if len(unknown) > 0 {
+		if !AllowUnknownConfigFields {
+			return nil, &types.ConfigUnknownFieldError{Path: configPath, Fields: unknown}
+		}
+		klog.Warningf("Ignoring unknown configuration keys in %s: %s", configPath, strings.Join(unknown, ", "))
+	}
+	if !hasExtends {
+		return config, nil
+	}
+
+	basePath, ok := extends.(string)
+	if !ok || basePath == "" {
+		return nil, &types.ConfigParseError{Err: fmt.Errorf("extends in %s must be a non-empty path", configPath)}
+	}
+	if depth >= maxExtendsDepth {
+		return nil, &types.ConfigParseError{Err: fmt.Errorf("extends chain from %s is deeper than %d files", configPath, maxExtendsDepth)}
+	}
+	if !filepath.IsAbs(basePath) && configPath != StdinConfigPath {
+		basePath = filepath.Join(filepath.Dir(configPath), basePath)
+	}
+
+	base, err := loadConfigTree(basePath, depth+1)
+	if err != nil {
+		return nil, err
+	}
+	return mergeConfig(base, config), nil
+}
+
+// mergeConfig deep-merges override into base and returns base.
+func mergeConfig(base, override map[string]interface{}) map[string]interface{} {
+	for key, value := range override {
+		baseObject, baseIsObject := base[key].(map[string]interface{})
+		overrideObject, overrideIsObject := value.(map[string]interface{})
+		if baseIsObject && overrideIsObject {
+			base[key] = mergeConfig(baseObject, overrideObject)
+			continue
+		}
+		base[key] = value
+	}
+	return base
+}
+
+// readFile reads the content of a config source - abstracted for testing.
 var readFile = func(path string) ([]byte, error) {
-	return os.ReadFile(path)
+	reader, err := openConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return io.ReadAll(reader)
+}
+
+// openConfig opens a config source for reading - abstracted for testing.
+var openConfig = func(path string) (io.ReadCloser, error) {
+	if path == StdinConfigPath {
+		return io.NopCloser(os.Stdin), nil
+	}
+	return os.Open(path)
 }
\ No newline at end of file
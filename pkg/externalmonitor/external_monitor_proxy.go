@@ -18,10 +18,13 @@ package externalmonitor
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"math"
-	"os"
+	"math/rand"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"google.golang.org/grpc"
@@ -29,6 +32,7 @@ import (
 	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
 	"k8s.io/klog/v2"
@@ -37,8 +41,19 @@ import (
 	"k8s.io/npd-ext/pkg/externalmonitor/types"
 	npdt "k8s.io/node-problem-detector/pkg/types"
 	"k8s.io/node-problem-detector/pkg/util/tomb"
+	"k8s.io/node-problem-detector/pkg/version"
 )
 
+// errSourceMismatch is returned when the plugin metadata name differs from the
+// configured source and StrictSourceCheck is enabled.
+var errSourceMismatch = errors.New("plugin name does not match source")
+
+// metadataRejected returns true if fetching metadata failed because the plugin
+// must not be used, rather than because it could not be reached.
+func metadataRejected(err error) bool {
+	return errors.Is(err, errSourceMismatch) || errors.Is(err, errInvalidParameters)
+}
+
 // ExternalMonitorProxy implements the Monitor interface and proxies calls to external gRPC services.
 type ExternalMonitorProxy struct {
 	name       string
@@ -50,36 +65,242 @@ type ExternalMonitorProxy struct {
 
 	// Connection management
 	connectionMutex  sync.RWMutex
+	// connectMutex serializes connectSocket callers, so that a forced
+	// reconnection and a backoff attempt don't dial at once and replace each
+	// other's connection. Taken before connectionMutex
+	connectMutex     sync.Mutex
 	connected        bool
+	reconnecting     bool
+	idle             bool // Connection closed between checks by IdleTimeout
+	selfTestPassed   bool
+	activeSocket     string
+	activeSocketInode uint64
 	lastConnectAttempt time.Time
 	backoffAttempt   int
-	errorCount       int
+	gaveUpReported   bool
+	lossReported     bool
+	backoff          BackoffStrategy
+	reconnectLimiter ReconnectLimiter
+	errorCount       atomic.Int64
+
+	// Connections established by connectSocket, so that a forced
+	// reconnection can tell that an attempt connected while it waited
+	connections atomic.Uint64
+
+	// Interval of regular checks adopted from the plugin metadata, in
+	// nanoseconds. Zero uses invoke_interval
+	invokeInterval atomic.Int64
+
+	// When logs were last tailed, in Unix nanoseconds
+	logTailAt atomic.Int64
+
+	// Set once Start passed its pre-flight checks
+	started atomic.Bool
+
+	// Set by Stop. Nothing is sent on statusChan once it is set
+	shuttingDown atomic.Bool
+
+	// Held for reading while sending on statusChan outside the loops, and
+	// for writing by Stop to close it
+	statusChanMutex sync.RWMutex
+
+	// Tracks healthCheckLoop, which Stop must wait for before closing
+	// statusChan. monitorLoop is tracked by the tomb
+	loops sync.WaitGroup
+
+	// Readiness, set after the first successful check
+	ready atomic.Bool
+
+	// Set while a CheckHealth call is running
+	checkInFlight atomic.Bool
+
+	// Consecutive failed CheckHealth calls, the last answer of the gRPC
+	// health service, and consecutive failed health service probes, for
+	// isPluginReady
+	checkFailures       atomic.Int64
+	healthService       atomic.Int32
+	healthProbeFailures atomic.Int64
+
+	// CheckHealth latency averages
+	latency latencyTracker
+
+	// Recent CheckHealth outcomes for the error rate
+	errorRate errorRateTracker
+
+	// Derived conditions last reported, by type. Guarded by reportedMutex
+	derivedConditions map[string]npdt.Condition
+
+	// Condition types whose transitions are being clamped for clock skew
+	skewedConditions map[string]bool
+
+	// Last status other than Unknown sent for each condition, by type, for
+	// occurrence events
+	occurredConditions map[string]npdt.ConditionStatus
+
+	// Derived status changes held back by their debounce period, by type.
+	// Guarded by reportedMutex
+	pendingDerived map[string]pendingDerivedChange
+
+	// Pre-connected standby connection, used with WarmStandby
+	standby warmStandby
+
+	// Spreads CheckHealth calls across sockets under weighted-random load
+	// balancing, nil otherwise
+	balancer *socketBalancer
+
+	// Consecutive statuses that failed conversion
+	malformedStatuses int
+
+	// Consecutive failed metadata fetches
+	metadataFailures int
+
+	// Maintenance enabled through the debug endpoint
+	maintenance atomic.Bool
+
+	// When Start was called, for the startup quiet period
+	startedAt time.Time
+
+	// Pause handling
+	paused     atomic.Bool
+	resumeChan chan struct{}
+	heldStatus *npdt.Status
+	heldEvents int
+
+	// Requests a check from monitorLoop right after connecting
+	connectedChan chan struct{}
+
+	// Requests a reconnection from healthCheckLoop
+	reconnectChan chan struct{}
+
+	// Requests monitorLoop to emit its condition rollup after another monitor
+	// changed it, and the rolled-up conditions last emitted
+	rollupChan chan struct{}
+	rolledUp   []npdt.Condition
+
+	// Device count advertised in the plugin metadata, for per-device conditions
+	deviceCount atomic.Int32
 
 	// Status tracking
-	sequenceNumber   int64
+	droppedStatuses  atomic.Int64
+	sequenceNumber   atomic.Int64
 	lastStatus       *npdt.Status
 	metadata         *pb.MonitorMetadata
+	metadataFetchedAt time.Time
+	metadataSocket    string
+
+	// PluginParameters normalized against the plugin's parameter specs, nil
+	// when the plugin advertises none
+	parameters map[string]string
+
+	// Start of the current outage, zero while reachable, and the reason
+	// last reported for it
+	disconnectedSince time.Time
+	outageReason      string
+
+	// Conditions last sent, for heartbeat re-emission
+	sentConditions     []npdt.Condition
+	conditionsSentAt   time.Time
+
+	// Debounce tracking, keyed by condition type
+	pendingTransitions map[string]*pendingTransition
+
+	// Consecutive statuses omitting a condition, by type
+	missedChecks map[string]int
+
+	// When the plugin last reported a condition True, by type
+	lastAsserted map[string]time.Time
+
+	// Latest severity per condition type
+	severityMutex       sync.RWMutex
+	conditionSeverities map[string]ConditionSeverity
+
+	// Breach magnitudes reported by the plugin, by condition type
+	breachMutex       sync.RWMutex
+	conditionBreaches map[string]float64
+
+	// Condition label values recorded in per-condition metrics, and whether
+	// reaching MaxConditionValues was logged. Guarded by metricLabelsMutex
+	metricLabelsMutex sync.Mutex
+	conditionLabels   map[string]bool
+	labelCapReported  bool
+
+	// Last time each event was forwarded, keyed by dedup key
+	recentEvents map[string]time.Time
+
+	// Conditions generated by the proxy, keyed by condition type
+	proxyConditionsMutex sync.Mutex
+	proxyConditions      map[string]npdt.Condition
+
+	// Conditions last reported by this monitor, for aggregation across monitors
+	reportedMutex      sync.RWMutex
+	reportedConditions map[string]npdt.Condition
+}
+
+// pendingTransition is a condition status change that has not yet been
+// observed for enough consecutive checks to be forwarded.
+type pendingTransition struct {
+	status npdt.ConditionStatus
+	count  int
 }
 
 // NewExternalMonitorProxy creates a new external monitor proxy.
 func NewExternalMonitorProxy(config *types.ExternalMonitorConfig) (*ExternalMonitorProxy, error) {
 	if err := config.Validate(); err != nil {
-		return nil, fmt.Errorf("invalid configuration: %v", err)
+		return nil, fmt.Errorf("invalid configuration: %w", err)
 	}
+	for _, warning := range config.Warnings() {
+		klog.Warning(warning)
+	}
+
+	initMetrics()
 
 	proxy := &ExternalMonitorProxy{
 		name:       config.Source,
 		config:     config,
 		statusChan: make(chan *npdt.Status, 1000), // Buffer size matches custompluginmonitor
 		tomb:       tomb.NewTomb(),
+		backoff:    NewBackoffStrategy(config.PluginConfig.RetryPolicy),
+		resumeChan: make(chan struct{}, 1),
+
+		reconnectLimiter: NewReconnectLimiter(config.PluginConfig.RetryPolicy),
+
+		connectedChan: make(chan struct{}, 1),
+		reconnectChan: make(chan struct{}, 1),
+		rollupChan:    make(chan struct{}, 1),
+
+		pendingTransitions: make(map[string]*pendingTransition),
+		missedChecks:       make(map[string]int),
+		lastAsserted:       make(map[string]time.Time),
+		proxyConditions:    make(map[string]npdt.Condition),
+		recentEvents:       make(map[string]time.Time),
+		reportedConditions: make(map[string]npdt.Condition),
+
+		conditionSeverities: make(map[string]ConditionSeverity),
+		conditionBreaches:   make(map[string]float64),
+		conditionLabels:     make(map[string]bool),
+		derivedConditions:   make(map[string]npdt.Condition),
+		occurredConditions:  make(map[string]npdt.ConditionStatus),
+		skewedConditions:    make(map[string]bool),
+		pendingDerived:      make(map[string]pendingDerivedChange),
 	}
 
+	registry.declare(proxy.name)
+
 	return proxy, nil
 }
 
 // Start implements the Monitor interface. Returns a status channel and starts monitoring.
+// It fails only on setup problems, such as a second Start or metrics that
+// could not be created; a plugin that cannot be reached yet is retried in the
+// background.
 func (p *ExternalMonitorProxy) Start() (<-chan *npdt.Status, error) {
 	klog.Infof("Starting external monitor proxy: %s", p.name)
+	p.startedAt = time.Now()
+
+	// Fail on setup problems that reconnecting cannot fix
+	if err := p.preflight(); err != nil {
+		return nil, err
+	}
 
 	// Attempt initial connection
 	if err := p.connect(); err != nil {
@@ -87,10 +308,21 @@ func (p *ExternalMonitorProxy) Start() (<-chan *npdt.Status, error) {
 		// Don't fail startup - will retry in background
 	}
 
+	if p.config.PluginConfig.WarmStandby {
+		p.maintainStandby()
+	}
+
+	if p.config.PluginConfig.LoadBalance == types.LoadBalanceWeightedRandom {
+		p.balancer = p.newSocketBalancer()
+	}
+
+	registry.register(p)
+
 	// Start monitoring loop
 	go p.monitorLoop()
 
 	// Start health check loop
+	p.loops.Add(1)
 	go p.healthCheckLoop()
 
 	return p.statusChan, nil
@@ -100,70 +332,120 @@ func (p *ExternalMonitorProxy) Start() (<-chan *npdt.Status, error) {
 func (p *ExternalMonitorProxy) Stop() {
 	klog.Infof("Stopping external monitor proxy: %s", p.name)
 
-	// Send stop signal to external plugin
-	if p.isConnected() {
+	// Prevent reconnections, then stop the plugin and close the connection in
+	// one critical section so the connection cannot be swapped in between
+	p.connectionMutex.Lock()
+	p.shuttingDown.Store(true)
+	if p.connectedUnsafe() {
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-
 		if _, err := p.client.Stop(ctx, &emptypb.Empty{}); err != nil {
-			klog.Warningf("Failed to send stop signal to %s: %v", p.name, err)
+			// Plugins without a Stop handler are expected, just close the
+			// connection. The proxy does not own the plugin process, so
+			// there is nothing to signal
+			if status.Code(err) == codes.Unimplemented {
+				klog.Infof("External monitor %s does not implement Stop, closing the connection", p.name)
+			} else {
+				klog.Warningf("Failed to send stop signal to %s: %v", p.name, err)
+			}
 		}
+		cancel()
 	}
-
-	// Stop internal loops
-	p.tomb.Stop()
-
-	// Close connection
-	p.connectionMutex.Lock()
 	if p.conn != nil {
 		p.conn.Close()
 		p.conn = nil
 	}
+	p.connected = false
 	p.connectionMutex.Unlock()
 
-	// Close status channel
+	// Stop internal loops. The health check loop may still be reconnecting or
+	// refreshing metadata, both of which send events
+	p.tomb.Stop()
+	p.loops.Wait()
+
+	if p.balancer != nil {
+		p.balancer.close()
+	}
+	p.standby.close()
+
+	registry.unregister(p)
+	if p.config.ConditionAggregation != "" {
+		releaseRollups()
+	}
+
+	// Close status channel once no event is being sent on it
+	p.statusChanMutex.Lock()
 	close(p.statusChan)
+	p.statusChanMutex.Unlock()
 
 	klog.Infof("External monitor proxy stopped: %s", p.name)
 }
 
 // connect establishes gRPC connection to the external plugin.
 func (p *ExternalMonitorProxy) connect() error {
-	p.connectionMutex.Lock()
-	defer p.connectionMutex.Unlock()
+	// Prefer the first reachable socket, falling back to the primary
+	socket := p.selectSocket()
+	if socket == "" {
+		socket = p.config.PluginConfig.Sockets()[0]
+	}
 
-	if p.conn != nil {
-		p.conn.Close()
+	p.connectMutex.Lock()
+	defer p.connectMutex.Unlock()
+	if err := p.connectSocket(socket); err != nil {
+		return fmt.Errorf("failed to connect to external monitor %s: %w", p.name, err)
 	}
 
-	// Create gRPC connection with keepalive
-	conn, err := grpc.Dial(
-		"unix://"+p.config.PluginConfig.SocketAddress,
+	klog.Infof("Connected to external monitor: %s (socket: %s)", p.name, socket)
+	return nil
+}
+
+// dial creates a gRPC client connection to the plugin socket.
+func (p *ExternalMonitorProxy) dial(socket string) (*grpc.ClientConn, error) {
+	opts := []grpc.DialOption{
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
 		grpc.WithKeepaliveParams(keepalive.ClientParameters{
 			Time:                30 * time.Second,
 			Timeout:             10 * time.Second,
 			PermitWithoutStream: true,
 		}),
-	)
-	if err != nil {
-		return fmt.Errorf("failed to connect to external monitor %s: %v", p.name, err)
+		grpc.WithUserAgent(fmt.Sprintf("node-problem-detector/%s external-monitor/%s", version.Version(), p.name)),
 	}
 
-	p.conn = conn
-	p.client = pb.NewExternalMonitorClient(conn)
-	p.connected = true
-	p.backoffAttempt = 0
-	p.errorCount = 0
+	// Send the configured headers on every call
+	if headers := p.config.PluginConfig.Headers; len(headers) > 0 {
+		opts = append(opts, grpc.WithUnaryInterceptor(headersInterceptor(headers)))
+	}
+
+	// Verify the peer process before handing the connection to gRPC
+	if p.config.PluginConfig.PeerCredentials.Enabled() {
+		opts = append(opts, grpc.WithContextDialer(p.dialVerifiedPeer))
+	}
 
-	klog.Infof("Connected to external monitor: %s", p.name)
+	// Spread calls across the endpoints a network address resolves to
+	if types.IsNetworkAddress(socket) {
+		opts = append(opts, grpc.WithDefaultServiceConfig(roundRobinServiceConfig))
+	}
 
-	// Get metadata from plugin
-	if err := p.fetchMetadata(); err != nil {
-		klog.Warningf("Failed to fetch metadata from %s: %v", p.name, err)
+	conn, err := grpc.NewClient(dialTarget(socket), opts...)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	// NewClient does not connect until the first call. Connect eagerly and
+	// wait for Ready so a dead endpoint fails within DialTimeout instead of
+	// looking connected, feeding the backoff logic
+	ctx, cancel := context.WithTimeout(context.Background(), p.config.PluginConfig.DialTimeout)
+	defer cancel()
+
+	conn.Connect()
+	for state := conn.GetState(); state != connectivity.Ready; state = conn.GetState() {
+		if !conn.WaitForStateChange(ctx, state) {
+			conn.Close()
+			return nil, fmt.Errorf("connection to %s not ready after %v (state: %s)",
+				socket, p.config.PluginConfig.DialTimeout, state)
+		}
+	}
+
+	return conn, nil
 }
 
 // isConnected safely checks connection status.
@@ -171,6 +453,11 @@ func (p *ExternalMonitorProxy) isConnected() bool {
 	p.connectionMutex.RLock()
 	defer p.connectionMutex.RUnlock()
 
+	return p.connectedUnsafe()
+}
+
+// connectedUnsafe checks connection status without locking.
+func (p *ExternalMonitorProxy) connectedUnsafe() bool {
 	if p.conn == nil {
 		return false
 	}
@@ -179,39 +466,341 @@ func (p *ExternalMonitorProxy) isConnected() bool {
 	return state == connectivity.Ready || state == connectivity.Idle
 }
 
-// fetchMetadata retrieves metadata from the external plugin.
+// isSelfTestPassed safely checks whether the plugin passed its self-test.
+func (p *ExternalMonitorProxy) isSelfTestPassed() bool {
+	p.connectionMutex.RLock()
+	defer p.connectionMutex.RUnlock()
+
+	return p.selfTestPassed
+}
+
+// runSelfTest asks the plugin to verify its backend before normal polling starts.
+// Plugins that do not implement SelfTest are assumed to pass. The call runs
+// without connectionMutex, which is only taken to store the result, and the
+// result is dropped if the connection was replaced meanwhile. Must be called
+// without connectionMutex held.
+func (p *ExternalMonitorProxy) runSelfTest() {
+	p.connectionMutex.RLock()
+	client := p.client
+	p.connectionMutex.RUnlock()
+	if client == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.config.PluginConfig.Timeout)
+	defer cancel()
+
+	passed, reason, message := true, "SelfTestPassed", fmt.Sprintf("External monitor %s passed its self-test", p.name)
+	if p.capabilityDisabled(capabilitySelfTest) {
+		p.logf(4, "SelfTest disabled by %s, assuming passed", p.name)
+	} else {
+		result, err := client.SelfTest(ctx, &emptypb.Empty{})
+		switch {
+		case status.Code(err) == codes.Unimplemented:
+			p.logf(4, "SelfTest not implemented by %s, assuming passed", p.name)
+		case err != nil:
+			klog.Warningf("SelfTest failed for %s: %v", p.name, err)
+			passed, reason = false, "SelfTestError"
+			message = fmt.Sprintf("Self-test call to %s failed with %s: %s", p.name, status.Code(err), errorMessage(err))
+		case !result.Passed:
+			klog.Warningf("SelfTest did not pass for %s: %s", p.name, result.Message)
+			passed, reason, message = false, "SelfTestFailed", result.Message
+		}
+	}
+
+	p.connectionMutex.Lock()
+	replaced := p.client != client
+	if !replaced {
+		p.selfTestPassed = passed
+	}
+	p.connectionMutex.Unlock()
+	if replaced {
+		p.logf(4, "Dropping self-test result of a replaced connection to %s", p.name)
+		return
+	}
+
+	p.setProxyCondition(PluginSelfTestFailedCondition, !passed, reason, message)
+}
+
+// fetchMetadata retrieves metadata from the external plugin. The call runs
+// without connectionMutex, which is only taken to apply the result, and
+// metadata of a connection replaced meanwhile is dropped. Must be called
+// without connectionMutex held.
 func (p *ExternalMonitorProxy) fetchMetadata() error {
+	p.connectionMutex.RLock()
+	client := p.client
+	p.connectionMutex.RUnlock()
+	if client == nil {
+		return fmt.Errorf("external monitor %s is not connected", p.name)
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), p.config.PluginConfig.Timeout)
 	defer cancel()
 
-	metadata, err := p.client.GetMetadata(ctx, &emptypb.Empty{})
+	metadata, err := client.GetMetadata(ctx, &emptypb.Empty{})
+
+	p.connectionMutex.Lock()
+	defer p.connectionMutex.Unlock()
+
+	if p.client != client {
+		p.logf(4, "Dropping metadata of a replaced connection to %s", p.name)
+		return nil
+	}
+	if err != nil {
+		if !p.isBenignError("GetMetadata", status.Code(err)) {
+			p.recordMetadataFailure(err)
+		}
+		return err
+	}
+	if metadata == nil {
+		// Keep any previously fetched metadata rather than overwriting it
+		err := fmt.Errorf("plugin returned nil metadata")
+		p.recordMetadataFailure(err)
+		return err
+	}
+	p.recordMetadataFetched()
+
+	if metadata.Name != "" && metadata.Name != p.config.Source {
+		if p.config.PluginConfig.StrictSourceCheck {
+			return fmt.Errorf("%w: plugin reports %q, configured source is %q",
+				errSourceMismatch, metadata.Name, p.config.Source)
+		}
+		klog.Warningf("External monitor %s reports name %q, check that the socket belongs to this monitor",
+			p.name, metadata.Name)
+	}
+
+	parameters, err := p.coerceParameters(metadata.Parameters)
 	if err != nil {
+		p.setParametersRejected("InvalidParameterType", err)
+		return err
+	}
+	parameters = p.withoutDefaultParameters(parameters, metadata.DefaultParameters)
+	if parameters, err = p.encryptParameters(parameters, metadata.ParameterEncryptionKey); err != nil {
+		p.setParametersRejected("InvalidEncryptionKey", err)
 		return err
 	}
 
+	previous := p.metadata
 	p.metadata = metadata
-	klog.Infof("External monitor %s metadata: version=%s, api_version=%s",
-		p.name, metadata.Version, metadata.ApiVersion)
+	p.parameters = parameters
+	p.setDeviceCount(metadata.DeviceCount)
+	p.metadataFetchedAt = time.Now()
+	p.metadataSocket = p.activeSocket
+	klog.Infof("External monitor %s metadata: version=%s, api_version=%s, config_hash=%s",
+		p.name, metadata.Version, metadata.ApiVersion, metadata.ConfigHash)
+
+	if p.config.ReportVersionCondition {
+		p.setVersionCondition(metadata.Version)
+	}
+	p.applyRecommendedInterval(previous, metadata)
+
+	if previous != nil && previous.ConfigHash != metadata.ConfigHash {
+		klog.Warningf("External monitor %s config hash changed from %q to %q",
+			p.name, previous.ConfigHash, metadata.ConfigHash)
+	}
+
+	if pluginRestarted(previous, metadata) {
+		klog.Warningf("External monitor %s restarted at %v (previously started at %v)",
+			p.name, metadata.StartedAt.AsTime(), previous.StartedAt.AsTime())
+		p.sendEvent(npdt.Warn, "PluginRestarted",
+			fmt.Sprintf("External monitor %s restarted at %s",
+				p.name, metadata.StartedAt.AsTime().Format(time.RFC3339)))
+	}
+
+	return nil
+}
+
+// metadataCached reports whether metadata fetched from the active socket within
+// MetadataCacheTTL can be reused for a new connection. Must be called with
+// connectionMutex held.
+func (p *ExternalMonitorProxy) metadataCached() bool {
+	ttl := p.config.PluginConfig.MetadataCacheTTL
+	return ttl > 0 && p.metadata != nil && p.metadataSocket == p.activeSocket &&
+		time.Since(p.metadataFetchedAt) < ttl
+}
+
+// fetchConnectionMetadata fetches metadata for a new connection, unless cached
+// metadata is still fresh. Failures are
+// logged, except a source mismatch under StrictSourceCheck, which closes the
+// connection. Must be called without connectionMutex held.
+func (p *ExternalMonitorProxy) fetchConnectionMetadata() error {
+	p.connectionMutex.RLock()
+	cached, age := p.metadataCached(), time.Since(p.metadataFetchedAt)
+	p.connectionMutex.RUnlock()
+	if cached {
+		p.logf(4, "Reusing metadata for %s fetched %v ago", p.name, age.Round(time.Second))
+		return nil
+	}
+
+	err := p.fetchMetadata()
+	if err == nil {
+		return nil
+	}
+
+	if metadataRejected(err) {
+		p.connectionMutex.Lock()
+		if p.conn != nil {
+			p.conn.Close()
+			p.conn = nil
+		}
+		p.connected = false
+		p.connectionMutex.Unlock()
+		return err
+	}
 
+	klog.Warningf("Failed to fetch metadata from %s: %v", p.name, err)
 	return nil
 }
 
+// refreshMetadataIfStale re-fetches plugin metadata once it is older than
+// MetadataMaxAge. Must be called without connectionMutex held.
+func (p *ExternalMonitorProxy) refreshMetadataIfStale() {
+	p.connectionMutex.RLock()
+	stale := p.client != nil && time.Since(p.metadataFetchedAt) >= p.config.PluginConfig.MetadataMaxAge
+	p.connectionMutex.RUnlock()
+	if !stale {
+		return
+	}
+
+	p.logf(4, "Refreshing stale metadata for %s", p.name)
+	if err := p.fetchMetadata(); err != nil {
+		klog.Warningf("Failed to refresh metadata from %s: %v", p.name, err)
+		if metadataRejected(err) {
+			p.connectionMutex.Lock()
+			p.connected = false
+			p.connectionMutex.Unlock()
+		}
+	}
+}
+
+// Capabilities a plugin can advertise as "false" to say it doesn't implement
+// an optional RPC. Plugins that don't advertise them are still called and may
+// answer Unimplemented.
+const (
+	capabilitySelfTest = "self_test"
+	capabilityTailLogs = "tail_logs"
+)
+
+// capabilityDisabled reports whether the plugin advertises a capability as
+// "false". Metadata older than MetadataMaxAge is refreshed first, so that a
+// plugin upgraded to support the capability is not skipped on stale metadata.
+// Must be called without connectionMutex held.
+func (p *ExternalMonitorProxy) capabilityDisabled(name string) bool {
+	p.refreshMetadataIfStale()
+
+	p.connectionMutex.RLock()
+	defer p.connectionMutex.RUnlock()
+
+	if p.metadata == nil {
+		return false
+	}
+	value, ok := p.metadata.Capabilities[name]
+	return ok && value == "false"
+}
+
+// requestParameters returns the parameters to send with a health check. When
+// the plugin's defaults take precedence, parameters equal to the advertised
+// default are left out so that the plugin keeps control of them.
+func (p *ExternalMonitorProxy) requestParameters() map[string]string {
+	p.connectionMutex.RLock()
+	defer p.connectionMutex.RUnlock()
+
+	// Prefer the parameters normalized against the plugin's parameter specs
+	// and defaults. Sensitive parameters can't be sent before they are
+	// encrypted.
+	if p.parameters != nil {
+		return p.parameters
+	}
+	return p.withoutSensitiveParameters(p.config.PluginConfig.PluginParameters)
+}
+
+// headersInterceptor returns a client interceptor adding static metadata
+// headers to every call.
+func headersInterceptor(headers map[string]string) grpc.UnaryClientInterceptor {
+	pairs := make([]string, 0, 2*len(headers))
+	for key, value := range headers {
+		pairs = append(pairs, key, value)
+	}
+
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx = metadata.AppendToOutgoingContext(ctx, pairs...)
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// pluginRestarted reports whether the plugin start time advanced between two metadata fetches.
+func pluginRestarted(previous, current *pb.MonitorMetadata) bool {
+	if previous == nil || previous.StartedAt == nil || current.StartedAt == nil {
+		return false
+	}
+	return current.StartedAt.AsTime().After(previous.StartedAt.AsTime())
+}
+
 // monitorLoop is the main monitoring loop that calls CheckHealth periodically.
 func (p *ExternalMonitorProxy) monitorLoop() {
 	defer p.tomb.Done()
 
-	ticker := time.NewTicker(p.config.PluginConfig.InvokeInterval)
+	interval := p.currentInvokeInterval()
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
-	// Send initial status if not skipped
+	// Conditions with their own invoke interval are checked separately, but
+	// still from this loop so that checks never overlap
+	selector, selectiveChecks := p.startConditionSchedules()
+
+	// Send initial status if not skipped. With an initial status delay, a
+	// real check runs first and the initial status is only sent if no status
+	// was produced before the delay expires.
+	var initialStatusTimer <-chan time.Time
 	if !p.config.PluginConfig.SkipInitialStatus {
-		p.sendInitialStatus()
+		if delay := p.config.PluginConfig.InitialStatusDelay; delay > 0 {
+			timer := time.NewTimer(delay)
+			defer timer.Stop()
+			initialStatusTimer = timer.C
+			p.checkHealth(nil)
+
+			// That check also covers the initial connection
+			select {
+			case <-p.connectedChan:
+			default:
+			}
+		} else {
+			p.sendInitialStatus()
+		}
 	}
 
 	for {
 		select {
+		case <-initialStatusTimer:
+			initialStatusTimer = nil
+			if p.lastStatus == nil {
+				p.logf(4, "No status from %s within the initial status delay", p.name)
+				p.sendInitialStatus()
+			}
 		case <-ticker.C:
-			p.checkHealth()
+			if current := p.currentInvokeInterval(); current != interval {
+				p.logf(4, "Checking %s every %v instead of %v", p.name, current, interval)
+				interval = current
+				ticker.Reset(interval)
+			}
+			if selectiveChecks != nil && len(selector) == 0 {
+				// Every condition is checked on its own schedule
+				continue
+			}
+			p.checkHealth(selector)
+		case conditions := <-selectiveChecks:
+			p.checkHealth(conditions)
+		case <-p.connectedChan:
+			if selectiveChecks != nil && len(selector) == 0 {
+				continue
+			}
+			p.logf(4, "Checking health of %s after connecting", p.name)
+			p.checkHealth(selector)
+		case <-p.resumeChan:
+			p.flushHeldStatus()
+		case <-p.rollupChan:
+			p.sendRollup()
 		case <-p.tomb.Stopping():
 			klog.Infof("Monitor loop stopping for %s", p.name)
 			return
@@ -221,15 +810,53 @@ func (p *ExternalMonitorProxy) monitorLoop() {
 
 // healthCheckLoop monitors the gRPC connection health.
 func (p *ExternalMonitorProxy) healthCheckLoop() {
-	ticker := time.NewTicker(p.config.PluginConfig.HealthCheck.Interval)
+	defer p.loops.Done()
+
+	interval := p.config.PluginConfig.HealthCheck.Interval
+
+	// Offset the loop from monitorLoop so that reconnection probes and
+	// health checks don't keep firing at the same instant
+	phase := healthCheckPhase(interval, newPhaseRand())
+	p.logf(4, "Delaying health check loop for %s by %v", p.name, phase)
+	timer := time.NewTimer(phase)
+	select {
+	case <-timer.C:
+	case <-p.tomb.Stopping():
+		timer.Stop()
+		klog.Infof("Health check loop stopping for %s", p.name)
+		return
+	}
+
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ticker.C:
-			if !p.isConnected() {
+			if p.isIdle() {
+				// The connection is dialed again before the next check
+				continue
+			}
+			p.reconnectIfSocketReplaced()
+			if !p.isConnected() && !p.promoteStandby() {
+				p.setReady(false)
 				p.attemptReconnection()
+			} else {
+				p.refreshMetadataIfStale()
+				p.probeHealthService()
+				if !p.isSelfTestPassed() {
+					p.runSelfTest()
+				}
+			}
+			if p.balancer != nil {
+				p.probeBackends(p.balancer)
 			}
+			if p.config.PluginConfig.WarmStandby {
+				p.maintainStandby()
+			}
+		case <-p.reconnectChan:
+			p.logf(4, "Reconnecting %s on request", p.name)
+			p.attemptReconnection()
 		case <-p.tomb.Stopping():
 			klog.Infof("Health check loop stopping for %s", p.name)
 			return
@@ -237,51 +864,288 @@ func (p *ExternalMonitorProxy) healthCheckLoop() {
 	}
 }
 
-// checkHealth calls the external monitor's CheckHealth method.
-func (p *ExternalMonitorProxy) checkHealth() {
-	if !p.isConnected() {
-		klog.V(4).Infof("Skipping health check for %s - not connected", p.name)
+// healthCheckPhaseFraction bounds the health check loop phase offset as a
+// fraction of the health check interval.
+const healthCheckPhaseFraction = 0.1
+
+// newPhaseRand returns the random source for health check phase offsets. It is
+// a variable so that tests can use a fixed seed.
+var newPhaseRand = func() *rand.Rand {
+	return rand.New(rand.NewSource(time.Now().UnixNano()))
+}
+
+// healthCheckPhase returns a random offset in [0, interval*healthCheckPhaseFraction).
+func healthCheckPhase(interval time.Duration, r *rand.Rand) time.Duration {
+	limit := int64(float64(interval) * healthCheckPhaseFraction)
+	if limit <= 0 {
+		return 0
+	}
+	return time.Duration(r.Int63n(limit))
+}
+
+// checkHealth calls the external monitor's CheckHealth method. When conditions
+// is not empty, only those condition types are evaluated by the plugin.
+func (p *ExternalMonitorProxy) checkHealth(conditions []string) {
+	p.wakeIdleConnection()
+	defer p.closeIdleConnection()
+
+	if !p.isConnected() && !p.promoteStandby() {
+		p.logf(4, "Skipping health check for %s - not connected", p.name)
+		p.reportUnreachable()
 		return
 	}
 
-	p.sequenceNumber++
+	// A plugin that says it is not serving would only return errors or
+	// unreliable statuses
+	if p.healthService.Load() == healthServiceNotServing {
+		p.logf(4, "Skipping health check for %s - not serving", p.name)
+		p.reportUnreachable()
+		return
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), p.config.PluginConfig.Timeout)
+	if !p.isSelfTestPassed() {
+		p.logf(4, "Skipping health check for %s - self-test not passed", p.name)
+		return
+	}
+
+	// Never run overlapping checks, which a timeout at or above the invoke
+	// interval would otherwise allow
+	if !p.checkInFlight.CompareAndSwap(false, true) {
+		p.logf(4, "Skipping health check for %s - previous check still in flight", p.name)
+		return
+	}
+	defer p.checkInFlight.Store(false)
+
+	sequence := p.sequenceNumber.Add(1)
+
+	parameters := p.conditionParameters(p.requestParameters(), conditions)
+	ctx, cancel := context.WithTimeout(context.Background(), p.checkTimeout(conditions, parameters))
 	defer cancel()
 
 	req := &pb.HealthCheckRequest{
-		Parameters: p.config.PluginConfig.PluginParameters,
-		Sequence:   p.sequenceNumber,
+		Parameters:     parameters,
+		Sequence:       sequence,
+		NodeConditions: p.nodeConditions(),
+		Conditions:     conditions,
 	}
 
-	status, err := p.client.CheckHealth(ctx, req)
-	if err != nil {
-		p.handleError(err, "CheckHealth")
+	// Spread calls across sockets when load balancing
+	p.connectionMutex.RLock()
+	client := p.client
+	var backend *socketBackend
+	if p.balancer != nil {
+		if backend = p.balancer.pick(); backend != nil {
+			client = backend.client
+		}
+	}
+	p.connectionMutex.RUnlock()
+	if client == nil {
+		p.logf(4, "Skipping health check for %s - connection closed", p.name)
+		return
+	}
+
+	start := time.Now()
+	resp, err := client.CheckHealth(ctx, req)
+	p.observeCallOutcome(err)
+	p.observeCheckResult(err)
+	if err != nil {
+		if backend != nil && status.Code(err) == codes.Unavailable {
+			klog.Warningf("Excluding socket %s of %s from load balancing: %v", backend.socket, p.name, err)
+			p.balancer.markUnhealthy(backend)
+			return
+		}
+		if status.Code(err) == codes.InvalidArgument && len(req.Parameters) > 0 {
+			p.setParametersRejected("InvalidArgument", err)
+		}
+		p.handleError(err, "CheckHealth")
+		if !p.isPluginReady() {
+			p.reportUnreachable()
+		}
+		return
+	}
+	p.observeLatency(time.Since(start))
+	p.setParametersAccepted()
+	p.setCheckSucceeded()
+
+	// Convert protobuf status to internal status
+	internalStatus, err := p.convertStatus(resp)
+	if err != nil {
+		klog.Errorf("Failed to convert status from %s: %v", p.name, err)
+		p.recordMalformedStatus(err)
+		return
+	}
+	p.recordWellFormedStatus()
+	p.recordAssertions(internalStatus)
+
+	// Report conditions the plugin stopped returning as Unknown
+	p.applyStaleness(internalStatus, conditions)
+
+	// Conditions outside a selective check keep their last value
+	if len(conditions) > 0 {
+		p.mergeConditions(internalStatus)
+	}
+
+	// Resolve conditions left True after the plugin stopped asserting them
+	p.applyAutoResolve(internalStatus)
+
+	// Keep problem conditions set until they clear by their margin
+	p.applyHysteresis(internalStatus)
+
+	// Hold back condition changes that have not been stable long enough
+	p.debounceConditions(internalStatus)
+
+	// Suppress conditions during maintenance
+	p.applyMaintenance(internalStatus)
+
+	// Mask problem conditions while hardware settles after startup
+	p.applyStartupQuietPeriod(internalStatus)
+
+	// Send status if changed or first time
+	if p.paused.Load() {
+		p.logf(4, "Holding status from %s while paused", p.name)
+		p.heldStatus = internalStatus
+		p.heldEvents += len(internalStatus.Events)
+	} else if p.shouldSendStatus(internalStatus) {
+		if p.sendStatus(internalStatus) {
+			p.logf(4, "Sent status from %s: %d events, %d conditions",
+				p.name, len(internalStatus.Events), len(internalStatus.Conditions))
+			p.recordConditionsSent(internalStatus.Conditions)
+			p.persistStatus(internalStatus)
+		}
+	} else if p.conditionHeartbeatDue() {
+		p.sendConditionHeartbeat()
+	}
+
+	p.lastStatus = internalStatus
+	p.errorCount.Store(0) // Reset error count on success
+	p.disconnectedSince = time.Time{}
+	p.setReady(true)
+}
+
+// reportUnreachable reports the declared conditions as Unknown while the plugin
+// is unreachable or otherwise not ready, with the cause as reason. The message
+// carries the outage duration and is refreshed on each condition heartbeat.
+func (p *ExternalMonitorProxy) reportUnreachable() {
+	if len(p.config.Conditions) == 0 || p.paused.Load() {
+		return
+	}
+
+	reason, cause := p.notReadyCause()
+	if reason == "" {
+		reason, cause = "PluginUnreachable", "unreachable"
+	}
+
+	firstReport := p.disconnectedSince.IsZero()
+	if firstReport {
+		p.disconnectedSince = time.Now()
+	} else if !p.conditionHeartbeatDue() && p.outageReason == reason {
+		return
+	}
+
+	outage := time.Since(p.disconnectedSince).Round(time.Second)
+	status := &npdt.Status{
+		Source: p.config.Source,
+	}
+	for _, condDef := range p.declaredConditions() {
+		status.Conditions = append(status.Conditions, npdt.Condition{
+			Type:       condDef.Type,
+			Status:     npdt.Unknown,
+			Transition: p.disconnectedSince,
+			Reason:     reason,
+			Message:    fmt.Sprintf("External monitor %s %s for %v", p.name, cause, outage),
+		})
+	}
+
+	p.applyMaintenance(status)
+
+	if p.sendStatus(status) {
+		p.logf(4, "Sent unreachable status from %s (outage %v)", p.name, outage)
+		p.recordConditionsSent(status.Conditions)
+	}
+	p.lastStatus = status
+	p.outageReason = reason
+}
+
+// Pause stops statuses from being sent while health checks keep running.
+func (p *ExternalMonitorProxy) Pause() {
+	if !p.paused.Swap(true) {
+		klog.Infof("Paused external monitor %s", p.name)
+	}
+}
+
+// Resume sends statuses again, starting with the conditions of the latest
+// status held while paused. Plugin events raised while paused are dropped.
+func (p *ExternalMonitorProxy) Resume() {
+	if !p.paused.Swap(false) {
+		return
+	}
+
+	klog.Infof("Resumed external monitor %s", p.name)
+	select {
+	case p.resumeChan <- struct{}{}:
+	default:
+	}
+}
+
+// requestConnectedCheck asks monitorLoop to check health right away instead of
+// waiting for the next tick, so conditions reflect the plugin soon after
+// connecting.
+func (p *ExternalMonitorProxy) requestConnectedCheck() {
+	select {
+	case p.connectedChan <- struct{}{}:
+	default:
+	}
+}
+
+// requestReconnect asks healthCheckLoop to reconnect, so that a check that
+// finds the plugin unhealthy doesn't wait out the backoff itself.
+func (p *ExternalMonitorProxy) requestReconnect() {
+	select {
+	case p.reconnectChan <- struct{}{}:
+	default:
+	}
+}
+
+// IsPaused returns true if the monitor is paused.
+func (p *ExternalMonitorProxy) IsPaused() bool {
+	return p.paused.Load()
+}
+
+// flushHeldStatus sends the conditions of the latest status checked while
+// paused. Plugin events raised while paused are dropped and their number
+// logged.
+func (p *ExternalMonitorProxy) flushHeldStatus() {
+	if p.heldStatus == nil || p.paused.Load() {
 		return
 	}
 
-	// Convert protobuf status to internal status
-	internalStatus, err := p.convertStatus(status)
-	if err != nil {
-		klog.Errorf("Failed to convert status from %s: %v", p.name, err)
-		return
+	// Events held while paused are stale, only conditions are flushed
+	if p.heldEvents > 0 {
+		klog.Infof("Dropping %d events from %s raised while paused", p.heldEvents, p.name)
+	}
+	status := &npdt.Status{
+		Source:     p.heldStatus.Source,
+		Conditions: p.heldStatus.Conditions,
 	}
+	p.heldStatus = nil
+	p.heldEvents = 0
 
-	// Send status if changed or first time
-	if p.shouldSendStatus(internalStatus) {
-		select {
-		case p.statusChan <- internalStatus:
-			klog.V(4).Infof("Sent status from %s: %d events, %d conditions",
-				p.name, len(internalStatus.Events), len(internalStatus.Conditions))
-		case <-p.tomb.Stopping():
-			return
-		default:
-			klog.Warningf("Status channel full for %s, dropping status", p.name)
-		}
+	if p.sendStatus(status) {
+		p.logf(4, "Flushed held status from %s: %d conditions", p.name, len(status.Conditions))
 	}
+}
 
-	p.lastStatus = internalStatus
-	p.errorCount = 0 // Reset error count on success
+// IsReady returns true once the plugin has produced a valid status, and false
+// again after the plugin stays unreachable or keeps failing.
+func (p *ExternalMonitorProxy) IsReady() bool {
+	return p.ready.Load()
+}
+
+// setReady updates readiness, logging transitions.
+func (p *ExternalMonitorProxy) setReady(ready bool) {
+	if p.ready.Swap(ready) != ready {
+		klog.Infof("External monitor %s ready: %v", p.name, ready)
+	}
 }
 
 // convertStatus converts protobuf Status to internal Status.
@@ -289,37 +1153,171 @@ func (p *ExternalMonitorProxy) convertStatus(pbStatus *pb.Status) (*npdt.Status,
 	if pbStatus == nil {
 		return nil, fmt.Errorf("status is nil")
 	}
+	for i, pbCondition := range pbStatus.Conditions {
+		if pbCondition == nil || pbCondition.Type == "" {
+			return nil, fmt.Errorf("condition %d has no type", i)
+		}
+	}
 
 	status := &npdt.Status{
 		Source: pbStatus.Source,
 	}
 
 	// Convert events
+	now := time.Now()
+	suppressed := 0
 	for _, pbEvent := range pbStatus.Events {
+		if p.isDuplicateEvent(pbEvent, now) {
+			p.logf(4, "Suppressing duplicate event %s from %s", pbEvent.Reason, p.name)
+			continue
+		}
+
+		severity := convertSeverity(pbEvent.Severity)
+		if eventSeverityRank[severity] < eventSeverityRank[npdt.Severity(p.config.PluginConfig.MinEventSeverity)] {
+			p.logf(4, "Dropping %s event %s from %s below minEventSeverity", severity, pbEvent.Reason, p.name)
+			continue
+		}
+
+		// Protect NPD from a plugin flooding events
+		if len(status.Events) >= p.config.PluginConfig.MaxEventsPerStatus {
+			suppressed++
+			continue
+		}
+
 		event := npdt.Event{
-			Severity:  convertSeverity(pbEvent.Severity),
+			Severity:  severity,
 			Timestamp: pbEvent.Timestamp.AsTime(),
 			Reason:    pbEvent.Reason,
-			Message:   pbEvent.Message,
+			Message:   p.eventMessage(pbEvent),
 		}
 		status.Events = append(status.Events, event)
 	}
+	if suppressed > 0 {
+		klog.Warningf("Suppressed %d events from %s exceeding maxEventsPerStatus %d",
+			suppressed, p.name, p.config.PluginConfig.MaxEventsPerStatus)
+		status.Events = append(status.Events, npdt.Event{
+			Severity:  npdt.Warn,
+			Timestamp: now,
+			Reason:    "EventsSuppressed",
+			Message: fmt.Sprintf("%d events from %s suppressed, more than %d in one status",
+				suppressed, p.name, p.config.PluginConfig.MaxEventsPerStatus),
+		})
+	}
 
-	// Convert conditions
+	// Convert conditions, keeping one condition per type at the position
+	// where the type first appeared
+	positions := make(map[string]int, len(pbStatus.Conditions))
+	dropped := 0
 	for _, pbCondition := range pbStatus.Conditions {
+		conditionType := p.mapConditionType(pbCondition.Type)
+
+		// Protect NPD from a plugin reporting unbounded condition types
+		if _, ok := positions[conditionType]; !ok && len(status.Conditions) >= p.config.PluginConfig.MaxConditionsPerStatus {
+			dropped++
+			continue
+		}
+
 		condition := npdt.Condition{
-			Type:       pbCondition.Type,
+			Type:       conditionType,
 			Status:     convertConditionStatus(pbCondition.Status),
 			Transition: pbCondition.Transition.AsTime(),
 			Reason:     pbCondition.Reason,
-			Message:    pbCondition.Message,
+			Message:    p.limitMessage("condition "+conditionType, pbCondition.Message),
 		}
-		status.Conditions = append(status.Conditions, condition)
+		if p.config.PluginConfig.ClampTransitions {
+			p.clampTransition(&condition, now)
+		}
+
+		if i, ok := positions[condition.Type]; ok {
+			klog.Warningf("Status from %s reports condition %s more than once, keeping the %s one",
+				p.name, condition.Type, p.config.PluginConfig.DuplicateConditions)
+			if p.config.PluginConfig.DuplicateConditions == types.DuplicateConditionsWorst &&
+				conditionRank[condition.Status] < conditionRank[status.Conditions[i].Status] {
+				continue
+			}
+			status.Conditions[i] = condition
+		} else {
+			positions[condition.Type] = len(status.Conditions)
+			status.Conditions = append(status.Conditions, condition)
+		}
+		p.setConditionSeverity(condition.Type, convertConditionSeverity(pbCondition.Severity))
+		p.setConditionBreach(condition.Type, pbCondition.BreachMagnitude)
+	}
+	if dropped > 0 {
+		klog.Warningf("Dropped %d conditions from %s exceeding maxConditionsPerStatus %d",
+			dropped, p.name, p.config.PluginConfig.MaxConditionsPerStatus)
 	}
 
 	return status, nil
 }
 
+// maxRemediationBytes bounds the remediation hint appended to event messages.
+const maxRemediationBytes = 256
+
+// truncatedMarker ends messages cut at MaxMessageBytes.
+const truncatedMarker = "... (truncated)"
+
+// limitMessage truncates a plugin message to MaxMessageBytes, marking the cut.
+// The field names the condition or event for the warning.
+func (p *ExternalMonitorProxy) limitMessage(field, message string) string {
+	limit := p.config.PluginConfig.MaxMessageBytes
+	if len(message) <= limit {
+		return message
+	}
+
+	klog.Warningf("Truncated message of %s from %s to %d of %d bytes", field, p.name, limit, len(message))
+	return strings.ToValidUTF8(message[:limit], "") + truncatedMarker
+}
+
+// eventMessage returns the event message with the remediation hint and any
+// diagnostics details appended, bounded by MaxEventDetailsBytes.
+func (p *ExternalMonitorProxy) eventMessage(event *pb.Event) string {
+	message := p.limitMessage("event "+event.Reason, event.Message)
+	if event.Remediation != "" {
+		remediation := event.Remediation
+		if len(remediation) > maxRemediationBytes {
+			remediation = strings.ToValidUTF8(remediation[:maxRemediationBytes], "") + "..."
+		}
+		message += "\nRemediation: " + remediation
+	}
+
+	if len(event.Details) == 0 {
+		return message
+	}
+
+	keys := make([]string, 0, len(event.Details))
+	for key := range event.Details {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var details strings.Builder
+	for _, key := range keys {
+		fmt.Fprintf(&details, "\n%s: %s", key, event.Details[key])
+	}
+
+	limit := p.config.PluginConfig.MaxEventDetailsBytes
+	if details.Len() > limit {
+		truncated := strings.ToValidUTF8(details.String()[:limit], "")
+		return message + truncated + "\n... (details truncated)"
+	}
+	return message + details.String()
+}
+
+// mapConditionType returns the NPD condition type for a plugin-reported type.
+func (p *ExternalMonitorProxy) mapConditionType(conditionType string) string {
+	if mapped, ok := p.config.ConditionNameMap[conditionType]; ok {
+		return mapped
+	}
+	return conditionType
+}
+
+// eventSeverityRank orders event severities for minEventSeverity.
+var eventSeverityRank = map[npdt.Severity]int{
+	npdt.Info: 0,
+	npdt.Warn: 1,
+}
+
 // convertSeverity converts protobuf Severity to internal Severity.
 func convertSeverity(pbSeverity pb.Severity) npdt.Severity {
 	switch pbSeverity {
@@ -348,8 +1346,8 @@ func convertConditionStatus(pbStatus pb.ConditionStatus) npdt.ConditionStatus {
 
 // shouldSendStatus determines if the status should be sent.
 func (p *ExternalMonitorProxy) shouldSendStatus(status *npdt.Status) bool {
-	// Always send first status
-	if p.lastStatus == nil {
+	// Always send first status, and every status if configured
+	if p.lastStatus == nil || p.config.PluginConfig.SendEveryStatus {
 		return true
 	}
 
@@ -358,41 +1356,168 @@ func (p *ExternalMonitorProxy) shouldSendStatus(status *npdt.Status) bool {
 		return true
 	}
 
+	// Keep evaluating derived changes held back by their debounce period
+	if p.derivedChangePending() {
+		return true
+	}
+
 	// Send if conditions changed
 	return !p.conditionsEqual(p.lastStatus.Conditions, status.Conditions)
 }
 
-// conditionsEqual checks if two condition slices are equal.
+// recordConditionsSent remembers the conditions last sent for heartbeats.
+func (p *ExternalMonitorProxy) recordConditionsSent(conditions []npdt.Condition) {
+	p.sentConditions = conditions
+	p.conditionsSentAt = time.Now()
+}
+
+// conditionHeartbeatDue reports whether unchanged conditions should be re-sent.
+func (p *ExternalMonitorProxy) conditionHeartbeatDue() bool {
+	interval := p.config.PluginConfig.ConditionHeartbeatInterval
+	if interval <= 0 || len(p.sentConditions) == 0 {
+		return false
+	}
+	return time.Since(p.conditionsSentAt) >= interval
+}
+
+// sendConditionHeartbeat re-sends the last sent conditions unchanged, keeping
+// their original transition times, so downstream freshness checks stay happy.
+func (p *ExternalMonitorProxy) sendConditionHeartbeat() {
+	status := &npdt.Status{
+		Source:     p.config.Source,
+		Conditions: p.sentConditions,
+	}
+
+	if p.sendStatus(status) {
+		p.logf(4, "Sent condition heartbeat from %s: %d conditions", p.name, len(status.Conditions))
+		p.conditionsSentAt = time.Now()
+	}
+}
+
+// debounceConditions replaces condition status changes that have not yet been
+// observed for the configured number of consecutive checks with the last
+// forwarded condition.
+func (p *ExternalMonitorProxy) debounceConditions(status *npdt.Status) {
+	if p.lastStatus == nil {
+		return
+	}
+
+	for i, condition := range status.Conditions {
+		required := p.debounceCount(condition.Type)
+		if required <= 1 {
+			continue
+		}
+
+		committed, ok := findCondition(p.lastStatus.Conditions, condition.Type)
+		if !ok || committed.Status == condition.Status {
+			delete(p.pendingTransitions, condition.Type)
+			continue
+		}
+
+		pending, ok := p.pendingTransitions[condition.Type]
+		if !ok || pending.status != condition.Status {
+			pending = &pendingTransition{status: condition.Status}
+			p.pendingTransitions[condition.Type] = pending
+		}
+		pending.count++
+
+		if pending.count >= required {
+			p.logf(4, "Committing debounced transition for %s/%s to %s after %d checks",
+				p.name, condition.Type, condition.Status, pending.count)
+			delete(p.pendingTransitions, condition.Type)
+			continue
+		}
+
+		p.logf(4, "Debouncing transition for %s/%s to %s (%d/%d)",
+			p.name, condition.Type, condition.Status, pending.count, required)
+		status.Conditions[i] = committed
+	}
+}
+
+// debounceCount returns the configured debounce count for a condition type.
+func (p *ExternalMonitorProxy) debounceCount(conditionType string) int {
+	for _, condDef := range p.declaredConditions() {
+		if condDef.Type == conditionType {
+			return condDef.DebounceCount
+		}
+	}
+	return 0
+}
+
+// findCondition returns the condition with the given type, if present.
+func findCondition(conditions []npdt.Condition, conditionType string) (npdt.Condition, bool) {
+	for _, condition := range conditions {
+		if condition.Type == conditionType {
+			return condition, true
+		}
+	}
+	return npdt.Condition{}, false
+}
+
+// conditionsEqual checks if two condition slices are equal. Conditions are
+// matched by type, so reordered slices compare equal.
 func (p *ExternalMonitorProxy) conditionsEqual(a, b []npdt.Condition) bool {
 	if len(a) != len(b) {
 		return false
 	}
 
-	for i := range a {
-		if a[i].Type != b[i].Type ||
-			a[i].Status != b[i].Status ||
-			a[i].Reason != b[i].Reason ||
-			a[i].Message != b[i].Message {
+	byType := make(map[string]npdt.Condition, len(a))
+	for _, condition := range a {
+		byType[condition.Type] = condition
+	}
+
+	seen := make(map[string]bool, len(b))
+	for _, condition := range b {
+		other, ok := byType[condition.Type]
+		if !ok ||
+			other.Status != condition.Status ||
+			other.Reason != condition.Reason ||
+			other.Message != condition.Message {
 			return false
 		}
+		seen[condition.Type] = true
 	}
 
-	return true
+	// Both slices must cover the same types even if one repeats a type
+	return len(seen) == len(byType)
 }
 
-// sendInitialStatus sends initial conditions from configuration.
+// sendInitialStatus sends the persisted status if one is restored, and
+// initial conditions from configuration otherwise.
 func (p *ExternalMonitorProxy) sendInitialStatus() {
-	if len(p.config.Conditions) == 0 {
+	status := p.restoreStatus()
+	if status == nil {
+		status = p.configuredInitialStatus()
+	}
+	if status == nil {
 		return
 	}
 
+	// Derived conditions are reported from the start too
+	p.recordReportedConditions(status.Conditions)
+	sent := p.appendDerivedConditions(status)
+
+	if p.deliverStatus(sent) {
+		p.logf(4, "Sent initial status from %s", p.name)
+		p.recordConditionsSent(status.Conditions)
+	}
+
+	p.lastStatus = status
+}
+
+// configuredInitialStatus builds the initial conditions from configuration.
+func (p *ExternalMonitorProxy) configuredInitialStatus() *npdt.Status {
+	if len(p.config.Conditions) == 0 {
+		return nil
+	}
+
 	status := &npdt.Status{
 		Source: p.config.Source,
 	}
 
 	// Create conditions from configuration
 	now := time.Now()
-	for _, condDef := range p.config.Conditions {
+	for _, condDef := range p.declaredConditions() {
 		condition := npdt.Condition{
 			Type:       condDef.Type,
 			Status:     npdt.False, // Assume healthy initially
@@ -403,30 +1528,130 @@ func (p *ExternalMonitorProxy) sendInitialStatus() {
 		status.Conditions = append(status.Conditions, condition)
 	}
 
+	return status
+}
+
+// logf logs a verbose message for this monitor. The per-monitor LogLevel, when
+// configured, takes precedence over the global klog verbosity.
+func (p *ExternalMonitorProxy) logf(level klog.Level, format string, args ...interface{}) {
+	if p.config.LogLevel != nil {
+		if klog.Level(*p.config.LogLevel) < level {
+			return
+		}
+	} else if !klog.V(level).Enabled() {
+		return
+	}
+
+	klog.InfoDepth(1, fmt.Sprintf("[%s] ", p.name)+fmt.Sprintf(format, args...))
+}
+
+// sendStatus sends a plugin status through deliverStatus. Conditions are
+// rolled up across monitors when aggregation is configured.
+func (p *ExternalMonitorProxy) sendStatus(status *npdt.Status) bool {
+	status = p.aggregateConditions(status)
+	status = p.appendDerivedConditions(status)
+	status = p.withOccurrenceEvents(status)
+	return p.deliverStatus(status)
+}
+
+// deliverStatus sends a status from the monitor loops. When the status channel
+// is full it waits up to MaxSendBlock for the consumer to catch up before
+// dropping the status.
+func (p *ExternalMonitorProxy) deliverStatus(status *npdt.Status) bool {
 	select {
 	case p.statusChan <- status:
-		klog.V(4).Infof("Sent initial status from %s", p.name)
-	case <-p.tomb.Stopping():
-		return
+		p.recordOccurrences(status.Conditions)
+		return true
 	default:
-		klog.Warningf("Status channel full for %s, dropping initial status", p.name)
 	}
 
-	p.lastStatus = status
+	if maxBlock := p.config.PluginConfig.MaxSendBlock; maxBlock > 0 {
+		timer := time.NewTimer(maxBlock)
+		defer timer.Stop()
+
+		select {
+		case p.statusChan <- status:
+			p.recordOccurrences(status.Conditions)
+			return true
+		case <-p.tomb.Stopping():
+			return false
+		case <-timer.C:
+		}
+	}
+
+	dropped := p.droppedStatuses.Add(1)
+	klog.Warningf("Status channel full for %s, dropping status (%d dropped so far)", p.name, dropped)
+	return false
+}
+
+// sendEvent sends a status carrying a single proxy-generated event. Events
+// raised while stopping are only logged.
+func (p *ExternalMonitorProxy) sendEvent(severity npdt.Severity, reason, message string) {
+	if p.shuttingDown.Load() {
+		p.logf(4, "Not sending %s event from %s while shutting down: %s", reason, p.name, message)
+		return
+	}
+
+	status := &npdt.Status{
+		Source: p.config.Source,
+		Events: []npdt.Event{
+			{
+				Severity:  severity,
+				Timestamp: time.Now(),
+				Reason:    reason,
+				Message:   message,
+			},
+		},
+	}
+
+	if p.offerStatus(status) {
+		p.logf(4, "Sent %s event from %s", reason, p.name)
+	} else if !p.shuttingDown.Load() {
+		klog.Warningf("Status channel full for %s, dropping %s event", p.name, reason)
+	}
+}
+
+// offerStatus sends a status without blocking, returning false if the channel
+// is full or the proxy is shutting down. Unlike a plain send it is safe from
+// goroutines Stop does not wait for, such as a forced reconnection from the
+// debug handler, because Stop closes statusChan under statusChanMutex.
+func (p *ExternalMonitorProxy) offerStatus(status *npdt.Status) bool {
+	p.statusChanMutex.RLock()
+	defer p.statusChanMutex.RUnlock()
+
+	if p.shuttingDown.Load() {
+		return false
+	}
+	select {
+	case p.statusChan <- status:
+		return true
+	default:
+		return false
+	}
 }
 
 // handleError handles gRPC errors and implements error counting.
 func (p *ExternalMonitorProxy) handleError(err error, operation string) {
-	p.errorCount++
-
 	st := status.Convert(err)
 
+	if p.isBenignError(operation, st.Code()) {
+		p.logf(4, "Benign error in %s.%s: %v", p.name, operation, err)
+		return
+	}
+
+	errorCount := p.errorCount.Add(1)
+
 	switch st.Code() {
 	case codes.Unavailable, codes.DeadlineExceeded:
-		klog.V(4).Infof("Transient error in %s.%s: %v", p.name, operation, err)
+		p.logf(4, "Transient error in %s.%s: %v", p.name, operation, err)
 
-		// Mark as disconnected for reconnection
+		// Fail over to the warm standby, or mark as disconnected for reconnection
 		p.connectionMutex.Lock()
+		if p.config.PluginConfig.WarmStandby && p.promoteStandbyUnsafe() {
+			p.connectionMutex.Unlock()
+			p.requestConnectedCheck()
+			return
+		}
 		p.connected = false
 		p.connectionMutex.Unlock()
 
@@ -434,97 +1659,264 @@ func (p *ExternalMonitorProxy) handleError(err error, operation string) {
 		klog.Infof("Operation %s not implemented by %s", operation, p.name)
 
 	default:
-		klog.Warningf("Error in %s.%s: %v", p.name, operation, err)
+		klog.Warningf("Error in %s.%s: %s: %s", p.name, operation, st.Code(), errorMessage(err))
+		if operation == "CheckHealth" {
+			p.setCheckFailed(err)
+		}
 	}
 
-	// If too many consecutive errors, trigger reconnection
-	if p.errorCount >= p.config.PluginConfig.HealthCheck.ErrorThreshold {
+	// If too many consecutive errors, have the health check loop reconnect
+	// rather than waiting out the backoff on the calling loop
+	if errorCount >= int64(p.config.PluginConfig.HealthCheck.ErrorThreshold) {
 		klog.Warningf("Too many errors for %s (%d), triggering reconnection",
-			p.name, p.errorCount)
-		p.attemptReconnection()
+			p.name, errorCount)
+		p.setReady(false)
+		p.requestReconnect()
+	}
+}
+
+// isBenignError reports whether a status code is configured as expected for an operation.
+func (p *ExternalMonitorProxy) isBenignError(operation string, code codes.Code) bool {
+	for _, benign := range p.config.PluginConfig.BenignErrorCodes[operation] {
+		if benign == code {
+			return true
+		}
 	}
+	return false
 }
 
 // attemptReconnection attempts to reconnect with exponential backoff.
 func (p *ExternalMonitorProxy) attemptReconnection() {
 	p.connectionMutex.Lock()
-	defer p.connectionMutex.Unlock()
 
-	// Don't attempt too frequently
-	if time.Since(p.lastConnectAttempt) < time.Second {
+	// Don't reconnect a stopping monitor, attempt too frequently, or while
+	// another attempt is backing off
+	if p.shuttingDown.Load() || p.reconnecting || time.Since(p.lastConnectAttempt) < time.Second {
+		p.connectionMutex.Unlock()
 		return
 	}
 
 	p.lastConnectAttempt = time.Now()
 
 	// Check if we've exceeded max attempts
-	if p.backoffAttempt >= p.config.PluginConfig.RetryPolicy.MaxAttempts {
+	if attempts := p.backoffAttempt; attempts >= p.config.PluginConfig.RetryPolicy.MaxAttempts {
 		klog.Errorf("Giving up reconnection for %s after %d attempts",
-			p.name, p.backoffAttempt)
+			p.name, attempts)
+		report := !p.gaveUpReported
+		p.gaveUpReported = true
+		p.connectionMutex.Unlock()
+
+		if report {
+			p.emitInternalEvent(npdt.Warn, internalEventGaveUp,
+				fmt.Sprintf("Gave up reconnecting to external monitor %s after %d attempts", p.name, attempts))
+		}
 		return
 	}
 
-	// Calculate backoff delay
-	backoff := time.Duration(float64(p.config.PluginConfig.RetryPolicy.InitialBackoff) *
-		math.Pow(p.config.PluginConfig.RetryPolicy.BackoffMultiplier, float64(p.backoffAttempt)))
+	p.reportConnectionLostUnsafe()
 
-	if backoff > p.config.PluginConfig.RetryPolicy.MaxBackoff {
-		backoff = p.config.PluginConfig.RetryPolicy.MaxBackoff
-	}
+	// Calculate backoff delay
+	backoff := p.backoff.Next(p.backoffAttempt)
 
 	p.backoffAttempt++
+	p.reconnecting = true
 
 	klog.Infof("Attempting reconnection for %s (attempt %d) in %v",
 		p.name, p.backoffAttempt, backoff)
 
-	// Wait for backoff period
-	time.Sleep(backoff)
+	p.connectionMutex.Unlock()
+
+	// Wait for backoff period without holding the lock, so status checks
+	// and isConnected stay responsive
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-p.tomb.Stopping():
+		p.connectionMutex.Lock()
+		p.reconnecting = false
+		p.connectionMutex.Unlock()
+		return
+	}
+
+	// Wait for the reconnection limiter, which may be shared across monitors
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-p.tomb.Stopping():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	limitErr := p.reconnectLimiter.Wait(ctx)
+
+	p.connectMutex.Lock()
+	defer p.connectMutex.Unlock()
+	p.connectionMutex.Lock()
+	defer p.connectionMutex.Unlock()
+
+	// reconnecting stays set until the attempt is over, so that no other
+	// attempt starts while connectSocket runs without the lock
+	defer func() { p.reconnecting = false }()
+
+	if limitErr != nil {
+		p.logf(4, "Skipping reconnection for %s: %v", p.name, limitErr)
+		return
+	}
+
+	// The connection may have recovered, or been forced, while we waited
+	if p.connectedUnsafe() && p.connected {
+		p.logf(4, "Connection to %s recovered during backoff", p.name)
+		p.reportReconnectedUnsafe(fmt.Sprintf("Connection to external monitor %s recovered", p.name))
+		return
+	}
 
-	// Check if socket exists
-	if _, err := os.Stat(p.config.PluginConfig.SocketAddress); err != nil {
-		klog.V(4).Infof("Socket %s not available for %s: %v",
-			p.config.PluginConfig.SocketAddress, p.name, err)
+	// Pick the first socket that exists
+	socket := p.selectSocket()
+	if socket == "" {
+		p.logf(4, "No socket available for %s", p.name)
 		return
 	}
 
 	// Attempt connection
-	if err := p.connectUnsafe(); err != nil {
+	p.connectionMutex.Unlock()
+	err := p.connectSocket(socket)
+	p.connectionMutex.Lock()
+	if err != nil {
 		klog.Warningf("Reconnection failed for %s: %v", p.name, err)
 		return
 	}
 
 	klog.Infof("Successfully reconnected to %s", p.name)
+	p.reportReconnectedUnsafe(fmt.Sprintf("Reconnected to external monitor %s (socket: %s)", p.name, socket))
 }
 
-// connectUnsafe is the internal connection method without locking.
-func (p *ExternalMonitorProxy) connectUnsafe() error {
-	if p.conn != nil {
-		p.conn.Close()
+// ForceReconnect resets the backoff state and reconnects to the plugin
+// immediately, bypassing the backoff delay. It is safe to call concurrently
+// with the monitoring loops. A reconnection attempt already dialing finishes
+// first and its connection is kept, while an attempt still backing off finds
+// the forced connection and doesn't dial.
+func (p *ExternalMonitorProxy) ForceReconnect() error {
+	connections := p.connections.Load()
+	p.connectMutex.Lock()
+	defer p.connectMutex.Unlock()
+	if p.connections.Load() != connections {
+		klog.Infof("Reconnected to %s while waiting to force reconnection", p.name)
+		return nil
 	}
 
-	conn, err := grpc.Dial(
-		"unix://"+p.config.PluginConfig.SocketAddress,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-		grpc.WithKeepaliveParams(keepalive.ClientParameters{
-			Time:                30 * time.Second,
-			Timeout:             10 * time.Second,
-			PermitWithoutStream: true,
-		}),
-	)
+	p.connectionMutex.Lock()
+	klog.Infof("Forcing reconnection for %s", p.name)
+
+	p.backoffAttempt = 0
+	p.backoff.Reset()
+	p.lastConnectAttempt = time.Now()
+	p.metadataFetchedAt = time.Time{} // Always fetch fresh metadata
+	p.connectionMutex.Unlock()
+
+	socket := p.selectSocket()
+	if socket == "" {
+		return fmt.Errorf("no socket available for %s", p.name)
+	}
+
+	if err := p.connectSocket(socket); err != nil {
+		klog.Warningf("Forced reconnection failed for %s: %v", p.name, err)
+		return err
+	}
+
+	klog.Infof("Successfully reconnected to %s", p.name)
+	p.connectionMutex.Lock()
+	p.reportReconnectedUnsafe(fmt.Sprintf("Reconnected to external monitor %s (socket: %s)", p.name, socket))
+	p.connectionMutex.Unlock()
+	return nil
+}
+
+// connectSocket connects to a socket and swaps the new connection in. The
+// dial, which may wait up to DialTimeout, runs without connectionMutex so that
+// isConnected and status reads stay responsive while reconnecting. Must be
+// called with connectMutex held and without connectionMutex held.
+func (p *ExternalMonitorProxy) connectSocket(socket string) error {
+	if p.shuttingDown.Load() {
+		return fmt.Errorf("external monitor %s is shutting down", p.name)
+	}
+
+	conn, err := p.dial(socket)
 	if err != nil {
 		return err
 	}
 
+	p.connectionMutex.Lock()
+
+	// Stop may have closed the previous connection while we dialed
+	if p.shuttingDown.Load() {
+		p.connectionMutex.Unlock()
+		conn.Close()
+		return fmt.Errorf("external monitor %s is shutting down", p.name)
+	}
+
+	// An idle connection was already closed, and won't be dialed again now
+	if p.conn != nil {
+		p.conn.Close()
+	}
 	p.conn = conn
 	p.client = pb.NewExternalMonitorClient(conn)
 	p.connected = true
+	p.idle = false
 	p.backoffAttempt = 0
-	p.errorCount = 0
+	p.gaveUpReported = false
+	p.healthService.Store(healthServiceUnknown)
+	p.healthProbeFailures.Store(0)
+	p.backoff.Reset()
+	p.errorCount.Store(0)
+	p.setActiveSocket(socket)
+	p.connectionMutex.Unlock()
+
+	// The new connection answers reconnections requested for the old one
+	select {
+	case <-p.reconnectChan:
+	default:
+	}
 
 	// Fetch metadata
-	if err := p.fetchMetadata(); err != nil {
-		klog.Warningf("Failed to fetch metadata from %s after reconnection: %v", p.name, err)
+	if err := p.fetchConnectionMetadata(); err != nil {
+		return err
 	}
 
+	p.runSelfTest()
+	p.requestConnectedCheck()
+	p.connections.Add(1)
+
 	return nil
+}
+
+// selectSocket returns the first configured socket that exists on disk,
+// or an empty string if none are available.
+func (p *ExternalMonitorProxy) selectSocket() string {
+	for _, socket := range p.config.PluginConfig.Sockets() {
+		if err := socketAvailable(socket); err != nil {
+			p.logf(4, "Socket %s not available for %s: %v", socket, p.name, err)
+			continue
+		}
+		return socket
+	}
+	return ""
+}
+
+// setActiveSocket records the socket in use and its inode, and emits an event
+// on failover. It is also reached from ForceReconnect, so the event goes
+// through offerStatus. Must be called with connectionMutex held.
+func (p *ExternalMonitorProxy) setActiveSocket(socket string) {
+	previous := p.activeSocket
+	p.activeSocket = socket
+	p.activeSocketInode = unixSocketInode(socket)
+
+	if previous == "" || previous == socket {
+		return
+	}
+
+	klog.Warningf("External monitor %s failed over from %s to %s", p.name, previous, socket)
+	p.sendEvent(npdt.Warn, "PluginFailover",
+		fmt.Sprintf("External monitor %s failed over from socket %s to %s", p.name, previous, socket))
 }
\ No newline at end of file
@@ -0,0 +1,505 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	npdt "k8s.io/node-problem-detector/pkg/types"
+
+	pb "k8s.io/npd-ext/api/services/external/v1"
+	"k8s.io/npd-ext/pkg/externalmonitor/types"
+)
+
+func TestDebounceConditions(t *testing.T) {
+	testCases := []struct {
+		name     string
+		count    int
+		sequence []npdt.ConditionStatus
+		// want is the status forwarded after each check
+		want []npdt.ConditionStatus
+	}{
+		{
+			name:     "disabled",
+			count:    1,
+			sequence: []npdt.ConditionStatus{npdt.False, npdt.True, npdt.False},
+			want:     []npdt.ConditionStatus{npdt.False, npdt.True, npdt.False},
+		},
+		{
+			name:     "committed after the count",
+			count:    3,
+			sequence: []npdt.ConditionStatus{npdt.False, npdt.True, npdt.True, npdt.True, npdt.True},
+			want:     []npdt.ConditionStatus{npdt.False, npdt.False, npdt.False, npdt.True, npdt.True},
+		},
+		{
+			name:     "flapping never committed",
+			count:    2,
+			sequence: []npdt.ConditionStatus{npdt.False, npdt.True, npdt.False, npdt.True, npdt.False},
+			want:     []npdt.ConditionStatus{npdt.False, npdt.False, npdt.False, npdt.False, npdt.False},
+		},
+		{
+			name:     "pending change restarted by another status",
+			count:    2,
+			sequence: []npdt.ConditionStatus{npdt.False, npdt.True, npdt.Unknown, npdt.Unknown},
+			want:     []npdt.ConditionStatus{npdt.False, npdt.False, npdt.False, npdt.Unknown},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := newTestProxy(t, testConfig(t, "/unused.sock", func(config *types.ExternalMonitorConfig) {
+				config.Conditions[0].DebounceCount = tc.count
+			}))
+
+			for i, conditionStatus := range tc.sequence {
+				status := &npdt.Status{Conditions: []npdt.Condition{{Type: "Fake", Status: conditionStatus}}}
+				p.debounceConditions(status)
+				if got := status.Conditions[0].Status; got != tc.want[i] {
+					t.Errorf("check %d reporting %s forwarded %s, want %s", i, conditionStatus, got, tc.want[i])
+				}
+				p.lastStatus = status
+			}
+		})
+	}
+}
+
+func TestPauseHoldsStatuses(t *testing.T) {
+	plugin := &fakePlugin{checkHealth: func(context.Context, *pb.HealthCheckRequest) (*pb.Status, error) {
+		status := healthyStatus("fake")
+		status.Events = []*pb.Event{{Severity: pb.Severity_SEVERITY_WARN, Reason: "FakeHiccup", Message: "hiccup"}}
+		return status, nil
+	}}
+	server := startFakePlugin(t, plugin, nil)
+	p := connectedTestProxy(t, server.socket, nil)
+
+	p.Pause()
+	p.checkHealth(nil)
+	p.checkHealth(nil)
+	if n := plugin.checks.Load(); n != 2 {
+		t.Fatalf("got %d checks while paused, want 2", n)
+	}
+	select {
+	case s := <-p.statusChan:
+		t.Fatalf("status sent while paused: %+v", s)
+	default:
+	}
+	if p.heldEvents != 2 {
+		t.Errorf("counted %d events held while paused, want 2", p.heldEvents)
+	}
+
+	p.Resume()
+	select {
+	case <-p.resumeChan:
+	case <-time.After(time.Second):
+		t.Fatal("resume not signaled")
+	}
+	p.flushHeldStatus()
+
+	select {
+	case s := <-p.statusChan:
+		if len(s.Events) != 0 {
+			t.Errorf("flushed events raised while paused: %+v", s.Events)
+		}
+		if len(s.Conditions) != 1 || s.Conditions[0].Reason != "FakeHealthy" {
+			t.Errorf("flushed conditions %+v, want Fake from the latest check", s.Conditions)
+		}
+	default:
+		t.Fatal("no status flushed on resume")
+	}
+	if p.heldStatus != nil || p.heldEvents != 0 {
+		t.Errorf("held status %+v and %d events kept after flushing", p.heldStatus, p.heldEvents)
+	}
+}
+
+// slowNodeConditions holds a check building its request for a while, after
+// signalling on entered.
+type slowNodeConditions struct {
+	entered chan struct{}
+}
+
+func (s slowNodeConditions) NodeConditions() ([]npdt.Condition, error) {
+	s.entered <- struct{}{}
+	time.Sleep(100 * time.Millisecond)
+	return nil, nil
+}
+
+func TestForceReconnectDuringCheck(t *testing.T) {
+	plugin := &fakePlugin{}
+	server := startFakePlugin(t, plugin, nil)
+	p := connectedTestProxy(t, server.socket, func(config *types.ExternalMonitorConfig) {
+		config.PluginConfig.NodeConditions = []string{"Ready"}
+	})
+	provider := slowNodeConditions{entered: make(chan struct{})}
+	SetNodeConditionProvider(provider)
+	t.Cleanup(func() { SetNodeConditionProvider(nil) })
+
+	// Reconnect after the check passed its connection checks, before it
+	// calls the plugin
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		p.checkHealth(nil)
+	}()
+	<-provider.entered
+	if err := p.ForceReconnect(); err != nil {
+		t.Fatalf("ForceReconnect: %v", err)
+	}
+	<-done
+
+	// The check calls the plugin on the new connection, not the closed one
+	if n := plugin.checks.Load(); n != 1 {
+		t.Errorf("plugin checked %d times, want 1", n)
+	}
+	if !p.IsReady() {
+		t.Error("monitor not ready after the check")
+	}
+}
+
+func TestErrorThresholdReconnectsWithoutBlockingChecks(t *testing.T) {
+	plugin := &fakePlugin{checkHealth: func(context.Context, *pb.HealthCheckRequest) (*pb.Status, error) {
+		return nil, status.Error(codes.Internal, "backend broken")
+	}}
+	server := startFakePlugin(t, plugin, nil)
+	p := newTestProxy(t, testConfig(t, server.socket, func(config *types.ExternalMonitorConfig) {
+		config.PluginConfig.HealthCheck.ErrorThreshold = 1
+		config.PluginConfig.RetryPolicy.InitialBackoff = time.Hour
+		config.PluginConfig.RetryPolicy.MaxBackoff = time.Hour
+	}))
+	statuses, err := p.Start()
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	go func() {
+		for range statuses {
+		}
+	}()
+	defer p.Stop()
+
+	// The first failed check asks the health check loop to reconnect, which
+	// then waits out the backoff
+	waitFor(t, 5*time.Second, "a reconnection backing off", func() bool {
+		p.connectionMutex.RLock()
+		defer p.connectionMutex.RUnlock()
+		return p.reconnecting
+	})
+
+	start := time.Now()
+	p.isConnected()
+	p.IsReady()
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("connection state took %v to read during backoff", elapsed)
+	}
+
+	// monitorLoop keeps checking meanwhile
+	checks := plugin.checks.Load()
+	p.requestConnectedCheck()
+	waitFor(t, 5*time.Second, "a check during backoff", func() bool {
+		return plugin.checks.Load() > checks
+	})
+}
+
+func TestForceReconnectDuringReconnection(t *testing.T) {
+	plugin := &fakePlugin{}
+	server := startFakePlugin(t, plugin, nil)
+	p := connectedTestProxy(t, server.socket, func(config *types.ExternalMonitorConfig) {
+		config.PluginConfig.RetryPolicy.InitialBackoff = time.Millisecond
+	})
+
+	// Hold the reconnection attempt in its metadata fetch
+	entered := make(chan struct{}, 2)
+	release := make(chan struct{})
+	plugin.setGetMetadata(func(context.Context) (*pb.MonitorMetadata, error) {
+		entered <- struct{}{}
+		<-release
+		return &pb.MonitorMetadata{Name: "fake", Version: "1.0.0"}, nil
+	})
+	p.connectionMutex.Lock()
+	p.connected = false
+	p.connectionMutex.Unlock()
+
+	reconnected := make(chan struct{})
+	go func() {
+		defer close(reconnected)
+		p.attemptReconnection()
+	}()
+	<-entered
+
+	forced := make(chan error)
+	go func() { forced <- p.ForceReconnect() }()
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	<-reconnected
+	if err := <-forced; err != nil {
+		t.Fatalf("ForceReconnect: %v", err)
+	}
+
+	// The forced reconnection keeps the connection of the attempt
+	if n := server.accepted.Load(); n != 2 {
+		t.Errorf("plugin accepted %d connections, want 2", n)
+	}
+	if n := plugin.metadataCalls.Load(); n != 2 {
+		t.Errorf("GetMetadata called %d times, want 2", n)
+	}
+	if !p.isConnected() {
+		t.Error("not connected after reconnecting")
+	}
+}
+
+func TestSendWaitsForSlowConsumer(t *testing.T) {
+	send := map[string]func(p *ExternalMonitorProxy){
+		"plugin status": func(p *ExternalMonitorProxy) {
+			p.sendStatus(&npdt.Status{Source: "fake"})
+		},
+		"initial status": func(p *ExternalMonitorProxy) {
+			p.sendInitialStatus()
+		},
+		"held status": func(p *ExternalMonitorProxy) {
+			p.heldStatus = &npdt.Status{Source: "fake"}
+			p.flushHeldStatus()
+		},
+	}
+	testCases := []struct {
+		name         string
+		maxSendBlock time.Duration
+		wantSent     bool
+	}{
+		{
+			name:         "waits for room",
+			maxSendBlock: 5 * time.Second,
+			wantSent:     true,
+		},
+		{
+			name: "drops without maxSendBlock",
+		},
+	}
+
+	for _, tc := range testCases {
+		for path, sendStatus := range send {
+			t.Run(tc.name+"/"+path, func(t *testing.T) {
+				p := newTestProxy(t, testConfig(t, "/run/fake.sock", func(config *types.ExternalMonitorConfig) {
+					config.PluginConfig.MaxSendBlock = tc.maxSendBlock
+				}))
+				for len(p.statusChan) < cap(p.statusChan) {
+					p.statusChan <- &npdt.Status{Source: "filler"}
+				}
+
+				// The consumer catches up after a while
+				consumed := make(chan struct{})
+				go func() {
+					defer close(consumed)
+					time.Sleep(50 * time.Millisecond)
+					<-p.statusChan
+				}()
+				sendStatus(p)
+				<-consumed
+
+				var last *npdt.Status
+				for len(p.statusChan) > 0 {
+					last = <-p.statusChan
+				}
+				if sent := last.Source == "fake"; sent != tc.wantSent {
+					t.Errorf("status sent = %v, want %v", sent, tc.wantSent)
+				}
+				if dropped := p.droppedStatuses.Load() > 0; dropped == tc.wantSent {
+					t.Errorf("status counted as dropped = %v, want %v", dropped, !tc.wantSent)
+				}
+			})
+		}
+	}
+}
+
+func TestFailoverToSecondarySocket(t *testing.T) {
+	primaryPlugin, secondaryPlugin := &fakePlugin{}, &fakePlugin{}
+	primary := startFakePlugin(t, primaryPlugin, nil)
+	secondary := startFakePlugin(t, secondaryPlugin, nil)
+	p := connectedTestProxy(t, "", func(config *types.ExternalMonitorConfig) {
+		config.PluginConfig.SocketAddresses = []string{primary.socket, secondary.socket}
+		config.PluginConfig.RetryPolicy.InitialBackoff = time.Millisecond
+	})
+
+	// The primary is preferred while it is up
+	p.checkHealth(nil)
+	if n := primaryPlugin.checks.Load(); n != 1 {
+		t.Fatalf("primary checked %d times, want 1", n)
+	}
+
+	// Stopping the primary removes its socket, the reconnection picks the
+	// secondary
+	primary.stop()
+	p.connectionMutex.Lock()
+	p.connected = false
+	p.connectionMutex.Unlock()
+	p.attemptReconnection()
+
+	p.connectionMutex.RLock()
+	active := p.activeSocket
+	p.connectionMutex.RUnlock()
+	if active != secondary.socket {
+		t.Fatalf("active socket %s, want the secondary %s", active, secondary.socket)
+	}
+	p.checkHealth(nil)
+	if n := secondaryPlugin.checks.Load(); n != 1 {
+		t.Errorf("secondary checked %d times, want 1", n)
+	}
+
+	var failover *npdt.Event
+	for len(p.statusChan) > 0 {
+		for _, event := range (<-p.statusChan).Events {
+			if event.Reason == "PluginFailover" {
+				failover = &event
+			}
+		}
+	}
+	if failover == nil {
+		t.Error("no PluginFailover event sent")
+	}
+}
+
+func TestDialTimeoutOnBlackHoleSocket(t *testing.T) {
+	// The listener never accepts, so connecting succeeds but the plugin
+	// never answers
+	dir, err := os.MkdirTemp("", "npd-ext")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	socket := filepath.Join(dir, "black-hole.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	dialTimeout := 200 * time.Millisecond
+	p := newTestProxy(t, testConfig(t, socket, func(config *types.ExternalMonitorConfig) {
+		config.PluginConfig.DialTimeout = dialTimeout
+	}))
+
+	start := time.Now()
+	dialed := make(chan error)
+	go func() {
+		p.connectMutex.Lock()
+		defer p.connectMutex.Unlock()
+		dialed <- p.connectSocket(socket)
+	}()
+
+	// The connection state stays readable while dialing
+	time.Sleep(dialTimeout / 4)
+	stateRead := make(chan struct{})
+	go func() {
+		p.isConnected()
+		close(stateRead)
+	}()
+	select {
+	case <-stateRead:
+	case <-time.After(dialTimeout / 2):
+		t.Error("isConnected blocked while dialing")
+	}
+
+	select {
+	case err := <-dialed:
+		if err == nil {
+			t.Fatal("connected to a plugin that never answers")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("dial not bounded by dialTimeout")
+	}
+	if elapsed := time.Since(start); elapsed < dialTimeout || elapsed > dialTimeout+time.Second {
+		t.Errorf("dial failed after %v, want about %v", elapsed, dialTimeout)
+	}
+	if p.isConnected() {
+		t.Error("connected after the dial timed out")
+	}
+}
+
+func TestMetadataCacheTTL(t *testing.T) {
+	testCases := []struct {
+		name string
+		ttl  time.Duration
+		// age is how long ago the metadata was fetched when reconnecting
+		age         time.Duration
+		otherSocket bool
+		wantCalls   int32
+	}{
+		{
+			name:      "disabled",
+			wantCalls: 2,
+		},
+		{
+			name:      "within the TTL",
+			ttl:       time.Hour,
+			age:       time.Minute,
+			wantCalls: 1,
+		},
+		{
+			name:      "expired",
+			ttl:       time.Hour,
+			age:       2 * time.Hour,
+			wantCalls: 2,
+		},
+		{
+			name:        "other socket",
+			ttl:         time.Hour,
+			otherSocket: true,
+			wantCalls:   2,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			plugin := &fakePlugin{}
+			server := startFakePlugin(t, plugin, nil)
+			other := startFakePlugin(t, plugin, nil)
+			p := connectedTestProxy(t, "", func(config *types.ExternalMonitorConfig) {
+				config.PluginConfig.SocketAddresses = []string{server.socket, other.socket}
+				config.PluginConfig.MetadataCacheTTL = tc.ttl
+			})
+
+			p.connectionMutex.Lock()
+			p.metadataFetchedAt = p.metadataFetchedAt.Add(-tc.age)
+			p.connectionMutex.Unlock()
+			socket := server.socket
+			if tc.otherSocket {
+				socket = other.socket
+			}
+			p.connectMutex.Lock()
+			err := p.connectSocket(socket)
+			p.connectMutex.Unlock()
+			if err != nil {
+				t.Fatalf("connectSocket: %v", err)
+			}
+
+			if n := plugin.metadataCalls.Load(); n != tc.wantCalls {
+				t.Errorf("GetMetadata called %d times, want %d", n, tc.wantCalls)
+			}
+			p.connectionMutex.RLock()
+			metadata := p.metadata
+			p.connectionMutex.RUnlock()
+			if metadata == nil || metadata.Name != "fake" {
+				t.Errorf("got metadata %v after reconnecting, want the plugin's", metadata)
+			}
+		})
+	}
+}
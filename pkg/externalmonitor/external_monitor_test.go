@@ -0,0 +1,262 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+
+	"k8s.io/npd-ext/pkg/externalmonitor/types"
+)
+
+// writeConfigs writes the named configuration files to a new directory and
+// returns its path.
+func writeConfigs(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoadConfigurationExtends(t *testing.T) {
+	dir := writeConfigs(t, map[string]string{
+		"base.json": `{
+			"plugin": "external",
+			"source": "base",
+			"pluginConfig": {
+				"socketAddress": "/run/base.sock",
+				"pluginParameters": {"threshold": "85", "mode": "quick"}
+			},
+			"conditions": [{"type": "Base", "reason": "BaseOK", "message": "ok"}]
+		}`,
+		"gpu.json": `{
+			"extends": "base.json",
+			"source": "gpu",
+			"pluginConfig": {"pluginParameters": {"mode": "full"}},
+			"conditions": [{"type": "GPUHealthy", "reason": "GPUIsHealthy", "message": "ok"}]
+		}`,
+	})
+
+	config, err := LoadConfiguration(filepath.Join(dir, "gpu.json"))
+	if err != nil {
+		t.Fatalf("LoadConfiguration: %v", err)
+	}
+
+	if config.Source != "gpu" || config.Plugin != "external" {
+		t.Errorf("got source %q and plugin %q, want gpu and external", config.Source, config.Plugin)
+	}
+	if config.PluginConfig.SocketAddress != "/run/base.sock" {
+		t.Errorf("got socket %q inherited from the base, want /run/base.sock", config.PluginConfig.SocketAddress)
+	}
+	wantParameters := map[string]string{"threshold": "85", "mode": "full"}
+	if !reflect.DeepEqual(config.PluginConfig.PluginParameters, wantParameters) {
+		t.Errorf("got parameters %v merged over the base, want %v", config.PluginConfig.PluginParameters, wantParameters)
+	}
+	if len(config.Conditions) != 1 || config.Conditions[0].Type != "GPUHealthy" {
+		t.Errorf("got conditions %+v, want the base conditions replaced by GPUHealthy", config.Conditions)
+	}
+}
+
+func TestLoadConfigurationErrors(t *testing.T) {
+	testCases := []struct {
+		name  string
+		files map[string]string
+		// check asserts the type and content of the error
+		check func(t *testing.T, err error)
+	}{
+		{
+			name:  "missing file",
+			files: map[string]string{},
+			check: func(t *testing.T, err error) {
+				var readErr *types.ConfigReadError
+				if !errors.As(err, &readErr) || !errors.Is(err, os.ErrNotExist) {
+					t.Errorf("got %v, want a ConfigReadError wrapping ErrNotExist", err)
+				}
+			},
+		},
+		{
+			name:  "invalid JSON",
+			files: map[string]string{"config.json": `{"source": `},
+			check: wantParseError,
+		},
+		{
+			name:  "unknown keys",
+			files: map[string]string{"config.json": `{"source": "gpu", "pluginConfig": {"sockAddress": "/x.sock"}}`},
+			check: func(t *testing.T, err error) {
+				var unknownErr *types.ConfigUnknownFieldError
+				if !errors.As(err, &unknownErr) {
+					t.Fatalf("got %v, want a ConfigUnknownFieldError", err)
+				}
+				if !reflect.DeepEqual(unknownErr.Fields, []string{"pluginConfig.sockAddress"}) {
+					t.Errorf("got unknown fields %v, want [pluginConfig.sockAddress]", unknownErr.Fields)
+				}
+			},
+		},
+		{
+			name: "unknown keys in the base",
+			files: map[string]string{
+				"base.json":   `{"sourse": "base"}`,
+				"config.json": `{"extends": "base.json", "source": "gpu"}`,
+			},
+			check: func(t *testing.T, err error) {
+				var unknownErr *types.ConfigUnknownFieldError
+				if !errors.As(err, &unknownErr) || filepath.Base(unknownErr.Path) != "base.json" {
+					t.Errorf("got %v, want a ConfigUnknownFieldError for base.json", err)
+				}
+			},
+		},
+		{
+			name:  "extends not a path",
+			files: map[string]string{"config.json": `{"extends": 3}`},
+			check: wantParseError,
+		},
+		{
+			name:  "missing base",
+			files: map[string]string{"config.json": `{"extends": "base.json"}`},
+			check: func(t *testing.T, err error) {
+				var readErr *types.ConfigReadError
+				if !errors.As(err, &readErr) || filepath.Base(readErr.Path) != "base.json" {
+					t.Errorf("got %v, want a ConfigReadError for base.json", err)
+				}
+			},
+		},
+		{
+			name: "extends cycle",
+			files: map[string]string{
+				"a.json":      `{"extends": "config.json"}`,
+				"config.json": `{"extends": "a.json"}`,
+			},
+			check: wantParseError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := writeConfigs(t, tc.files)
+			_, err := LoadConfiguration(filepath.Join(dir, "config.json"))
+			if err == nil {
+				t.Fatal("LoadConfiguration succeeded")
+			}
+			tc.check(t, err)
+		})
+	}
+}
+
+// wantParseError asserts that err is a ConfigParseError.
+func wantParseError(t *testing.T, err error) {
+	t.Helper()
+
+	var parseErr *types.ConfigParseError
+	if !errors.As(err, &parseErr) {
+		t.Errorf("got %v, want a ConfigParseError", err)
+	}
+}
+
+// shippedConfigs returns the external monitor configurations shipped under
+// deployment/ and examples/, keyed by where they were found. Configurations
+// in ConfigMaps are keyed by file and data key.
+func shippedConfigs(t *testing.T) map[string][]byte {
+	t.Helper()
+
+	configs := make(map[string][]byte)
+	for _, root := range []string{"../../deployment", "../../examples"} {
+		err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil || entry.IsDir() {
+				return err
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			switch filepath.Ext(path) {
+			case ".json":
+				configs[path] = data
+			case ".yaml":
+				for i, document := range strings.Split(string(data), "\n---") {
+					var configMap struct {
+						Kind string            `json:"kind"`
+						Data map[string]string `json:"data"`
+					}
+					if err := yaml.Unmarshal([]byte(document), &configMap); err != nil {
+						t.Fatalf("%s document %d: %v", path, i, err)
+					}
+					if configMap.Kind != "ConfigMap" {
+						continue
+					}
+					for key, value := range configMap.Data {
+						if strings.HasSuffix(key, ".json") {
+							configs[path+":"+key] = []byte(value)
+						}
+					}
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for name, data := range configs {
+		var config struct {
+			Plugin string `json:"plugin"`
+		}
+		if err := json.Unmarshal(data, &config); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if config.Plugin != "external" {
+			delete(configs, name)
+		}
+	}
+	return configs
+}
+
+func TestLoadShippedConfigurations(t *testing.T) {
+	configs := shippedConfigs(t)
+	if len(configs) == 0 {
+		t.Fatal("found no shipped external monitor configurations")
+	}
+
+	for name, data := range configs {
+		t.Run(name, func(t *testing.T) {
+			dir := writeConfigs(t, map[string]string{"config.json": string(data)})
+			config, err := LoadConfiguration(filepath.Join(dir, "config.json"))
+			if err != nil {
+				t.Fatalf("LoadConfiguration: %v", err)
+			}
+			if err := config.ApplyConfiguration(); err != nil {
+				t.Fatalf("ApplyConfiguration: %v", err)
+			}
+			if errs := config.ValidateAll(); len(errs) > 0 {
+				t.Errorf("ValidateAll: %v", errs)
+			}
+		})
+	}
+}
@@ -0,0 +1,280 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "k8s.io/npd-ext/api/services/external/v1"
+	"k8s.io/npd-ext/pkg/externalmonitor/types"
+)
+
+// fakePlugin is an in-process external monitor plugin. Unset hooks answer
+// with a healthy status and minimal metadata.
+type fakePlugin struct {
+	pb.UnimplementedExternalMonitorServer
+
+	mutex       sync.Mutex
+	checkHealth func(ctx context.Context, req *pb.HealthCheckRequest) (*pb.Status, error)
+	getMetadata func(ctx context.Context) (*pb.MonitorMetadata, error)
+	metadata    *pb.MonitorMetadata
+	requests    []*pb.HealthCheckRequest
+
+	checks        atomic.Int32
+	metadataCalls atomic.Int32
+}
+
+func (f *fakePlugin) CheckHealth(ctx context.Context, req *pb.HealthCheckRequest) (*pb.Status, error) {
+	f.checks.Add(1)
+	f.mutex.Lock()
+	f.requests = append(f.requests, req)
+	check := f.checkHealth
+	f.mutex.Unlock()
+
+	if check != nil {
+		return check(ctx, req)
+	}
+	return healthyStatus("fake"), nil
+}
+
+func (f *fakePlugin) GetMetadata(ctx context.Context, _ *emptypb.Empty) (*pb.MonitorMetadata, error) {
+	f.metadataCalls.Add(1)
+	f.mutex.Lock()
+	get := f.getMetadata
+	f.mutex.Unlock()
+
+	if get != nil {
+		return get(ctx)
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.metadata != nil {
+		return f.metadata, nil
+	}
+	return &pb.MonitorMetadata{Name: "fake", Version: "1.0.0"}, nil
+}
+
+// setCheckHealth replaces the CheckHealth hook.
+func (f *fakePlugin) setCheckHealth(check func(ctx context.Context, req *pb.HealthCheckRequest) (*pb.Status, error)) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.checkHealth = check
+}
+
+// setGetMetadata replaces the GetMetadata hook.
+func (f *fakePlugin) setGetMetadata(get func(ctx context.Context) (*pb.MonitorMetadata, error)) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.getMetadata = get
+}
+
+// lastRequest returns the last CheckHealth request, nil if none was made.
+func (f *fakePlugin) lastRequest() *pb.HealthCheckRequest {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if len(f.requests) == 0 {
+		return nil
+	}
+	return f.requests[len(f.requests)-1]
+}
+
+// fakeHealth is a grpc.health.v1 service answering with check.
+type fakeHealth struct {
+	healthpb.UnimplementedHealthServer
+
+	mutex sync.Mutex
+	check func(ctx context.Context) (*healthpb.HealthCheckResponse, error)
+}
+
+func (h *fakeHealth) Check(ctx context.Context, _ *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	h.mutex.Lock()
+	check := h.check
+	h.mutex.Unlock()
+	return check(ctx)
+}
+
+// set replaces the answer of the health service.
+func (h *fakeHealth) set(check func(ctx context.Context) (*healthpb.HealthCheckResponse, error)) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.check = check
+}
+
+// healthStatus returns a health service answer with the given status.
+func healthStatus(status healthpb.HealthCheckResponse_ServingStatus) func(context.Context) (*healthpb.HealthCheckResponse, error) {
+	return func(context.Context) (*healthpb.HealthCheckResponse, error) {
+		return &healthpb.HealthCheckResponse{Status: status}, nil
+	}
+}
+
+// healthyStatus returns a status with the condition "Fake" False.
+func healthyStatus(source string) *pb.Status {
+	return &pb.Status{
+		Source: source,
+		Conditions: []*pb.Condition{{
+			Type:       "Fake",
+			Status:     pb.ConditionStatus_CONDITION_STATUS_FALSE,
+			Transition: timestamppb.Now(),
+			Reason:     "FakeHealthy",
+			Message:    "ok",
+		}},
+	}
+}
+
+// fakeServer serves a fake plugin on a Unix socket until the test ends.
+type fakeServer struct {
+	socket string
+	server *grpc.Server
+
+	// Connections accepted, across restarts
+	accepted atomic.Int32
+}
+
+// countingListener counts accepted connections.
+type countingListener struct {
+	net.Listener
+	accepted *atomic.Int32
+}
+
+func (l countingListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err == nil {
+		l.accepted.Add(1)
+	}
+	return conn, err
+}
+
+// startFakePlugin serves plugin, and health if not nil, on a new Unix socket.
+// The socket lives in a short temporary directory since t.TempDir paths can
+// exceed the Unix socket path limit.
+func startFakePlugin(t *testing.T, plugin *fakePlugin, health *fakeHealth) *fakeServer {
+	t.Helper()
+
+	dir, err := os.MkdirTemp("", "npd-ext")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	s := &fakeServer{socket: filepath.Join(dir, "plugin.sock")}
+	s.serve(t, plugin, health)
+	return s
+}
+
+// serve starts a server for plugin on the socket, replacing a stopped one.
+func (s *fakeServer) serve(t *testing.T, plugin *fakePlugin, health *fakeHealth) {
+	t.Helper()
+
+	os.Remove(s.socket)
+	listener, err := net.Listen("unix", s.socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.server = grpc.NewServer()
+	pb.RegisterExternalMonitorServer(s.server, plugin)
+	if health != nil {
+		healthpb.RegisterHealthServer(s.server, health)
+	}
+	go s.server.Serve(countingListener{Listener: listener, accepted: &s.accepted})
+	t.Cleanup(s.server.Stop)
+}
+
+// stop stops the server, closing connections to it.
+func (s *fakeServer) stop() {
+	s.server.Stop()
+}
+
+// testConfig returns a valid configuration for a monitor of the fake plugin,
+// with defaults applied after configure.
+func testConfig(t *testing.T, socket string, configure func(*types.ExternalMonitorConfig)) *types.ExternalMonitorConfig {
+	t.Helper()
+
+	config := &types.ExternalMonitorConfig{
+		Plugin: "external",
+		Source: "fake",
+		PluginConfig: types.ExternalPluginConfig{
+			SocketAddress:  socket,
+			InvokeInterval: time.Minute,
+			Timeout:        time.Second,
+			DialTimeout:    time.Second,
+			HealthCheck:    types.HealthCheckConfig{Interval: 10 * time.Second, Timeout: time.Second},
+		},
+		Conditions: []types.ConditionDefinition{{Type: "Fake", Reason: "FakeHealthy", Message: "ok"}},
+	}
+	if configure != nil {
+		configure(config)
+	}
+	if err := config.ApplyConfiguration(); err != nil {
+		t.Fatalf("ApplyConfiguration: %v", err)
+	}
+	return config
+}
+
+// newTestProxy creates a proxy for config without starting its loops.
+func newTestProxy(t *testing.T, config *types.ExternalMonitorConfig) *ExternalMonitorProxy {
+	t.Helper()
+
+	p, err := NewExternalMonitorProxy(config)
+	if err != nil {
+		t.Fatalf("NewExternalMonitorProxy: %v", err)
+	}
+	t.Cleanup(func() {
+		p.connectionMutex.Lock()
+		if p.conn != nil {
+			p.conn.Close()
+		}
+		p.connectionMutex.Unlock()
+	})
+	return p
+}
+
+// connectedTestProxy creates a proxy and connects it to the fake plugin.
+func connectedTestProxy(t *testing.T, socket string, configure func(*types.ExternalMonitorConfig)) *ExternalMonitorProxy {
+	t.Helper()
+
+	p := newTestProxy(t, testConfig(t, socket, configure))
+	if err := p.connect(); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	return p
+}
+
+// waitFor polls cond until it holds or the timeout expires.
+func waitFor(t *testing.T, timeout time.Duration, what string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out after %v waiting for %s", timeout, what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
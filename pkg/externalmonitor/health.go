@@ -0,0 +1,78 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"k8s.io/klog/v2"
+)
+
+// Monitor health states reported by OverallHealth.
+const (
+	monitorHealthy      = "healthy"
+	monitorReconnecting = "reconnecting"
+	monitorNotReady     = "not ready"
+	monitorGaveUp       = "gave up reconnecting"
+)
+
+// OverallHealth aggregates the state of every registered external monitor for
+// liveness probes. It is unhealthy when a liveness-critical monitor has given
+// up reconnecting. The details map each source to its state.
+func OverallHealth() (bool, map[string]string) {
+	healthy := true
+	details := make(map[string]string)
+	for _, p := range registry.list() {
+		state := p.healthState()
+		details[p.name] = state
+		if state == monitorGaveUp && p.config.IsLivenessCritical() {
+			healthy = false
+		}
+	}
+	return healthy, details
+}
+
+// healthState returns the liveness state of the proxy.
+func (p *ExternalMonitorProxy) healthState() string {
+	p.connectionMutex.RLock()
+	defer p.connectionMutex.RUnlock()
+
+	if (!p.connectedUnsafe() || !p.connected) && !p.idle {
+		if p.backoffAttempt >= p.config.PluginConfig.RetryPolicy.MaxAttempts {
+			return monitorGaveUp
+		}
+		return monitorReconnecting
+	}
+	if !p.IsReady() || !p.pluginReadyUnsafe() {
+		return monitorNotReady
+	}
+	return monitorHealthy
+}
+
+// handleHealthz reports OverallHealth, failing with 503 when unhealthy.
+func handleHealthz(w http.ResponseWriter, r *http.Request) {
+	healthy, details := OverallHealth()
+
+	w.Header().Set("Content-Type", "application/json")
+	if !healthy {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(w).Encode(details); err != nil {
+		klog.Errorf("Failed to encode monitor health: %v", err)
+	}
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	npdt "k8s.io/node-problem-detector/pkg/types"
+)
+
+// applyHysteresis keeps True conditions with a clear margin True while the
+// plugin reports them False without clearing the threshold by the margin, so
+// that a reading oscillating around the threshold does not flap the condition.
+func (p *ExternalMonitorProxy) applyHysteresis(status *npdt.Status) {
+	if p.lastStatus == nil {
+		return
+	}
+
+	for i, condition := range status.Conditions {
+		margin := p.clearMargin(condition.Type)
+		if margin <= 0 || condition.Status != npdt.False {
+			continue
+		}
+
+		committed, ok := findCondition(p.lastStatus.Conditions, condition.Type)
+		if !ok || committed.Status != npdt.True {
+			continue
+		}
+
+		breach, ok := p.conditionBreach(condition.Type)
+		if !ok || breach == 0 || breach <= -margin {
+			continue
+		}
+
+		p.logf(4, "Keeping %s/%s True, breach magnitude %.3f is within the clear margin %.3f",
+			p.name, condition.Type, breach, margin)
+		status.Conditions[i] = committed
+	}
+}
+
+// clearMargin returns the configured clear margin for a condition type.
+func (p *ExternalMonitorProxy) clearMargin(conditionType string) float64 {
+	for _, condDef := range p.declaredConditions() {
+		if condDef.Type == conditionType {
+			return condDef.ClearMargin
+		}
+	}
+	return 0
+}
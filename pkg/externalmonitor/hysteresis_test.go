@@ -0,0 +1,72 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"testing"
+
+	npdt "k8s.io/node-problem-detector/pkg/types"
+
+	"k8s.io/npd-ext/pkg/externalmonitor/types"
+)
+
+func TestApplyHysteresis(t *testing.T) {
+	testCases := []struct {
+		name string
+		// committed is the last forwarded status, empty for none
+		committed npdt.ConditionStatus
+		reported  npdt.ConditionStatus
+		// breach is the reported breach magnitude, nil for none
+		breach *float64
+		want   npdt.ConditionStatus
+	}{
+		{name: "first status", reported: npdt.False, breach: ptr(-1.0), want: npdt.False},
+		{name: "staying False", committed: npdt.False, reported: npdt.False, breach: ptr(-1.0), want: npdt.False},
+		{name: "turning True", committed: npdt.False, reported: npdt.True, breach: ptr(1.0), want: npdt.True},
+		{name: "clearing within the margin", committed: npdt.True, reported: npdt.False, breach: ptr(-2.0), want: npdt.True},
+		{name: "clearing by the margin", committed: npdt.True, reported: npdt.False, breach: ptr(-5.0), want: npdt.False},
+		{name: "clearing beyond the margin", committed: npdt.True, reported: npdt.False, breach: ptr(-8.0), want: npdt.False},
+		{name: "clearing without a magnitude", committed: npdt.True, reported: npdt.False, want: npdt.False},
+		{name: "clearing with a zero magnitude", committed: npdt.True, reported: npdt.False, breach: ptr(0.0), want: npdt.False},
+		{name: "turning Unknown", committed: npdt.True, reported: npdt.Unknown, breach: ptr(-1.0), want: npdt.Unknown},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := newTestProxy(t, testConfig(t, "/unused.sock", func(config *types.ExternalMonitorConfig) {
+				config.Conditions[0].ClearMargin = 5
+			}))
+			if tc.committed != "" {
+				p.lastStatus = &npdt.Status{Conditions: []npdt.Condition{{Type: "Fake", Status: tc.committed, Reason: "Committed"}}}
+			}
+			if tc.breach != nil {
+				p.setConditionBreach("Fake", *tc.breach)
+			}
+
+			status := &npdt.Status{Conditions: []npdt.Condition{{Type: "Fake", Status: tc.reported, Reason: "Reported"}}}
+			p.applyHysteresis(status)
+			if got := status.Conditions[0].Status; got != tc.want {
+				t.Errorf("status after hysteresis = %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+// ptr returns a pointer to v.
+func ptr[T any](v T) *T {
+	return &v
+}
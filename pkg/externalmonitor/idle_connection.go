@@ -0,0 +1,117 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"time"
+
+	"k8s.io/klog/v2"
+
+	pb "k8s.io/npd-ext/api/services/external/v1"
+)
+
+// isIdle reports whether the connection was closed until the next check.
+func (p *ExternalMonitorProxy) isIdle() bool {
+	p.connectionMutex.RLock()
+	defer p.connectionMutex.RUnlock()
+
+	return p.idle
+}
+
+// closeIdleConnection closes the connection after a check when the next
+// regular check is at least IdleTimeout away.
+func (p *ExternalMonitorProxy) closeIdleConnection() {
+	timeout := p.config.PluginConfig.IdleTimeout
+	if timeout <= 0 || p.currentInvokeInterval() < timeout {
+		return
+	}
+
+	p.connectionMutex.Lock()
+	defer p.connectionMutex.Unlock()
+
+	if p.shuttingDown.Load() || !p.connectedUnsafe() || !p.connected {
+		return
+	}
+
+	p.conn.Close()
+	p.conn = nil
+	p.client = nil
+	p.idle = true
+	p.logf(4, "Closed connection to %s until the next check", p.name)
+}
+
+// wakeIdleConnection dials the plugin again before a check when its connection
+// was closed by closeIdleConnection. Metadata is only fetched once stale, and
+// is refetched if the socket was recreated since. A failed dial leaves the
+// proxy disconnected, so the check reports the plugin unreachable and the
+// health check loop reconnects with backoff as usual. Like connectSocket, it
+// dials and calls the plugin without connectionMutex held.
+func (p *ExternalMonitorProxy) wakeIdleConnection() {
+	p.connectionMutex.Lock()
+	if !p.idle || p.shuttingDown.Load() {
+		p.connectionMutex.Unlock()
+		return
+	}
+
+	socket := p.selectSocket()
+	if socket == "" {
+		klog.Warningf("No socket available for %s for the next check", p.name)
+		p.idle = false
+		p.connected = false
+		p.connectionMutex.Unlock()
+		return
+	}
+	if socket != p.activeSocket || unixSocketInode(socket) != p.activeSocketInode {
+		p.metadataFetchedAt = time.Time{} // The socket may belong to a restarted plugin
+	}
+	p.connectionMutex.Unlock()
+
+	// The proxy stays idle while dialing, so the health check loop leaves it alone
+	conn, err := p.dial(socket)
+
+	p.connectionMutex.Lock()
+	if !p.idle || p.shuttingDown.Load() {
+		// Reconnected, say by ForceReconnect, or stopped while dialing
+		p.connectionMutex.Unlock()
+		if conn != nil {
+			conn.Close()
+		}
+		return
+	}
+	p.idle = false
+	if err != nil {
+		klog.Warningf("Failed to connect to %s for the next check: %v", p.name, err)
+		p.connected = false
+		p.connectionMutex.Unlock()
+		return
+	}
+
+	if p.conn != nil {
+		p.conn.Close()
+	}
+	p.conn = conn
+	p.client = pb.NewExternalMonitorClient(conn)
+	p.setActiveSocket(socket)
+	p.logf(4, "Connected to %s for the next check (socket: %s)", p.name, socket)
+	p.connectionMutex.Unlock()
+
+	p.refreshMetadataIfStale()
+	p.probeHealthService()
+	if !p.isSelfTestPassed() {
+		p.runSelfTest()
+	}
+}
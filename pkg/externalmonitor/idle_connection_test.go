@@ -0,0 +1,143 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"k8s.io/npd-ext/pkg/externalmonitor/types"
+)
+
+// idleConfig closes the connection after each check of a monitor checked
+// every minute.
+func idleConfig(config *types.ExternalMonitorConfig) {
+	config.PluginConfig.InvokeInterval = time.Minute
+	config.PluginConfig.IdleTimeout = 30 * time.Second
+}
+
+func TestIdleConnectionDialsPerCheck(t *testing.T) {
+	plugin := &fakePlugin{}
+	server := startFakePlugin(t, plugin, nil)
+	p := connectedTestProxy(t, server.socket, idleConfig)
+
+	for i := 1; i <= 3; i++ {
+		p.checkHealth(nil)
+
+		if n := plugin.checks.Load(); n != int32(i) {
+			t.Fatalf("after check %d: plugin checked %d times", i, n)
+		}
+		if !p.isIdle() {
+			t.Fatalf("after check %d: connection kept open", i)
+		}
+		p.connectionMutex.RLock()
+		conn := p.conn
+		p.connectionMutex.RUnlock()
+		if conn != nil {
+			t.Fatalf("after check %d: connection not closed", i)
+		}
+	}
+
+	// The first check uses the connection from connect, the others dial
+	if n := server.accepted.Load(); n != 3 {
+		t.Errorf("plugin accepted %d connections, want 3", n)
+	}
+	// Fresh metadata is not fetched again per check
+	if n := plugin.metadataCalls.Load(); n != 1 {
+		t.Errorf("GetMetadata called %d times, want 1", n)
+	}
+	if !p.isPluginReady() {
+		t.Error("idle plugin is not ready")
+	}
+}
+
+func TestIdleConnectionKeptOpenForFrequentChecks(t *testing.T) {
+	server := startFakePlugin(t, &fakePlugin{}, nil)
+	p := connectedTestProxy(t, server.socket, func(config *types.ExternalMonitorConfig) {
+		config.PluginConfig.InvokeInterval = 10 * time.Second
+		config.PluginConfig.IdleTimeout = 30 * time.Second
+	})
+
+	p.checkHealth(nil)
+	p.checkHealth(nil)
+
+	if p.isIdle() || !p.isConnected() {
+		t.Error("connection closed although checks are more frequent than IdleTimeout")
+	}
+	if n := server.accepted.Load(); n != 1 {
+		t.Errorf("plugin accepted %d connections, want 1", n)
+	}
+}
+
+func TestIdleConnectionRefetchesMetadataOfRestartedPlugin(t *testing.T) {
+	plugin := &fakePlugin{}
+	server := startFakePlugin(t, plugin, nil)
+	p := connectedTestProxy(t, server.socket, idleConfig)
+	p.checkHealth(nil)
+
+	// The restarted plugin listens on a new socket. Linking the old one
+	// keeps its inode from being reused for the new one
+	if err := os.Link(server.socket, server.socket+".old"); err != nil {
+		t.Fatal(err)
+	}
+	server.stop()
+	server.serve(t, plugin, nil)
+	p.checkHealth(nil)
+
+	if n := plugin.checks.Load(); n != 2 {
+		t.Errorf("plugin checked %d times, want 2", n)
+	}
+	if n := plugin.metadataCalls.Load(); n != 2 {
+		t.Errorf("GetMetadata called %d times, want 2", n)
+	}
+}
+
+func TestForceReconnectWhileIdle(t *testing.T) {
+	plugin := &fakePlugin{}
+	server := startFakePlugin(t, plugin, nil)
+	p := connectedTestProxy(t, server.socket, idleConfig)
+	p.checkHealth(nil)
+
+	if err := p.ForceReconnect(); err != nil {
+		t.Fatalf("ForceReconnect: %v", err)
+	}
+	if p.isIdle() {
+		t.Fatal("still idle after ForceReconnect")
+	}
+	p.connectionMutex.RLock()
+	forced := p.conn
+	p.connectionMutex.RUnlock()
+
+	// The forced connection is used rather than replaced by another dial
+	p.wakeIdleConnection()
+	p.connectionMutex.RLock()
+	current := p.conn
+	p.connectionMutex.RUnlock()
+	if current != forced {
+		t.Error("waking replaced the forced connection")
+	}
+
+	accepted := server.accepted.Load()
+	p.checkHealth(nil)
+	if n := server.accepted.Load(); n != accepted {
+		t.Errorf("check dialed %d more connections, want 0", n-accepted)
+	}
+	if n := plugin.checks.Load(); n != 2 {
+		t.Errorf("plugin checked %d times, want 2", n)
+	}
+}
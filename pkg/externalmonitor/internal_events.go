@@ -0,0 +1,58 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"fmt"
+
+	npdt "k8s.io/node-problem-detector/pkg/types"
+)
+
+// Reasons of the connection lifecycle events sent with emitInternalEvents.
+const (
+	internalEventDisconnected = "PluginDisconnected"
+	internalEventReconnected  = "PluginReconnected"
+	internalEventGaveUp       = "PluginReconnectGaveUp"
+)
+
+// emitInternalEvent sends a connection lifecycle event if emitInternalEvents
+// is set. It doesn't block, so it may be called with connectionMutex held.
+func (p *ExternalMonitorProxy) emitInternalEvent(severity npdt.Severity, reason, message string) {
+	if !p.config.PluginConfig.EmitInternalEvents {
+		return
+	}
+	p.sendEvent(severity, reason, message)
+}
+
+// reportConnectionLostUnsafe emits the PluginDisconnected event once per
+// outage of an established connection. Must be called with connectionMutex
+// held.
+func (p *ExternalMonitorProxy) reportConnectionLostUnsafe() {
+	if p.lossReported || p.activeSocket == "" {
+		return
+	}
+	p.lossReported = true
+	p.emitInternalEvent(npdt.Warn, internalEventDisconnected,
+		fmt.Sprintf("Lost connection to external monitor %s, reconnecting", p.name))
+}
+
+// reportReconnectedUnsafe emits the PluginReconnected event and ends the
+// outage. Must be called with connectionMutex held.
+func (p *ExternalMonitorProxy) reportReconnectedUnsafe(message string) {
+	p.lossReported = false
+	p.emitInternalEvent(npdt.Info, internalEventReconnected, message)
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// PluginDegradedLatencyCondition is reported when CheckHealth latency
+	// trends above the plugin's own baseline.
+	PluginDegradedLatencyCondition = "PluginDegradedLatency"
+
+	// latencyFastAlpha weights new samples in the recent latency average.
+	latencyFastAlpha = 0.3
+
+	// latencySlowAlpha weights new samples in the baseline latency average.
+	latencySlowAlpha = 0.02
+
+	// latencyWarmupSamples is how many samples are needed before the
+	// baseline is trusted.
+	latencyWarmupSamples = 10
+)
+
+// latencyTracker keeps a fast (recent) and a slow (baseline) exponential
+// moving average of call latency in seconds.
+type latencyTracker struct {
+	fast    float64
+	slow    float64
+	samples int
+}
+
+// observe adds a latency sample.
+func (t *latencyTracker) observe(latency time.Duration) {
+	seconds := latency.Seconds()
+	if t.samples == 0 {
+		t.fast = seconds
+		t.slow = seconds
+	} else {
+		t.fast += latencyFastAlpha * (seconds - t.fast)
+		t.slow += latencySlowAlpha * (seconds - t.slow)
+	}
+	t.samples++
+}
+
+// degraded reports whether recent latency exceeds factor times the baseline.
+func (t *latencyTracker) degraded(factor float64) bool {
+	return t.samples >= latencyWarmupSamples && t.slow > 0 && t.fast > factor*t.slow
+}
+
+// observeLatency records a CheckHealth latency and updates the degraded
+// latency condition when it is enabled.
+func (p *ExternalMonitorProxy) observeLatency(latency time.Duration) {
+	p.latency.observe(latency)
+	p.recordLatencyEMA(p.latency.fast, p.latency.slow)
+
+	factor := p.config.PluginConfig.DegradedLatencyFactor
+	if factor <= 0 {
+		return
+	}
+
+	if p.latency.degraded(factor) {
+		p.logf(4, "CheckHealth latency of %s degraded: recent %.3fs, baseline %.3fs",
+			p.name, p.latency.fast, p.latency.slow)
+		p.setProxyCondition(PluginDegradedLatencyCondition, true, "LatencyAboveBaseline",
+			fmt.Sprintf("CheckHealth latency of %s is more than %gx its baseline", p.name, factor))
+		return
+	}
+	p.setProxyCondition(PluginDegradedLatencyCondition, false, "LatencyNormal",
+		fmt.Sprintf("CheckHealth latency of %s is within %gx its baseline", p.name, factor))
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestLatencyTracker(t *testing.T) {
+	repeat := func(latency time.Duration, n int) []time.Duration {
+		samples := make([]time.Duration, n)
+		for i := range samples {
+			samples[i] = latency
+		}
+		return samples
+	}
+
+	testCases := []struct {
+		name         string
+		samples      []time.Duration
+		factor       float64
+		wantDegraded bool
+	}{
+		{
+			name:    "steady latency",
+			samples: repeat(100*time.Millisecond, 50),
+			factor:  2,
+		},
+		{
+			name:    "spike during warmup",
+			samples: append(repeat(100*time.Millisecond, 3), repeat(time.Second, 5)...),
+			factor:  2,
+		},
+		{
+			name:         "sustained slowdown",
+			samples:      append(repeat(100*time.Millisecond, 50), repeat(time.Second, 5)...),
+			factor:       2,
+			wantDegraded: true,
+		},
+		{
+			name:    "single slow call",
+			samples: append(repeat(100*time.Millisecond, 50), time.Second/3),
+			factor:  2,
+		},
+		{
+			name:    "recovered",
+			samples: append(append(repeat(100*time.Millisecond, 50), repeat(time.Second, 5)...), repeat(100*time.Millisecond, 20)...),
+			factor:  2,
+		},
+		{
+			name:    "zero baseline",
+			samples: repeat(0, 20),
+			factor:  2,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var tracker latencyTracker
+			for _, latency := range tc.samples {
+				tracker.observe(latency)
+			}
+			if got := tracker.degraded(tc.factor); got != tc.wantDegraded {
+				t.Errorf("degraded(%v) = %v with recent %.3fs and baseline %.3fs, want %v",
+					tc.factor, got, tracker.fast, tracker.slow, tc.wantDegraded)
+			}
+		})
+	}
+}
+
+func TestLatencyTrackerAverages(t *testing.T) {
+	var tracker latencyTracker
+	tracker.observe(time.Second)
+	if tracker.fast != 1 || tracker.slow != 1 {
+		t.Fatalf("first sample gave averages %v and %v, want 1 and 1", tracker.fast, tracker.slow)
+	}
+
+	tracker.observe(2 * time.Second)
+	if want := 1 + latencyFastAlpha; math.Abs(tracker.fast-want) > 1e-9 {
+		t.Errorf("recent average = %v, want %v", tracker.fast, want)
+	}
+	if want := 1 + latencySlowAlpha; math.Abs(tracker.slow-want) > 1e-9 {
+		t.Errorf("baseline average = %v, want %v", tracker.slow, want)
+	}
+}
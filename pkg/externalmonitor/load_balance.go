@@ -0,0 +1,155 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"k8s.io/klog/v2"
+
+	pb "k8s.io/npd-ext/api/services/external/v1"
+)
+
+// socketBackend is one socket CheckHealth calls are spread across.
+type socketBackend struct {
+	socket  string
+	weight  int
+	conn    *grpc.ClientConn
+	client  pb.ExternalMonitorClient
+	healthy bool
+}
+
+// socketBalancer picks a healthy socket for each CheckHealth call at random,
+// in proportion to the socket weights.
+type socketBalancer struct {
+	mutex    sync.Mutex
+	backends []*socketBackend
+	rand     *rand.Rand
+	closed   bool
+}
+
+// newSocketBalancer creates a balancer over the configured sockets. Sockets
+// are connected by probe.
+func (p *ExternalMonitorProxy) newSocketBalancer() *socketBalancer {
+	balancer := &socketBalancer{
+		rand: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	for _, socket := range p.config.PluginConfig.Sockets() {
+		weight, ok := p.config.PluginConfig.SocketWeights[socket]
+		if !ok {
+			weight = 1
+		}
+		balancer.backends = append(balancer.backends, &socketBackend{socket: socket, weight: weight})
+	}
+	p.probeBackends(balancer)
+	return balancer
+}
+
+// probeBackends connects the unhealthy backends, making them eligible again.
+func (p *ExternalMonitorProxy) probeBackends(b *socketBalancer) {
+	b.mutex.Lock()
+	if b.closed {
+		b.mutex.Unlock()
+		return
+	}
+	var unhealthy []*socketBackend
+	for _, backend := range b.backends {
+		if !backend.healthy {
+			unhealthy = append(unhealthy, backend)
+		}
+	}
+	b.mutex.Unlock()
+
+	// Dial without holding the lock so calls keep using the healthy backends
+	for _, backend := range unhealthy {
+		conn, err := p.dial(backend.socket)
+		if err != nil {
+			p.logf(4, "Socket %s of %s still unavailable: %v", backend.socket, p.name, err)
+			continue
+		}
+
+		b.mutex.Lock()
+		if b.closed {
+			b.mutex.Unlock()
+			conn.Close()
+			return
+		}
+		if backend.conn != nil {
+			backend.conn.Close()
+		}
+		backend.conn = conn
+		backend.client = pb.NewExternalMonitorClient(conn)
+		backend.healthy = true
+		b.mutex.Unlock()
+		klog.Infof("Socket %s of %s is available for load balancing", backend.socket, p.name)
+	}
+}
+
+// pick returns a healthy backend chosen at random by weight, or nil if no
+// backend is healthy.
+func (b *socketBalancer) pick() *socketBackend {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	total := 0
+	for _, backend := range b.backends {
+		if backend.healthy {
+			total += backend.weight
+		}
+	}
+	if total == 0 {
+		return nil
+	}
+
+	n := b.rand.Intn(total)
+	for _, backend := range b.backends {
+		if !backend.healthy {
+			continue
+		}
+		if n < backend.weight {
+			return backend
+		}
+		n -= backend.weight
+	}
+	return nil
+}
+
+// markUnhealthy excludes a backend until it is probed successfully again.
+func (b *socketBalancer) markUnhealthy(backend *socketBackend) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	backend.healthy = false
+}
+
+// close closes the connections of all backends.
+func (b *socketBalancer) close() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.closed = true
+	for _, backend := range b.backends {
+		if backend.conn != nil {
+			backend.conn.Close()
+			backend.conn = nil
+		}
+		backend.healthy = false
+	}
+}
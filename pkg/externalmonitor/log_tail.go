@@ -0,0 +1,103 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "k8s.io/npd-ext/api/services/external/v1"
+)
+
+const (
+	// logTailMinInterval is the minimum time between TailLogs calls to a
+	// plugin, so that the debug endpoint cannot load it.
+	logTailMinInterval = 10 * time.Second
+
+	// maxLogTailLines bounds the log lines requested and accepted per call.
+	maxLogTailLines = 1000
+)
+
+var (
+	// errLogTailUnimplemented is returned when the plugin does not implement TailLogs.
+	errLogTailUnimplemented = errors.New("plugin does not implement TailLogs")
+
+	// errLogTailRateLimited is returned when logs were tailed less than
+	// logTailMinInterval ago.
+	errLogTailRateLimited = fmt.Errorf("logs may be tailed at most once every %v", logTailMinInterval)
+)
+
+// TailLogs returns up to maxLines of the plugin's most recent log lines,
+// oldest first. maxLines is capped at maxLogTailLines; zero or less requests
+// the cap. Calls are rate limited to one per logTailMinInterval.
+func (p *ExternalMonitorProxy) TailLogs(ctx context.Context, maxLines int) ([]string, error) {
+	if maxLines <= 0 || maxLines > maxLogTailLines {
+		maxLines = maxLogTailLines
+	}
+
+	now := time.Now()
+	last := p.logTailAt.Load()
+	if now.Sub(time.Unix(0, last)) < logTailMinInterval || !p.logTailAt.CompareAndSwap(last, now.UnixNano()) {
+		return nil, errLogTailRateLimited
+	}
+
+	p.connectionMutex.RLock()
+	client := p.client
+	connected := p.connectedUnsafe()
+	p.connectionMutex.RUnlock()
+	if !connected {
+		return nil, fmt.Errorf("external monitor %s is not connected", p.name)
+	}
+	if p.capabilityDisabled(capabilityTailLogs) {
+		return nil, errLogTailUnimplemented
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.config.PluginConfig.Timeout)
+	defer cancel()
+
+	stream, err := client.TailLogs(ctx, &pb.TailLogsRequest{MaxLines: int32(maxLines)})
+	if err != nil {
+		return nil, tailLogsError(err)
+	}
+
+	var lines []string
+	for len(lines) < maxLines {
+		line, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, tailLogsError(err)
+		}
+		lines = append(lines, line.Line)
+	}
+	return lines, nil
+}
+
+// tailLogsError maps an Unimplemented TailLogs error to errLogTailUnimplemented.
+func tailLogsError(err error) error {
+	if status.Code(err) == codes.Unimplemented {
+		return errLogTailUnimplemented
+	}
+	return err
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"k8s.io/klog/v2"
+	npdt "k8s.io/node-problem-detector/pkg/types"
+)
+
+// maintenanceReason is the reason of conditions suppressed during maintenance.
+const maintenanceReason = "MaintenanceWindow"
+
+// SetMaintenance enables or disables maintenance independently of the
+// configured windows.
+func (p *ExternalMonitorProxy) SetMaintenance(enabled bool) {
+	if p.maintenance.Swap(enabled) != enabled {
+		klog.Infof("Maintenance for external monitor %s set to %v", p.name, enabled)
+	}
+}
+
+// InMaintenance returns true if maintenance is enabled or a configured
+// maintenance window is active.
+func (p *ExternalMonitorProxy) InMaintenance() bool {
+	return p.maintenance.Load() || p.config.PluginConfig.Maintenance.Active(time.Now())
+}
+
+// applyMaintenance reports the configured conditions as False during
+// maintenance. Events are left untouched.
+func (p *ExternalMonitorProxy) applyMaintenance(status *npdt.Status) {
+	suppressed := p.config.PluginConfig.Maintenance.Conditions
+	if len(suppressed) == 0 || !p.InMaintenance() {
+		return
+	}
+
+	for i, condition := range status.Conditions {
+		if !slices.Contains(suppressed, condition.Type) {
+			continue
+		}
+
+		// Keep the transition time while maintenance continues
+		if p.lastStatus != nil {
+			if committed, ok := findCondition(p.lastStatus.Conditions, condition.Type); ok &&
+				committed.Reason == maintenanceReason && committed.Status == npdt.False {
+				status.Conditions[i] = committed
+				continue
+			}
+		}
+
+		status.Conditions[i] = npdt.Condition{
+			Type:       condition.Type,
+			Status:     npdt.False,
+			Transition: time.Now(),
+			Reason:     maintenanceReason,
+			Message:    fmt.Sprintf("%s is suppressed during a maintenance window", condition.Type),
+		}
+	}
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"slices"
+
+	"k8s.io/klog/v2"
+
+	"k8s.io/npd-ext/pkg/externalmonitor/types"
+)
+
+// metricLabelOther is the condition label value overflowing condition types
+// are recorded under with the bucket overflow policy. It doesn't count
+// towards MaxConditionValues.
+const metricLabelOther = "other"
+
+// conditionLabel returns the condition label value to record a per-condition
+// metric under, or false if it must not be recorded. Condition types that are
+// denied, not allowed or beyond MaxConditionValues distinct values are
+// dropped or bucketed according to the overflow policy.
+func (p *ExternalMonitorProxy) conditionLabel(conditionType string) (string, bool) {
+	limits := p.config.MetricLabels
+
+	p.metricLabelsMutex.Lock()
+	defer p.metricLabelsMutex.Unlock()
+
+	if p.conditionLabels[conditionType] {
+		return conditionType, true
+	}
+
+	switch {
+	case matchesConditionType(limits.DenyConditions, conditionType):
+		p.logf(4, "Condition %s of %s is denied as a metric label", conditionType, p.name)
+	case len(limits.AllowConditions) > 0 && !matchesConditionType(limits.AllowConditions, conditionType):
+		p.logf(4, "Condition %s of %s is not allowed as a metric label", conditionType, p.name)
+	case len(p.conditionLabels) >= limits.MaxConditionValues:
+		if !p.labelCapReported {
+			p.labelCapReported = true
+			klog.Warningf("External monitor %s reached %d condition metric label values, %s condition %s and further new types",
+				p.name, limits.MaxConditionValues, overflowAction(limits.Overflow), conditionType)
+		} else {
+			p.logf(4, "Condition %s of %s exceeds the metric label cap", conditionType, p.name)
+		}
+	default:
+		p.conditionLabels[conditionType] = true
+		return conditionType, true
+	}
+
+	if limits.Overflow == types.MetricLabelOverflowBucket {
+		return metricLabelOther, true
+	}
+	return "", false
+}
+
+// matchesConditionType reports whether a condition type, or the per-device
+// definition it was expanded from, is listed.
+func matchesConditionType(list []string, conditionType string) bool {
+	return slices.Contains(list, conditionType) || slices.Contains(list, deviceTemplateType(conditionType))
+}
+
+// overflowAction describes an overflow policy for logs.
+func overflowAction(overflow string) string {
+	if overflow == types.MetricLabelOverflowBucket {
+		return "bucketing as " + metricLabelOther
+	}
+	return "dropping"
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"testing"
+
+	"k8s.io/npd-ext/pkg/externalmonitor/types"
+)
+
+func TestConditionLabel(t *testing.T) {
+	type label struct {
+		conditionType string
+		want          string
+		wantOK        bool
+	}
+
+	testCases := []struct {
+		name   string
+		limits types.MetricLabelsConfig
+		labels []label
+	}{
+		{
+			name:   "drop beyond the cap",
+			limits: types.MetricLabelsConfig{MaxConditionValues: 2},
+			labels: []label{
+				{"A", "A", true},
+				{"B", "B", true},
+				{"C", "", false},
+				{"A", "A", true},
+				{"D", "", false},
+			},
+		},
+		{
+			name:   "bucket beyond the cap",
+			limits: types.MetricLabelsConfig{MaxConditionValues: 1, Overflow: types.MetricLabelOverflowBucket},
+			labels: []label{
+				{"A", "A", true},
+				{"B", metricLabelOther, true},
+				{"C", metricLabelOther, true},
+				{"A", "A", true},
+			},
+		},
+		{
+			name:   "denied types",
+			limits: types.MetricLabelsConfig{DenyConditions: []string{"GPUHealthy"}},
+			labels: []label{
+				{"GPUHealthy", "", false},
+				{"GPUHealthy[0]", "", false},
+				{"Other", "Other", true},
+			},
+		},
+		{
+			name:   "allowed types",
+			limits: types.MetricLabelsConfig{AllowConditions: []string{"GPUHealthy"}, Overflow: types.MetricLabelOverflowBucket},
+			labels: []label{
+				{"GPUHealthy[1]", "GPUHealthy[1]", true},
+				{"Other", metricLabelOther, true},
+			},
+		},
+		{
+			name:   "denied types don't use up the cap",
+			limits: types.MetricLabelsConfig{MaxConditionValues: 1, DenyConditions: []string{"A"}},
+			labels: []label{
+				{"A", "", false},
+				{"B", "B", true},
+				{"C", "", false},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := newTestProxy(t, testConfig(t, "/unused.sock", func(config *types.ExternalMonitorConfig) {
+				config.MetricLabels = tc.limits
+			}))
+
+			for _, l := range tc.labels {
+				got, ok := p.conditionLabel(l.conditionType)
+				if got != l.want || ok != l.wantOK {
+					t.Errorf("conditionLabel(%q) = %q, %v, want %q, %v", l.conditionType, got, ok, l.want, l.wantOK)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"errors"
+	"sync"
+
+	"k8s.io/klog/v2"
+	"k8s.io/node-problem-detector/pkg/util/metrics"
+)
+
+// Metrics exported by external monitor proxies, tagged by source.
+var (
+	metricsOnce sync.Once
+
+	checkLatencyEMAMetric *metrics.Float64Metric
+	checkErrorRateMetric  *metrics.Float64Metric
+	conditionBreachMetric *metrics.Float64Metric
+
+	// metricsErr holds the errors creating the metrics, which fail Start
+	// for monitors with MetricsReporting
+	metricsErr error
+)
+
+// initMetrics registers the external monitor metrics once per process.
+func initMetrics() {
+	metricsOnce.Do(func() {
+		var err error
+		checkLatencyEMAMetric, err = metrics.NewFloat64Metric(
+			metrics.MetricID("external_monitor/check_latency_ema"),
+			"external_monitor/check_latency_ema",
+			"Exponential moving average of CheckHealth latency in seconds",
+			"s",
+			metrics.LastValue,
+			[]string{"source", "window"})
+		if err != nil {
+			klog.Errorf("Failed to create check latency metric: %v", err)
+			metricsErr = errors.Join(metricsErr, err)
+		}
+
+		checkErrorRateMetric, err = metrics.NewFloat64Metric(
+			metrics.MetricID("external_monitor/check_error_rate"),
+			"external_monitor/check_error_rate",
+			"Share of failed CheckHealth calls within the error rate window",
+			"1",
+			metrics.LastValue,
+			[]string{"source"})
+		if err != nil {
+			klog.Errorf("Failed to create check error rate metric: %v", err)
+			metricsErr = errors.Join(metricsErr, err)
+		}
+
+		conditionBreachMetric, err = metrics.NewFloat64Metric(
+			metrics.MetricID("external_monitor/condition_breach_magnitude"),
+			"external_monitor/condition_breach_magnitude",
+			"Magnitude of the threshold breach behind a condition, relative to the threshold",
+			"1",
+			metrics.LastValue,
+			[]string{"source", "condition"})
+		if err != nil {
+			klog.Errorf("Failed to create condition breach metric: %v", err)
+			metricsErr = errors.Join(metricsErr, err)
+		}
+	})
+}
+
+// recordLatencyEMA records the latency averages of the proxy.
+func (p *ExternalMonitorProxy) recordLatencyEMA(fast, slow float64) {
+	if !p.config.MetricsReporting || checkLatencyEMAMetric == nil {
+		return
+	}
+
+	for window, value := range map[string]float64{"fast": fast, "slow": slow} {
+		if err := checkLatencyEMAMetric.Record(map[string]string{"source": p.name, "window": window}, value); err != nil {
+			klog.Warningf("Failed to record check latency for %s: %v", p.name, err)
+		}
+	}
+}
+
+// recordErrorRate records the CheckHealth error rate of the proxy.
+func (p *ExternalMonitorProxy) recordErrorRate(rate float64) {
+	if !p.config.MetricsReporting || checkErrorRateMetric == nil {
+		return
+	}
+
+	if err := checkErrorRateMetric.Record(map[string]string{"source": p.name}, rate); err != nil {
+		klog.Warningf("Failed to record check error rate for %s: %v", p.name, err)
+	}
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"sync"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"k8s.io/klog/v2"
+
+	pb "k8s.io/npd-ext/api/services/external/v1"
+	npdt "k8s.io/node-problem-detector/pkg/types"
+)
+
+// NodeConditionProvider supplies the node's current conditions.
+type NodeConditionProvider interface {
+	NodeConditions() ([]npdt.Condition, error)
+}
+
+// StaticNodeConditionProvider is a NodeConditionProvider returning a fixed set of conditions.
+type StaticNodeConditionProvider []npdt.Condition
+
+// NodeConditions implements NodeConditionProvider.
+func (s StaticNodeConditionProvider) NodeConditions() ([]npdt.Condition, error) {
+	return s, nil
+}
+
+var (
+	nodeConditionProviderMutex sync.RWMutex
+	nodeConditionProvider      NodeConditionProvider
+)
+
+// SetNodeConditionProvider sets the provider used to include node conditions
+// in health check requests.
+func SetNodeConditionProvider(provider NodeConditionProvider) {
+	nodeConditionProviderMutex.Lock()
+	defer nodeConditionProviderMutex.Unlock()
+
+	nodeConditionProvider = provider
+}
+
+// nodeConditions returns the configured subset of node conditions to send to
+// the plugin, or nil when disabled or unavailable.
+func (p *ExternalMonitorProxy) nodeConditions() []*pb.Condition {
+	if len(p.config.PluginConfig.NodeConditions) == 0 {
+		return nil
+	}
+
+	nodeConditionProviderMutex.RLock()
+	provider := nodeConditionProvider
+	nodeConditionProviderMutex.RUnlock()
+
+	if provider == nil {
+		p.logf(4, "No node condition provider set for %s", p.name)
+		return nil
+	}
+
+	conditions, err := provider.NodeConditions()
+	if err != nil {
+		klog.Warningf("Failed to get node conditions for %s: %v", p.name, err)
+		return nil
+	}
+
+	wanted := make(map[string]bool, len(p.config.PluginConfig.NodeConditions))
+	for _, conditionType := range p.config.PluginConfig.NodeConditions {
+		wanted[conditionType] = true
+	}
+
+	var pbConditions []*pb.Condition
+	for _, condition := range conditions {
+		if wanted[condition.Type] {
+			pbConditions = append(pbConditions, toPBCondition(condition))
+		}
+	}
+	return pbConditions
+}
+
+// toPBCondition converts an internal Condition to a protobuf Condition.
+func toPBCondition(condition npdt.Condition) *pb.Condition {
+	pbStatus := pb.ConditionStatus_CONDITION_STATUS_UNKNOWN
+	switch condition.Status {
+	case npdt.True:
+		pbStatus = pb.ConditionStatus_CONDITION_STATUS_TRUE
+	case npdt.False:
+		pbStatus = pb.ConditionStatus_CONDITION_STATUS_FALSE
+	}
+
+	return &pb.Condition{
+		Type:       condition.Type,
+		Status:     pbStatus,
+		Transition: timestamppb.New(condition.Transition),
+		Reason:     condition.Reason,
+		Message:    condition.Message,
+	}
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"slices"
+
+	npdt "k8s.io/node-problem-detector/pkg/types"
+)
+
+// withOccurrenceEvents returns the status with an occurrence event appended
+// for each declared condition turning True after being False or not reported
+// yet. Unknown in between, e.g. while the plugin was unreachable, doesn't make
+// a condition that stays True occur again. The status is not modified.
+func (p *ExternalMonitorProxy) withOccurrenceEvents(status *npdt.Status) *npdt.Status {
+	if !p.config.PluginConfig.OccurrenceEvents {
+		return status
+	}
+
+	var events []npdt.Event
+	for _, condDef := range p.declaredConditions() {
+		condition, ok := findCondition(status.Conditions, condDef.Type)
+		if !ok || condition.Status != npdt.True || p.occurredConditions[condition.Type] == npdt.True {
+			continue
+		}
+
+		severity, ok := condDef.OccurrenceSeverities[condition.Reason]
+		if !ok {
+			severity = p.config.PluginConfig.OccurrenceEventSeverity
+		}
+		events = append(events, npdt.Event{
+			Severity:  npdt.Severity(severity),
+			Timestamp: condition.Transition,
+			Reason:    condition.Reason,
+			Message:   condition.Message,
+		})
+		p.logf(4, "Condition %s of %s occurred with reason %s", condition.Type, p.name, condition.Reason)
+	}
+	if len(events) == 0 {
+		return status
+	}
+
+	withEvents := *status
+	withEvents.Events = append(slices.Clone(status.Events), events...)
+	return &withEvents
+}
+
+// recordOccurrences notes the last known status of each condition sent, so
+// only a new occurrence produces an event.
+func (p *ExternalMonitorProxy) recordOccurrences(conditions []npdt.Condition) {
+	if !p.config.PluginConfig.OccurrenceEvents {
+		return
+	}
+
+	for _, condition := range conditions {
+		if condition.Status != npdt.Unknown {
+			p.occurredConditions[condition.Type] = condition.Status
+		}
+	}
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"testing"
+	"time"
+
+	npdt "k8s.io/node-problem-detector/pkg/types"
+
+	"k8s.io/npd-ext/pkg/externalmonitor/types"
+)
+
+func TestWithOccurrenceEvents(t *testing.T) {
+	testCases := []struct {
+		name string
+		// sequence are the statuses of "Fake" sent one after another
+		sequence []npdt.ConditionStatus
+		// wantEvents is the number of occurrence events for each status
+		wantEvents []int
+	}{
+		{
+			name:       "reported True first",
+			sequence:   []npdt.ConditionStatus{npdt.True},
+			wantEvents: []int{1},
+		},
+		{
+			name:       "staying True",
+			sequence:   []npdt.ConditionStatus{npdt.True, npdt.True, npdt.True},
+			wantEvents: []int{1, 0, 0},
+		},
+		{
+			name:       "turning True after False",
+			sequence:   []npdt.ConditionStatus{npdt.False, npdt.True, npdt.False, npdt.True},
+			wantEvents: []int{0, 1, 0, 1},
+		},
+		{
+			name:       "Unknown in between",
+			sequence:   []npdt.ConditionStatus{npdt.True, npdt.Unknown, npdt.True},
+			wantEvents: []int{1, 0, 0},
+		},
+		{
+			name:       "Unknown first",
+			sequence:   []npdt.ConditionStatus{npdt.Unknown, npdt.True},
+			wantEvents: []int{0, 1},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := newTestProxy(t, testConfig(t, "/unused.sock", func(config *types.ExternalMonitorConfig) {
+				config.PluginConfig.OccurrenceEvents = true
+			}))
+
+			for i, conditionStatus := range tc.sequence {
+				status := &npdt.Status{
+					Source: "fake",
+					Conditions: []npdt.Condition{{
+						Type: "Fake", Status: conditionStatus, Transition: time.Now(), Reason: "FakeBroken", Message: "broken",
+					}},
+				}
+				sent := p.withOccurrenceEvents(status)
+				if len(status.Events) != 0 {
+					t.Fatalf("status %d modified: %+v", i, status.Events)
+				}
+				if got := len(sent.Events); got != tc.wantEvents[i] {
+					t.Errorf("status %d (%s) has %d occurrence events, want %d", i, conditionStatus, got, tc.wantEvents[i])
+				}
+				for _, event := range sent.Events {
+					if event.Severity != npdt.Warn || event.Reason != "FakeBroken" || event.Message != "broken" {
+						t.Errorf("got event %+v, want a warning with the condition reason and message", event)
+					}
+				}
+				p.recordOccurrences(sent.Conditions)
+			}
+		})
+	}
+}
+
+func TestWithOccurrenceEventsSeverities(t *testing.T) {
+	p := newTestProxy(t, testConfig(t, "/unused.sock", func(config *types.ExternalMonitorConfig) {
+		config.PluginConfig.OccurrenceEvents = true
+		config.Conditions[0].OccurrenceSeverities = map[string]string{"FakeBurning": types.EventSeverityInfo}
+	}))
+
+	status := &npdt.Status{Conditions: []npdt.Condition{{Type: "Fake", Status: npdt.True, Reason: "FakeBurning"}}}
+	sent := p.withOccurrenceEvents(status)
+	if len(sent.Events) != 1 || sent.Events[0].Severity != npdt.Info {
+		t.Errorf("got events %+v, want one info event for reason FakeBurning", sent.Events)
+	}
+
+	// Conditions that aren't declared never occur
+	status = &npdt.Status{Conditions: []npdt.Condition{{Type: "Other", Status: npdt.True, Reason: "OtherBroken"}}}
+	if sent := p.withOccurrenceEvents(status); len(sent.Events) != 0 {
+		t.Errorf("got events %+v for an undeclared condition, want none", sent.Events)
+	}
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package paramcrypt encrypts external monitor plugin parameters to a key
+// advertised by the plugin, so that secrets such as credentials are never
+// sent in the clear in HealthCheckRequest.
+//
+// A value is encrypted with an ephemeral X25519 key agreed with the plugin's
+// public key. The shared secret is expanded with HKDF-SHA256 into an AES-256-GCM
+// key, and the parameter name is authenticated with the value so that one
+// encrypted value can't be replayed as another parameter. Encrypted values are
+// sent as Prefix followed by the base64 encoding of the ephemeral public key,
+// the nonce and the ciphertext.
+package paramcrypt
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Prefix marks an encrypted parameter value.
+const Prefix = "enc:v1:"
+
+// hkdfInfo binds derived keys to this use.
+const hkdfInfo = "npd-ext parameter encryption v1"
+
+const (
+	keySize   = 32
+	nonceSize = 12
+)
+
+// ErrNotEncrypted is returned by Decrypt for a value without Prefix.
+var ErrNotEncrypted = errors.New("parameter value is not encrypted")
+
+// GenerateKey returns a new X25519 key for a plugin. The plugin advertises
+// its PublicKey().Bytes() as parameter_encryption_key in its metadata.
+func GenerateKey() (*ecdh.PrivateKey, error) {
+	return ecdh.X25519().GenerateKey(rand.Reader)
+}
+
+// ParsePrivateKey parses a 32 byte X25519 private key, e.g. one read from a
+// file so that the key survives plugin restarts.
+func ParsePrivateKey(key []byte) (*ecdh.PrivateKey, error) {
+	return ecdh.X25519().NewPrivateKey(key)
+}
+
+// IsEncrypted reports whether value was produced by Encrypt.
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, Prefix)
+}
+
+// Encrypt encrypts the value of the named parameter to the plugin's public key.
+func Encrypt(publicKey []byte, name, value string) (string, error) {
+	recipient, err := ecdh.X25519().NewPublicKey(publicKey)
+	if err != nil {
+		return "", fmt.Errorf("invalid parameter encryption key: %w", err)
+	}
+	ephemeral, err := GenerateKey()
+	if err != nil {
+		return "", err
+	}
+	shared, err := ephemeral.ECDH(recipient)
+	if err != nil {
+		return "", fmt.Errorf("invalid parameter encryption key: %w", err)
+	}
+
+	ephemeralPublic := ephemeral.PublicKey().Bytes()
+	aead, err := newAEAD(shared, ephemeralPublic, publicKey)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, nonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := make([]byte, 0, len(ephemeralPublic)+nonceSize+len(value)+aead.Overhead())
+	sealed = append(sealed, ephemeralPublic...)
+	sealed = append(sealed, nonce...)
+	sealed = aead.Seal(sealed, nonce, []byte(value), []byte(name))
+	return Prefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt decrypts the value of the named parameter with the plugin's private
+// key. It fails with ErrNotEncrypted if the value isn't encrypted, and with
+// an error if it was encrypted to another key or for another parameter.
+func Decrypt(privateKey *ecdh.PrivateKey, name, value string) (string, error) {
+	if !IsEncrypted(value) {
+		return "", ErrNotEncrypted
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, Prefix))
+	if err != nil {
+		return "", fmt.Errorf("malformed encrypted parameter %q: %w", name, err)
+	}
+	if len(sealed) < keySize+nonceSize {
+		return "", fmt.Errorf("malformed encrypted parameter %q: too short", name)
+	}
+
+	ephemeralPublic, nonce, ciphertext := sealed[:keySize], sealed[keySize:keySize+nonceSize], sealed[keySize+nonceSize:]
+	ephemeral, err := ecdh.X25519().NewPublicKey(ephemeralPublic)
+	if err != nil {
+		return "", fmt.Errorf("malformed encrypted parameter %q: %w", name, err)
+	}
+	shared, err := privateKey.ECDH(ephemeral)
+	if err != nil {
+		return "", fmt.Errorf("malformed encrypted parameter %q: %w", name, err)
+	}
+
+	aead, err := newAEAD(shared, ephemeralPublic, privateKey.PublicKey().Bytes())
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(name))
+	if err != nil {
+		return "", fmt.Errorf("cannot decrypt parameter %q: %w", name, err)
+	}
+	return string(plaintext), nil
+}
+
+// DecryptParameters returns a copy of the request parameters with encrypted
+// values decrypted. Plain values are passed through unchanged.
+func DecryptParameters(privateKey *ecdh.PrivateKey, parameters map[string]string) (map[string]string, error) {
+	decrypted := make(map[string]string, len(parameters))
+	for name, value := range parameters {
+		if !IsEncrypted(value) {
+			decrypted[name] = value
+			continue
+		}
+		plaintext, err := Decrypt(privateKey, name, value)
+		if err != nil {
+			return nil, err
+		}
+		decrypted[name] = plaintext
+	}
+	return decrypted, nil
+}
+
+// newAEAD derives the AES-256-GCM key for a value from the X25519 shared
+// secret, salted with both public keys.
+func newAEAD(shared, ephemeralPublic, recipientPublic []byte) (cipher.AEAD, error) {
+	salt := bytes.Join([][]byte{ephemeralPublic, recipientPublic}, nil)
+	key, err := hkdf.Key(sha256.New, shared, salt, hkdfInfo, keySize)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package paramcrypt
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, value := range []string{"", "hunter2", strings.Repeat("long secret ", 100)} {
+		encrypted, err := Encrypt(key.PublicKey().Bytes(), "password", value)
+		if err != nil {
+			t.Fatalf("Encrypt(%q): %v", value, err)
+		}
+		if !IsEncrypted(encrypted) {
+			t.Errorf("Encrypt(%q) = %q, want a value with prefix %q", value, encrypted, Prefix)
+		}
+		if value != "" && strings.Contains(encrypted, value) {
+			t.Errorf("Encrypt(%q) = %q contains the plaintext", value, encrypted)
+		}
+
+		decrypted, err := Decrypt(key, "password", encrypted)
+		if err != nil {
+			t.Fatalf("Decrypt: %v", err)
+		}
+		if decrypted != value {
+			t.Errorf("Decrypt = %q, want %q", decrypted, value)
+		}
+	}
+}
+
+func TestEncryptUsesFreshKeys(t *testing.T) {
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := Encrypt(key.PublicKey().Bytes(), "password", "hunter2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Encrypt(key.PublicKey().Bytes(), "password", "hunter2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first == second {
+		t.Error("encrypting the same value twice gave the same ciphertext")
+	}
+}
+
+func TestDecryptRejects(t *testing.T) {
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted, err := Encrypt(key.PublicKey().Bytes(), "password", "hunter2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// flip changes one byte of the sealed value at offset from its end
+	flip := func(offset int) string {
+		sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(encrypted, Prefix))
+		if err != nil {
+			t.Fatal(err)
+		}
+		sealed[len(sealed)-offset] ^= 0x01
+		return Prefix + base64.StdEncoding.EncodeToString(sealed)
+	}
+
+	testCases := []struct {
+		name     string
+		otherKey bool
+		param    string
+		value    string
+	}{
+		{name: "tampered ciphertext", param: "password", value: flip(1)},
+		{name: "tampered nonce", param: "password", value: flip(len("hunter2") + 16 + 1)},
+		{name: "replayed as another parameter", param: "token", value: encrypted},
+		{name: "another key", param: "password", value: encrypted, otherKey: true},
+		{name: "truncated", param: "password", value: encrypted[:len(Prefix)+8]},
+		{name: "not base64", param: "password", value: Prefix + "!!!"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			privateKey := key
+			if tc.otherKey {
+				privateKey = otherKey
+			}
+			if got, err := Decrypt(privateKey, tc.param, tc.value); err == nil {
+				t.Errorf("Decrypt = %q, want an error", got)
+			}
+		})
+	}
+}
+
+func TestDecryptParameters(t *testing.T) {
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted, err := Encrypt(key.PublicKey().Bytes(), "password", "hunter2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decrypted, err := DecryptParameters(key, map[string]string{"password": encrypted, "threshold": "85"})
+	if err != nil {
+		t.Fatalf("DecryptParameters: %v", err)
+	}
+	if decrypted["password"] != "hunter2" || decrypted["threshold"] != "85" {
+		t.Errorf("DecryptParameters = %v, want password decrypted and threshold unchanged", decrypted)
+	}
+
+	if _, err := Decrypt(key, "threshold", "85"); !errors.Is(err, ErrNotEncrypted) {
+		t.Errorf("Decrypt of a plain value = %v, want ErrNotEncrypted", err)
+	}
+	if _, err := DecryptParameters(key, map[string]string{"token": encrypted}); err == nil {
+		t.Error("DecryptParameters accepted a value encrypted for another parameter")
+	}
+}
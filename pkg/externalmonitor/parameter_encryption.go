@@ -0,0 +1,84 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/klog/v2"
+
+	"k8s.io/npd-ext/pkg/externalmonitor/paramcrypt"
+)
+
+// encryptParameters returns the request parameters with the sensitive ones
+// encrypted to the key from the plugin metadata. Without a key they are left
+// out rather than sent in the clear. The parameters are returned unchanged
+// when none are sensitive.
+func (p *ExternalMonitorProxy) encryptParameters(parameters map[string]string, key []byte) (map[string]string, error) {
+	sensitive := p.config.PluginConfig.SensitiveParameters
+	if len(sensitive) == 0 {
+		return parameters, nil
+	}
+	if parameters == nil {
+		parameters = p.config.PluginConfig.PluginParameters
+	}
+
+	encrypted := make(map[string]string, len(parameters))
+	for name, value := range parameters {
+		encrypted[name] = value
+	}
+	if len(key) == 0 {
+		klog.Warningf("External monitor %s advertises no parameter encryption key, not sending sensitive parameters %s",
+			p.name, strings.Join(sensitive, ", "))
+		for _, name := range sensitive {
+			delete(encrypted, name)
+		}
+		return encrypted, nil
+	}
+
+	for _, name := range sensitive {
+		value, ok := encrypted[name]
+		if !ok {
+			continue
+		}
+		ciphertext, err := paramcrypt.Encrypt(key, name, value)
+		if err != nil {
+			return nil, fmt.Errorf("cannot encrypt parameter %q for %s: %w", name, p.name, err)
+		}
+		encrypted[name] = ciphertext
+	}
+	return encrypted, nil
+}
+
+// withoutSensitiveParameters returns the parameters without the sensitive
+// ones, for requests sent before the plugin metadata, and so its key, is known.
+func (p *ExternalMonitorProxy) withoutSensitiveParameters(parameters map[string]string) map[string]string {
+	sensitive := p.config.PluginConfig.SensitiveParameters
+	if len(sensitive) == 0 {
+		return parameters
+	}
+
+	filtered := make(map[string]string, len(parameters))
+	for name, value := range parameters {
+		filtered[name] = value
+	}
+	for _, name := range sensitive {
+		delete(filtered, name)
+	}
+	return filtered
+}
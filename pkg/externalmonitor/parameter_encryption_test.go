@@ -0,0 +1,94 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"testing"
+
+	pb "k8s.io/npd-ext/api/services/external/v1"
+	"k8s.io/npd-ext/pkg/externalmonitor/paramcrypt"
+	"k8s.io/npd-ext/pkg/externalmonitor/types"
+)
+
+func TestSensitiveParametersRoundTrip(t *testing.T) {
+	key, err := paramcrypt.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name       string
+		precedence string
+		// want are the decrypted parameters the plugin receives
+		want map[string]string
+	}{
+		{
+			name:       "config precedence",
+			precedence: types.ParameterPrecedenceConfig,
+			want:       map[string]string{"user": "npd", "password": "hunter2", "token": "default-token", "threshold": "85"},
+		},
+		{
+			name:       "plugin precedence",
+			precedence: types.ParameterPrecedencePlugin,
+			want:       map[string]string{"password": "hunter2"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			plugin := &fakePlugin{metadata: &pb.MonitorMetadata{
+				Name:                   "fake",
+				ParameterEncryptionKey: key.PublicKey().Bytes(),
+				DefaultParameters: map[string]string{
+					"user": "npd", "password": "changeme", "token": "default-token", "threshold": "85",
+				},
+			}}
+			server := startFakePlugin(t, plugin, nil)
+			p := connectedTestProxy(t, server.socket, func(config *types.ExternalMonitorConfig) {
+				config.PluginConfig.ParameterPrecedence = tc.precedence
+				config.PluginConfig.PluginParameters = map[string]string{
+					"user": "npd", "password": "hunter2", "token": "default-token", "threshold": "85",
+				}
+				config.PluginConfig.SensitiveParameters = []string{"password", "token"}
+			})
+
+			p.checkHealth(nil)
+			req := plugin.lastRequest()
+			if req == nil {
+				t.Fatal("no CheckHealth request")
+			}
+			for _, name := range []string{"password", "token"} {
+				if value, ok := req.Parameters[name]; ok && !paramcrypt.IsEncrypted(value) {
+					t.Errorf("sensitive parameter %s sent in the clear: %q", name, value)
+				}
+			}
+
+			got, err := paramcrypt.DecryptParameters(key, req.Parameters)
+			if err != nil {
+				t.Fatalf("DecryptParameters: %v", err)
+			}
+			if len(got) != len(tc.want) {
+				t.Errorf("plugin received %v, want %v", got, tc.want)
+			}
+			for name, value := range tc.want {
+				if got[name] != value {
+					t.Errorf("plugin received %s=%q, want %q", name, got[name], value)
+				}
+			}
+		})
+	}
+}
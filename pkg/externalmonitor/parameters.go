@@ -0,0 +1,155 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	pb "k8s.io/npd-ext/api/services/external/v1"
+	"k8s.io/npd-ext/pkg/externalmonitor/types"
+)
+
+// PluginParameterRejectedCondition is reported, with ReportParameterRejection,
+// while the plugin parameters are rejected.
+const PluginParameterRejectedCondition = "PluginParameterRejected"
+
+// errInvalidParameters is returned when configured plugin parameters don't
+// match the types advertised in the plugin metadata.
+var errInvalidParameters = errors.New("invalid plugin parameters")
+
+// coerceParameters validates the configured plugin parameters against the
+// parameter specs from the plugin metadata and returns them normalized, e.g.
+// " 85" for an int parameter becomes "85". Parameters without a spec pass
+// through unchanged. Returns nil when the plugin advertises no specs.
+func (p *ExternalMonitorProxy) coerceParameters(specs []*pb.ParameterSpec) (map[string]string, error) {
+	if len(specs) == 0 {
+		return nil, nil
+	}
+
+	byName := make(map[string]*pb.ParameterSpec, len(specs))
+	for _, spec := range specs {
+		if spec != nil {
+			byName[spec.Name] = spec
+		}
+	}
+
+	var problems []string
+	coerced := make(map[string]string, len(p.config.PluginConfig.PluginParameters))
+	for name, value := range p.config.PluginConfig.PluginParameters {
+		spec, ok := byName[name]
+		if !ok {
+			if name != types.TimeoutParameter {
+				klog.Warningf("External monitor %s does not declare parameter %q", p.name, name)
+			}
+			coerced[name] = value
+			continue
+		}
+
+		normalized, err := coerceParameter(spec.Type, value)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+		coerced[name] = normalized
+	}
+
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("%w for %s: %s", errInvalidParameters, p.name, strings.Join(problems, "; "))
+	}
+	return coerced, nil
+}
+
+// withoutDefaultParameters returns the parameters, the configured ones if nil,
+// without those equal to the defaults advertised in the plugin metadata when
+// the plugin's defaults take precedence. It must see the plaintext values,
+// since an encrypted value never equals its default. The parameters are
+// returned unchanged otherwise.
+func (p *ExternalMonitorProxy) withoutDefaultParameters(parameters, defaults map[string]string) map[string]string {
+	if p.config.PluginConfig.ParameterPrecedence != types.ParameterPrecedencePlugin || len(defaults) == 0 {
+		return parameters
+	}
+	if parameters == nil {
+		parameters = p.config.PluginConfig.PluginParameters
+	}
+
+	filtered := make(map[string]string, len(parameters))
+	for name, value := range parameters {
+		if defaultValue, ok := defaults[name]; ok && defaultValue == value {
+			continue
+		}
+		filtered[name] = value
+	}
+	return filtered
+}
+
+// coerceParameter parses a parameter value as the given type and returns its
+// canonical form.
+func coerceParameter(paramType pb.ParameterType, value string) (string, error) {
+	trimmed := strings.TrimSpace(value)
+	switch paramType {
+	case pb.ParameterType_PARAMETER_TYPE_INT:
+		n, err := strconv.ParseInt(trimmed, 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("%q is not an int", value)
+		}
+		return strconv.FormatInt(n, 10), nil
+	case pb.ParameterType_PARAMETER_TYPE_FLOAT:
+		f, err := strconv.ParseFloat(trimmed, 64)
+		if err != nil {
+			return "", fmt.Errorf("%q is not a float", value)
+		}
+		return strconv.FormatFloat(f, 'f', -1, 64), nil
+	case pb.ParameterType_PARAMETER_TYPE_BOOL:
+		b, err := strconv.ParseBool(trimmed)
+		if err != nil {
+			return "", fmt.Errorf("%q is not a bool", value)
+		}
+		return strconv.FormatBool(b), nil
+	case pb.ParameterType_PARAMETER_TYPE_DURATION:
+		d, err := time.ParseDuration(trimmed)
+		if err != nil {
+			return "", fmt.Errorf("%q is not a duration", value)
+		}
+		return d.String(), nil
+	default:
+		return value, nil
+	}
+}
+
+// setParametersRejected reports PluginParameterRejected when enabled.
+func (p *ExternalMonitorProxy) setParametersRejected(reason string, err error) {
+	if !p.config.PluginConfig.ReportParameterRejection {
+		return
+	}
+	p.setProxyCondition(PluginParameterRejectedCondition, true, reason,
+		fmt.Sprintf("Plugin parameters rejected: %s", errorMessage(err)))
+}
+
+// setParametersAccepted clears PluginParameterRejected when enabled.
+func (p *ExternalMonitorProxy) setParametersAccepted() {
+	if !p.config.PluginConfig.ReportParameterRejection {
+		return
+	}
+	p.setProxyCondition(PluginParameterRejectedCondition, false, "ParametersAccepted",
+		fmt.Sprintf("External monitor %s accepts its parameters", p.name))
+}
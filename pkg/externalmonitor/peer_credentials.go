@@ -0,0 +1,73 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"k8s.io/klog/v2"
+)
+
+const (
+	// PluginPeerRejectedCondition is reported when the socket peer fails credential verification.
+	PluginPeerRejectedCondition = "PluginPeerRejected"
+)
+
+// peerCredentials identifies the process on the other end of a Unix socket.
+type peerCredentials struct {
+	pid int32
+	uid uint32
+	gid uint32
+}
+
+// dialVerifiedPeer dials the plugin socket and rejects the connection unless the
+// serving process matches the configured peer credential allowlist.
+func (p *ExternalMonitorProxy) dialVerifiedPeer(ctx context.Context, addr string) (net.Conn, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "unix", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	cred, err := getPeerCredentials(conn)
+	if err != nil {
+		conn.Close()
+		klog.Errorf("Security: unable to verify peer on socket %s for %s: %v", addr, p.name, err)
+		p.setProxyCondition(PluginPeerRejectedCondition, true, "PeerVerificationFailed",
+			fmt.Sprintf("Unable to verify peer on socket %s: %v", addr, err))
+		return nil, err
+	}
+
+	if !p.config.PluginConfig.PeerCredentials.Allows(cred.uid, cred.gid) {
+		conn.Close()
+		klog.Errorf("Security: rejecting peer on socket %s for %s: pid=%d uid=%d gid=%d not in allowlist",
+			addr, p.name, cred.pid, cred.uid, cred.gid)
+		p.setProxyCondition(PluginPeerRejectedCondition, true, "PeerNotAllowed",
+			fmt.Sprintf("Peer on socket %s (pid=%d uid=%d gid=%d) is not in the allowlist",
+				addr, cred.pid, cred.uid, cred.gid))
+		return nil, fmt.Errorf("peer pid=%d uid=%d gid=%d is not allowed", cred.pid, cred.uid, cred.gid)
+	}
+
+	p.logf(4, "Verified peer on socket %s for %s: pid=%d uid=%d gid=%d",
+		addr, p.name, cred.pid, cred.uid, cred.gid)
+	p.setProxyCondition(PluginPeerRejectedCondition, false, "PeerVerified",
+		fmt.Sprintf("Peer on socket %s passed credential verification", addr))
+
+	return conn, nil
+}
@@ -0,0 +1,33 @@
+//go:build !linux
+
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"fmt"
+	"net"
+	"runtime"
+)
+
+// peerCredentialsSupported is true where getPeerCredentials is implemented.
+const peerCredentialsSupported = false
+
+// getPeerCredentials is not supported on this platform.
+func getPeerCredentials(conn net.Conn) (peerCredentials, error) {
+	return peerCredentials{}, fmt.Errorf("peer credential verification is not supported on %s", runtime.GOOS)
+}
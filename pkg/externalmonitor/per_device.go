@@ -0,0 +1,86 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"k8s.io/klog/v2"
+
+	"k8s.io/npd-ext/pkg/externalmonitor/types"
+)
+
+// declaredConditions returns the configured condition definitions with each
+// per-device definition expanded into one definition per advertised device.
+func (p *ExternalMonitorProxy) declaredConditions() []types.ConditionDefinition {
+	devices := int(p.deviceCount.Load())
+
+	var declared []types.ConditionDefinition
+	for _, condDef := range p.config.Conditions {
+		if !condDef.PerDevice || devices == 0 {
+			declared = append(declared, condDef)
+			continue
+		}
+		for device := 0; device < devices; device++ {
+			deviceDef := condDef
+			deviceDef.Type = deviceConditionType(condDef.Type, device)
+			declared = append(declared, deviceDef)
+		}
+	}
+	return declared
+}
+
+// setDeviceCount records the device count advertised in the plugin metadata.
+func (p *ExternalMonitorProxy) setDeviceCount(count int32) {
+	if count < 0 {
+		count = 0
+	}
+	if previous := p.deviceCount.Swap(count); previous != 0 && previous != count {
+		klog.Infof("External monitor %s device count changed from %d to %d", p.name, previous, count)
+	}
+}
+
+// deviceConditionType returns the type of a per-device condition, e.g.
+// "GPUHealthy[0]".
+func deviceConditionType(conditionType string, device int) string {
+	return fmt.Sprintf("%s[%d]", conditionType, device)
+}
+
+// deviceTemplateType returns the type of the per-device definition a condition
+// type was expanded from, e.g. "GPUHealthy" for "GPUHealthy[0]", or the type
+// itself if it is not a per-device type.
+func deviceTemplateType(conditionType string) string {
+	open := strings.LastIndexByte(conditionType, '[')
+	if open <= 0 || !strings.HasSuffix(conditionType, "]") {
+		return conditionType
+	}
+	if _, err := strconv.Atoi(conditionType[open+1 : len(conditionType)-1]); err != nil {
+		return conditionType
+	}
+	return conditionType[:open]
+}
+
+// conditionSelected reports whether a check selecting the given condition
+// types evaluates conditionType. Selecting a per-device definition selects
+// all of its devices, and an empty selection selects every condition.
+func conditionSelected(selected []string, conditionType string) bool {
+	return len(selected) == 0 || slices.Contains(selected, conditionType) ||
+		slices.Contains(selected, deviceTemplateType(conditionType))
+}
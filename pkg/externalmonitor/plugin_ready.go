@@ -0,0 +1,141 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"k8s.io/klog/v2"
+)
+
+// Results of probing the standard gRPC health service.
+const (
+	healthServiceUnknown int32 = iota // Not probed yet, or not implemented
+	healthServiceServing
+	healthServiceNotServing
+)
+
+// isPluginReady reports whether the plugin can serve requests: the channel is
+// up, or closed on purpose between checks, CheckHealth has not failed
+// ErrorThreshold times in a row, and the gRPC health service, if the plugin
+// implements it, reports SERVING. A plugin whose server is up but whose
+// backend is broken is not ready.
+func (p *ExternalMonitorProxy) isPluginReady() bool {
+	p.connectionMutex.RLock()
+	defer p.connectionMutex.RUnlock()
+
+	return p.pluginReadyUnsafe()
+}
+
+// pluginReadyUnsafe is isPluginReady without locking.
+func (p *ExternalMonitorProxy) pluginReadyUnsafe() bool {
+	reason, _ := p.notReadyCauseUnsafe()
+	return reason == ""
+}
+
+// notReadyCause returns the condition reason and a description of why the
+// plugin is not ready, or an empty reason when it is.
+func (p *ExternalMonitorProxy) notReadyCause() (string, string) {
+	p.connectionMutex.RLock()
+	defer p.connectionMutex.RUnlock()
+
+	return p.notReadyCauseUnsafe()
+}
+
+// notReadyCauseUnsafe is notReadyCause without locking.
+func (p *ExternalMonitorProxy) notReadyCauseUnsafe() (string, string) {
+	switch {
+	case !p.idle && (!p.connectedUnsafe() || !p.connected):
+		return "PluginUnreachable", "unreachable"
+	case p.healthService.Load() == healthServiceNotServing:
+		return "PluginNotServing", "not serving"
+	case p.checkFailures.Load() >= int64(p.config.PluginConfig.HealthCheck.ErrorThreshold):
+		return "PluginChecksFailing", "failing health checks"
+	}
+	return "", ""
+}
+
+// observeCheckResult counts consecutive CheckHealth failures. Unlike the
+// error count, it is not reset by reconnecting, since a reconnection succeeds
+// whenever the channel is up.
+func (p *ExternalMonitorProxy) observeCheckResult(err error) {
+	if err == nil {
+		p.checkFailures.Store(0)
+		return
+	}
+	if !p.isBenignError("CheckHealth", status.Code(err)) {
+		p.checkFailures.Add(1)
+	}
+}
+
+// probeHealthService asks the standard gRPC health service whether the plugin
+// is serving. Plugins that don't implement it are judged by their channel and
+// CheckHealth results alone. A failed probe, say a timeout, only marks the
+// plugin not serving once ErrorThreshold probes in a row failed; an explicit
+// NOT_SERVING answer does right away. The probe runs without connectionMutex
+// and its result is dropped if the connection was replaced meanwhile. Must be
+// called without connectionMutex held.
+func (p *ExternalMonitorProxy) probeHealthService() {
+	p.connectionMutex.RLock()
+	conn := p.conn
+	p.connectionMutex.RUnlock()
+	if conn == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.config.PluginConfig.Timeout)
+	defer cancel()
+
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+
+	p.connectionMutex.RLock()
+	defer p.connectionMutex.RUnlock()
+	if p.conn != conn {
+		p.logf(4, "Dropping health service result of a replaced connection to %s", p.name)
+		return
+	}
+
+	result := healthServiceServing
+	switch {
+	case status.Code(err) == codes.Unimplemented:
+		result = healthServiceUnknown
+	case err != nil:
+		failures := p.healthProbeFailures.Add(1)
+		p.logf(4, "Health service of %s failed (%d in a row): %v", p.name, failures, err)
+		if failures < int64(p.config.PluginConfig.HealthCheck.ErrorThreshold) {
+			return
+		}
+		result = healthServiceNotServing
+	case resp.Status != healthpb.HealthCheckResponse_SERVING:
+		result = healthServiceNotServing
+	}
+	if code := status.Code(err); code == codes.OK || code == codes.Unimplemented {
+		p.healthProbeFailures.Store(0)
+	}
+
+	if previous := p.healthService.Swap(result); previous != result {
+		switch result {
+		case healthServiceNotServing:
+			klog.Warningf("External monitor %s is not serving", p.name)
+		case healthServiceServing:
+			klog.Infof("External monitor %s reports it is serving", p.name)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+
+	npdt "k8s.io/node-problem-detector/pkg/types"
+	"k8s.io/npd-ext/pkg/externalmonitor/types"
+)
+
+func TestNotReadyCause(t *testing.T) {
+	checkFailed := status.Error(codes.Internal, "backend broken")
+	probeFailed := func(code codes.Code) func(context.Context) (*healthpb.HealthCheckResponse, error) {
+		return func(context.Context) (*healthpb.HealthCheckResponse, error) {
+			return nil, status.Error(code, "probe failed")
+		}
+	}
+
+	testCases := []struct {
+		name string
+		// health is the health service answer, nil for a plugin without one
+		health       func(context.Context) (*healthpb.HealthCheckResponse, error)
+		probes       int
+		checkResults []error
+		channelDown  bool
+		wantReason   string
+	}{
+		{
+			name:         "channel up, serving, checks passing",
+			health:       healthStatus(healthpb.HealthCheckResponse_SERVING),
+			probes:       1,
+			checkResults: []error{nil},
+		},
+		{
+			name:         "channel up, no health service, checks passing",
+			probes:       1,
+			checkResults: []error{nil},
+		},
+		{
+			name:       "channel up, health service not serving",
+			health:     healthStatus(healthpb.HealthCheckResponse_NOT_SERVING),
+			probes:     1,
+			wantReason: "PluginNotServing",
+		},
+		{
+			name:         "channel up, no health service, checks failing",
+			checkResults: []error{checkFailed, checkFailed},
+			wantReason:   "PluginChecksFailing",
+		},
+		{
+			name:         "channel up, serving, checks failing",
+			health:       healthStatus(healthpb.HealthCheckResponse_SERVING),
+			probes:       1,
+			checkResults: []error{checkFailed, checkFailed},
+			wantReason:   "PluginChecksFailing",
+		},
+		{
+			name:         "channel up, checks failing below the threshold",
+			checkResults: []error{checkFailed, nil, checkFailed},
+		},
+		{
+			name:   "channel up, one probe timing out",
+			health: probeFailed(codes.DeadlineExceeded),
+			probes: 1,
+		},
+		{
+			name:   "channel up, one probe unavailable",
+			health: probeFailed(codes.Unavailable),
+			probes: 1,
+		},
+		{
+			name:       "channel up, probes failing up to the threshold",
+			health:     probeFailed(codes.DeadlineExceeded),
+			probes:     2,
+			wantReason: "PluginNotServing",
+		},
+		{
+			name:        "channel down",
+			channelDown: true,
+			wantReason:  "PluginUnreachable",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var health *fakeHealth
+			if tc.health != nil {
+				health = &fakeHealth{check: tc.health}
+			}
+			server := startFakePlugin(t, &fakePlugin{}, health)
+			p := connectedTestProxy(t, server.socket, func(config *types.ExternalMonitorConfig) {
+				config.PluginConfig.HealthCheck.ErrorThreshold = 2
+			})
+
+			for i := 0; i < tc.probes; i++ {
+				p.probeHealthService()
+			}
+			for _, err := range tc.checkResults {
+				p.observeCheckResult(err)
+			}
+			if tc.channelDown {
+				// The channel goes idle when the server stops, the proxy
+				// notices once a call fails
+				server.stop()
+				p.checkHealth(nil)
+			}
+
+			reason, _ := p.notReadyCause()
+			if reason != tc.wantReason {
+				t.Errorf("notReadyCause() = %q, want %q", reason, tc.wantReason)
+			}
+			if ready := p.isPluginReady(); ready != (tc.wantReason == "") {
+				t.Errorf("isPluginReady() = %v, want %v", ready, tc.wantReason == "")
+			}
+		})
+	}
+}
+
+func TestProbeHealthServiceRecovers(t *testing.T) {
+	health := &fakeHealth{check: healthStatus(healthpb.HealthCheckResponse_NOT_SERVING)}
+	server := startFakePlugin(t, &fakePlugin{}, health)
+	p := connectedTestProxy(t, server.socket, nil)
+
+	p.probeHealthService()
+	if p.isPluginReady() {
+		t.Fatal("plugin reporting NOT_SERVING is ready")
+	}
+
+	health.set(healthStatus(healthpb.HealthCheckResponse_SERVING))
+	p.probeHealthService()
+	if !p.isPluginReady() {
+		t.Error("plugin reporting SERVING again is not ready")
+	}
+}
+
+func TestCheckHealthSkipsPluginNotServing(t *testing.T) {
+	plugin := &fakePlugin{}
+	health := &fakeHealth{check: healthStatus(healthpb.HealthCheckResponse_NOT_SERVING)}
+	server := startFakePlugin(t, plugin, health)
+	p := connectedTestProxy(t, server.socket, nil)
+	p.probeHealthService()
+
+	p.checkHealth(nil)
+
+	if n := plugin.checks.Load(); n != 0 {
+		t.Errorf("CheckHealth called %d times on a plugin not serving", n)
+	}
+	select {
+	case s := <-p.statusChan:
+		if len(s.Conditions) != 1 || s.Conditions[0].Status != npdt.Unknown || s.Conditions[0].Reason != "PluginNotServing" {
+			t.Errorf("got conditions %+v, want Fake Unknown with reason PluginNotServing", s.Conditions)
+		}
+	default:
+		t.Error("no status reported for a plugin not serving")
+	}
+}
@@ -0,0 +1,50 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// preflight checks for setup problems that reconnecting cannot fix, so that
+// Start fails on them instead of retrying forever. A plugin that cannot be
+// reached yet is not one of them.
+func (p *ExternalMonitorProxy) preflight() error {
+	if p.shuttingDown.Load() {
+		return fmt.Errorf("external monitor %s was stopped and cannot be restarted", p.name)
+	}
+
+	if p.config.MetricsReporting && metricsErr != nil {
+		return fmt.Errorf("metrics for external monitor %s could not be created: %w", p.name, metricsErr)
+	}
+
+	if p.config.PluginConfig.PeerCredentials.Enabled() && !peerCredentialsSupported {
+		return fmt.Errorf("peer credential verification for external monitor %s is not supported on %s",
+			p.name, runtime.GOOS)
+	}
+
+	// All monitors are created before any is started
+	if err := p.checkDerivedSources(); err != nil {
+		return err
+	}
+
+	if !p.started.CompareAndSwap(false, true) {
+		return fmt.Errorf("external monitor %s is already started", p.name)
+	}
+	return nil
+}
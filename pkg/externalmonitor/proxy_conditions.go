@@ -0,0 +1,165 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	npdt "k8s.io/node-problem-detector/pkg/types"
+)
+
+const (
+	// PluginSelfTestFailedCondition is reported when the plugin fails its self-test.
+	PluginSelfTestFailedCondition = "PluginSelfTestFailed"
+
+	// PluginMalformedStatusCondition is reported when the plugin keeps
+	// returning statuses that cannot be converted.
+	PluginMalformedStatusCondition = "PluginMalformedStatus"
+
+	// PluginMetadataUnavailableCondition is reported when the plugin is
+	// reachable but keeps failing GetMetadata, so capabilities are unknown.
+	PluginMetadataUnavailableCondition = "PluginMetadataUnavailable"
+
+	// ExternalMonitorVersionCondition is an informational condition carrying
+	// the plugin version. It is always False.
+	ExternalMonitorVersionCondition = "ExternalMonitorVersion"
+)
+
+// setProxyCondition updates a condition generated by the proxy itself, rather
+// than reported by the plugin, and sends it when it changes. A proxy condition
+// that has never been a problem is not reported.
+func (p *ExternalMonitorProxy) setProxyCondition(conditionType string, problem bool, reason, message string) {
+	p.proxyConditionsMutex.Lock()
+	defer p.proxyConditionsMutex.Unlock()
+
+	conditionStatus := npdt.False
+	if problem {
+		conditionStatus = npdt.True
+	}
+
+	previous, ok := p.proxyConditions[conditionType]
+	if !ok && !problem {
+		return
+	}
+	if ok && previous.Status == conditionStatus && previous.Reason == reason && previous.Message == message {
+		return
+	}
+
+	condition := npdt.Condition{
+		Type:       conditionType,
+		Status:     conditionStatus,
+		Transition: time.Now(),
+		Reason:     reason,
+		Message:    message,
+	}
+	if ok && previous.Status == conditionStatus {
+		condition.Transition = previous.Transition
+	}
+	p.proxyConditions[conditionType] = condition
+	p.sendProxyCondition(condition)
+}
+
+// recordMalformedStatus counts a status that failed conversion and reports
+// PluginMalformedStatus once MalformedStatusThreshold is reached.
+func (p *ExternalMonitorProxy) recordMalformedStatus(err error) {
+	p.malformedStatuses++
+	if p.malformedStatuses < p.config.PluginConfig.MalformedStatusThreshold {
+		return
+	}
+
+	p.setProxyCondition(PluginMalformedStatusCondition, true, "MalformedStatus",
+		fmt.Sprintf("External monitor %s returned at least %d malformed statuses in a row: %v",
+			p.name, p.config.PluginConfig.MalformedStatusThreshold, err))
+}
+
+// recordWellFormedStatus resets the malformed status count after a successful conversion.
+func (p *ExternalMonitorProxy) recordWellFormedStatus() {
+	if p.malformedStatuses == 0 {
+		return
+	}
+
+	p.malformedStatuses = 0
+	p.setProxyCondition(PluginMalformedStatusCondition, false, "WellFormedStatus",
+		fmt.Sprintf("External monitor %s returns well-formed statuses", p.name))
+}
+
+// recordMetadataFailure counts a failed metadata fetch and reports
+// PluginMetadataUnavailable once MetadataFailureThreshold is reached. Must be
+// called with connectionMutex held.
+func (p *ExternalMonitorProxy) recordMetadataFailure(err error) {
+	p.metadataFailures++
+	if p.metadataFailures < p.config.PluginConfig.MetadataFailureThreshold {
+		return
+	}
+
+	p.setProxyCondition(PluginMetadataUnavailableCondition, true, "MetadataFetchFailed",
+		fmt.Sprintf("External monitor %s failed to return metadata at least %d times in a row: %v",
+			p.name, p.config.PluginConfig.MetadataFailureThreshold, errorMessage(err)))
+}
+
+// recordMetadataFetched resets the metadata failure count after a successful
+// fetch. Must be called with connectionMutex held.
+func (p *ExternalMonitorProxy) recordMetadataFetched() {
+	if p.metadataFailures == 0 {
+		return
+	}
+
+	p.metadataFailures = 0
+	p.setProxyCondition(PluginMetadataUnavailableCondition, false, "MetadataAvailable",
+		fmt.Sprintf("External monitor %s returns metadata", p.name))
+}
+
+// setVersionCondition reports the plugin version. A version change is
+// reported as a new transition.
+func (p *ExternalMonitorProxy) setVersionCondition(version string) {
+	p.proxyConditionsMutex.Lock()
+	defer p.proxyConditionsMutex.Unlock()
+
+	message := fmt.Sprintf("External monitor %s is running version %s", p.name, version)
+	if previous, ok := p.proxyConditions[ExternalMonitorVersionCondition]; ok && previous.Message == message {
+		return
+	}
+
+	condition := npdt.Condition{
+		Type:       ExternalMonitorVersionCondition,
+		Status:     npdt.False,
+		Transition: time.Now(),
+		Reason:     "PluginVersion",
+		Message:    message,
+	}
+	p.proxyConditions[ExternalMonitorVersionCondition] = condition
+	p.sendProxyCondition(condition)
+}
+
+// sendProxyCondition sends a proxy-generated condition. Proxy conditions are
+// also set from the health check loop, so they go through offerStatus. Must
+// be called with proxyConditionsMutex held.
+func (p *ExternalMonitorProxy) sendProxyCondition(condition npdt.Condition) {
+	status := &npdt.Status{
+		Source:     p.config.Source,
+		Conditions: []npdt.Condition{condition},
+	}
+
+	if p.offerStatus(status) {
+		p.logf(4, "Sent %s=%s from %s", condition.Type, condition.Status, p.name)
+	} else if !p.shuttingDown.Load() {
+		klog.Warningf("Status channel full for %s, dropping %s condition", p.name, condition.Type)
+	}
+}
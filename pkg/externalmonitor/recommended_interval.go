@@ -0,0 +1,77 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"time"
+
+	"k8s.io/klog/v2"
+
+	pb "k8s.io/npd-ext/api/services/external/v1"
+)
+
+// recommendedIntervalTolerance is how many times faster or slower than the
+// plugin's recommendation invoke_interval may be before a warning is logged.
+const recommendedIntervalTolerance = 2
+
+// applyRecommendedInterval compares invoke_interval with the interval
+// recommended in new metadata, and adopts the recommendation clamped to the
+// configured bounds under AdoptRecommendedInterval. A recommendation is only
+// logged when it changes.
+func (p *ExternalMonitorProxy) applyRecommendedInterval(previous, current *pb.MonitorMetadata) {
+	recommended := current.GetRecommendedInterval().AsDuration()
+	if current.RecommendedInterval == nil || recommended <= 0 {
+		// Go back to invoke_interval if a restarted plugin dropped its recommendation
+		if p.invokeInterval.Swap(0) != 0 {
+			klog.Infof("External monitor %s no longer recommends an interval, using %v",
+				p.name, p.config.PluginConfig.InvokeInterval)
+		}
+		return
+	}
+	if previous != nil && previous.RecommendedInterval != nil &&
+		previous.RecommendedInterval.AsDuration() == recommended {
+		return
+	}
+
+	config := p.config.PluginConfig
+	if config.AdoptRecommendedInterval {
+		interval := min(max(recommended, config.MinInvokeInterval), config.MaxInvokeInterval)
+		klog.Infof("External monitor %s recommends checking every %v, using %v", p.name, recommended, interval)
+		p.setInvokeInterval(interval)
+		return
+	}
+
+	configured := config.InvokeInterval
+	if configured*recommendedIntervalTolerance < recommended || configured > recommended*recommendedIntervalTolerance {
+		klog.Warningf("External monitor %s recommends checking every %v, but invoke_interval is %v",
+			p.name, recommended, configured)
+	}
+}
+
+// setInvokeInterval changes the interval of regular checks, taking effect
+// from the next check.
+func (p *ExternalMonitorProxy) setInvokeInterval(interval time.Duration) {
+	p.invokeInterval.Store(int64(interval))
+}
+
+// currentInvokeInterval returns the interval of regular checks.
+func (p *ExternalMonitorProxy) currentInvokeInterval() time.Duration {
+	if interval := p.invokeInterval.Load(); interval > 0 {
+		return time.Duration(interval)
+	}
+	return p.config.PluginConfig.InvokeInterval
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"k8s.io/npd-ext/pkg/externalmonitor/types"
+)
+
+// ReconnectLimiter gates reconnection attempts after their backoff delay. A
+// limiter shared by the nodes connecting to a replicated plugin backend, e.g.
+// one backed by a token service, keeps the fleet from overwhelming the backend
+// when it restarts and every node reconnects at once.
+type ReconnectLimiter interface {
+	// Wait blocks until a reconnection attempt may proceed, or returns an
+	// error when the attempt should be skipped, e.g. because ctx is done.
+	Wait(ctx context.Context) error
+}
+
+// NewReconnectLimiter creates the limiter selected by the retry policy: a
+// token bucket when a reconnect rate is set, no limit otherwise.
+func NewReconnectLimiter(policy types.RetryPolicy) ReconnectLimiter {
+	if policy.ReconnectRate <= 0 {
+		return noopReconnectLimiter{}
+	}
+	return NewTokenBucketLimiter(policy.ReconnectRate, policy.ReconnectBurst)
+}
+
+// noopReconnectLimiter never delays reconnection attempts.
+type noopReconnectLimiter struct{}
+
+func (noopReconnectLimiter) Wait(ctx context.Context) error {
+	return nil
+}
+
+// NewTokenBucketLimiter returns a ReconnectLimiter allowing rate attempts per
+// second on average with bursts of up to burst attempts. It may be shared by
+// several monitors to limit their attempts together.
+func NewTokenBucketLimiter(rate float64, burst int) ReconnectLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucketLimiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// tokenBucketLimiter is a token bucket refilled at rate tokens per second.
+type tokenBucketLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func (l *tokenBucketLimiter) Wait(ctx context.Context) error {
+	for {
+		delay := l.reserve()
+		if delay == 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// reserve takes a token and returns zero if one is available, or the time
+// until the next token otherwise.
+func (l *tokenBucketLimiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0
+	}
+	return time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+}
+
+// SetReconnectLimiter replaces the reconnection limiter from the retry policy,
+// e.g. with one shared across monitors or backed by a remote token service.
+// Must be called before Start.
+func (p *ExternalMonitorProxy) SetReconnectLimiter(limiter ReconnectLimiter) {
+	p.reconnectLimiter = limiter
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"k8s.io/npd-ext/pkg/externalmonitor/types"
+)
+
+func TestTokenBucketLimiter(t *testing.T) {
+	limiter := NewTokenBucketLimiter(10, 2)
+
+	// The burst passes at once, the next attempt waits for a token
+	start := time.Now()
+	for i := 0; i < 2; i++ {
+		if err := limiter.Wait(context.Background()); err != nil {
+			t.Fatalf("Wait %d: %v", i, err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("burst took %v, want no wait", elapsed)
+	}
+	if err := limiter.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Errorf("attempt after the burst passed after %v, want about 100ms", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := limiter.Wait(ctx); err == nil {
+		t.Error("Wait with a cancelled context succeeded without a token")
+	}
+}
+
+func TestSharedReconnectLimiter(t *testing.T) {
+	limiter := NewTokenBucketLimiter(5, 1)
+	var proxies []*ExternalMonitorProxy
+	var servers []*fakeServer
+	for i := 0; i < 2; i++ {
+		server := startFakePlugin(t, &fakePlugin{}, nil)
+		p := connectedTestProxy(t, server.socket, func(config *types.ExternalMonitorConfig) {
+			config.PluginConfig.RetryPolicy.InitialBackoff = time.Millisecond
+		})
+		p.SetReconnectLimiter(limiter)
+		p.connectionMutex.Lock()
+		p.connected = false
+		p.connectionMutex.Unlock()
+		proxies = append(proxies, p)
+		servers = append(servers, server)
+	}
+
+	// The monitors share one token, so the second waits for the next
+	start := time.Now()
+	for _, p := range proxies {
+		p.attemptReconnection()
+	}
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("both monitors reconnected within %v, want the second to wait about 200ms", elapsed)
+	}
+	for i, server := range servers {
+		if n := server.accepted.Load(); n != 2 {
+			t.Errorf("plugin %d accepted %d connections, want 2", i, n)
+		}
+	}
+}
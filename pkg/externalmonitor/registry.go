@@ -0,0 +1,95 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"sort"
+	"sync"
+)
+
+// proxyRegistry tracks running external monitor proxies by source.
+type proxyRegistry struct {
+	mutex   sync.RWMutex
+	proxies map[string]*ExternalMonitorProxy
+
+	// Sources of all created proxies, started or not
+	declared map[string]bool
+}
+
+// registry contains all started external monitor proxies.
+var registry = &proxyRegistry{
+	proxies:  make(map[string]*ExternalMonitorProxy),
+	declared: make(map[string]bool),
+}
+
+// declare records the source of a created proxy.
+func (r *proxyRegistry) declare(source string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.declared[source] = true
+}
+
+// isDeclared returns true if a proxy was created for the source.
+func (r *proxyRegistry) isDeclared(source string) bool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.declared[source]
+}
+
+// register adds a proxy to the registry.
+func (r *proxyRegistry) register(p *ExternalMonitorProxy) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.proxies[p.name] = p
+}
+
+// unregister removes a proxy from the registry.
+func (r *proxyRegistry) unregister(p *ExternalMonitorProxy) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.proxies[p.name] == p {
+		delete(r.proxies, p.name)
+	}
+}
+
+// get returns the proxy registered for a source.
+func (r *proxyRegistry) get(source string) (*ExternalMonitorProxy, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	p, ok := r.proxies[source]
+	return p, ok
+}
+
+// list returns all registered proxies sorted by source.
+func (r *proxyRegistry) list() []*ExternalMonitorProxy {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	proxies := make([]*ExternalMonitorProxy, 0, len(r.proxies))
+	for _, p := range r.proxies {
+		proxies = append(proxies, p)
+	}
+	sort.Slice(proxies, func(i, j int) bool {
+		return proxies[i].name < proxies[j].name
+	})
+	return proxies
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// reconnectIfSocketReplaced reconnects when the active unix socket has been
+// recreated with a different inode, as a restarted plugin does, instead of
+// waiting for calls on the stale connection to fail.
+func (p *ExternalMonitorProxy) reconnectIfSocketReplaced() {
+	p.connectionMutex.RLock()
+	socket, inode := p.activeSocket, p.activeSocketInode
+	p.connectionMutex.RUnlock()
+
+	if inode == 0 {
+		return
+	}
+	current := unixSocketInode(socket)
+	if current == 0 || current == inode {
+		return
+	}
+
+	p.connectMutex.Lock()
+	defer p.connectMutex.Unlock()
+	p.connectionMutex.Lock()
+	defer p.connectionMutex.Unlock()
+
+	// Another goroutine may have reconnected meanwhile
+	if p.activeSocket != socket || p.activeSocketInode != inode {
+		return
+	}
+
+	klog.Infof("Socket %s of %s was recreated (inode %d, was %d), reconnecting", socket, p.name, current, inode)
+	p.metadataFetchedAt = time.Time{} // The restarted plugin may report new metadata
+
+	// Dial without the lock, see connectSocket
+	p.connectionMutex.Unlock()
+	err := p.connectSocket(socket)
+	p.connectionMutex.Lock()
+	if err != nil {
+		klog.Warningf("Reconnection to recreated socket %s of %s failed: %v", socket, p.name, err)
+		p.connected = false
+		return
+	}
+	p.reportReconnectedUnsafe(fmt.Sprintf("Reconnected to external monitor %s after its socket %s was recreated", p.name, socket))
+}
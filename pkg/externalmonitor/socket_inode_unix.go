@@ -0,0 +1,44 @@
+//go:build unix
+
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"os"
+	"strings"
+	"syscall"
+
+	"k8s.io/npd-ext/pkg/externalmonitor/types"
+)
+
+// unixSocketInode returns the inode of a unix socket, or 0 for network
+// addresses and sockets that cannot be stat'ed.
+func unixSocketInode(socket string) uint64 {
+	if types.IsNetworkAddress(socket) {
+		return 0
+	}
+	info, err := os.Stat(strings.TrimPrefix(socket, "unix://"))
+	if err != nil {
+		return 0
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0
+	}
+	return uint64(stat.Ino)
+}
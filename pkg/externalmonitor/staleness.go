@@ -0,0 +1,80 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"fmt"
+	"time"
+
+	npdt "k8s.io/node-problem-detector/pkg/types"
+)
+
+// staleReason is the reason of conditions the plugin stopped reporting.
+const staleReason = "ConditionStale"
+
+// applyStaleness counts the checks whose status omits a configured condition.
+// An omitted condition keeps its last value until it has been missed for its
+// staleAfterMissedChecks, and is reported as Unknown from then on. Conditions
+// outside a selective check are not counted as missed.
+func (p *ExternalMonitorProxy) applyStaleness(status *npdt.Status, checked []string) {
+	for _, condDef := range p.declaredConditions() {
+		limit := condDef.StaleAfterMissedChecks
+		if limit == 0 {
+			limit = p.config.PluginConfig.StaleAfterMissedChecks
+		}
+		if limit == 0 || !conditionSelected(checked, condDef.Type) {
+			continue
+		}
+
+		if _, ok := findCondition(status.Conditions, condDef.Type); ok {
+			delete(p.missedChecks, condDef.Type)
+			continue
+		}
+
+		p.missedChecks[condDef.Type]++
+		missed := p.missedChecks[condDef.Type]
+
+		var last npdt.Condition
+		haveLast := false
+		if p.lastStatus != nil {
+			last, haveLast = findCondition(p.lastStatus.Conditions, condDef.Type)
+		}
+
+		if missed < limit {
+			// Keep the last value, which a full check would otherwise drop
+			if haveLast {
+				status.Conditions = append(status.Conditions, last)
+			}
+			continue
+		}
+
+		// Keep the transition time while the condition stays stale
+		if haveLast && last.Reason == staleReason && last.Status == npdt.Unknown {
+			status.Conditions = append(status.Conditions, last)
+			continue
+		}
+
+		p.logf(4, "Condition %s of %s missing from %d consecutive statuses", condDef.Type, p.name, missed)
+		status.Conditions = append(status.Conditions, npdt.Condition{
+			Type:       condDef.Type,
+			Status:     npdt.Unknown,
+			Transition: time.Now(),
+			Reason:     staleReason,
+			Message:    fmt.Sprintf("%s was not reported by %s in the last %d checks", condDef.Type, p.name, missed),
+		})
+	}
+}
@@ -0,0 +1,202 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"k8s.io/klog/v2"
+
+	pb "k8s.io/npd-ext/api/services/external/v1"
+)
+
+// warmStandby is a pre-connected connection to a standby socket that takes
+// over instantly when the active connection is lost.
+type warmStandby struct {
+	mutex    sync.Mutex
+	socket   string
+	conn     *grpc.ClientConn
+	client   pb.ExternalMonitorClient
+	metadata *pb.MonitorMetadata
+}
+
+// maintainStandby drops a standby connection that failed and dials a new one
+// to the first available socket other than the active one. Only the
+// connectivity state is inspected, so keeping the standby costs no RPCs.
+func (p *ExternalMonitorProxy) maintainStandby() {
+	p.standby.mutex.Lock()
+	if p.standby.conn != nil {
+		state := p.standby.conn.GetState()
+		if state == connectivity.Ready || state == connectivity.Idle {
+			p.standby.mutex.Unlock()
+			return
+		}
+		klog.Warningf("Standby socket %s of %s is %s, redialing", p.standby.socket, p.name, state)
+		p.standby.closeUnsafe()
+	}
+	p.standby.mutex.Unlock()
+
+	p.connectionMutex.RLock()
+	active := p.activeSocket
+	p.connectionMutex.RUnlock()
+	if p.shuttingDown.Load() {
+		return
+	}
+
+	socket := ""
+	for _, candidate := range p.config.PluginConfig.Sockets() {
+		if candidate == active {
+			continue
+		}
+		if socketAvailable(candidate) == nil {
+			socket = candidate
+			break
+		}
+	}
+	if socket == "" {
+		p.logf(4, "No standby socket available for %s", p.name)
+		return
+	}
+
+	// Dial and fetch metadata without holding any lock
+	conn, err := p.dial(socket)
+	if err != nil {
+		p.logf(4, "Failed to connect standby socket %s of %s: %v", socket, p.name, err)
+		return
+	}
+	client := pb.NewExternalMonitorClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.config.PluginConfig.Timeout)
+	metadata, err := client.GetMetadata(ctx, &emptypb.Empty{})
+	cancel()
+	if err != nil {
+		klog.Warningf("Failed to fetch metadata from standby socket %s of %s: %v", socket, p.name, err)
+		conn.Close()
+		return
+	}
+
+	p.standby.mutex.Lock()
+	defer p.standby.mutex.Unlock()
+
+	if p.standby.conn != nil {
+		conn.Close()
+		return
+	}
+	p.standby.socket = socket
+	p.standby.conn = conn
+	p.standby.client = client
+	p.standby.metadata = metadata
+	klog.Infof("Standby socket %s of %s connected", socket, p.name)
+}
+
+// promoteStandbyUnsafe swaps a ready standby connection in as the active
+// connection. Returns false if no standby is ready. Must be called with
+// connectionMutex held.
+func (p *ExternalMonitorProxy) promoteStandbyUnsafe() bool {
+	if p.shuttingDown.Load() {
+		return false
+	}
+
+	p.standby.mutex.Lock()
+	defer p.standby.mutex.Unlock()
+
+	if p.standby.conn == nil {
+		return false
+	}
+	state := p.standby.conn.GetState()
+	if state != connectivity.Ready && state != connectivity.Idle {
+		return false
+	}
+
+	parameters, err := p.coerceParameters(p.standby.metadata.Parameters)
+	if err != nil {
+		klog.Warningf("Not promoting standby socket %s of %s: %v", p.standby.socket, p.name, err)
+		p.setParametersRejected("InvalidParameterType", err)
+		p.standby.closeUnsafe()
+		return false
+	}
+	parameters = p.withoutDefaultParameters(parameters, p.standby.metadata.DefaultParameters)
+	if parameters, err = p.encryptParameters(parameters, p.standby.metadata.ParameterEncryptionKey); err != nil {
+		klog.Warningf("Not promoting standby socket %s of %s: %v", p.standby.socket, p.name, err)
+		p.setParametersRejected("InvalidEncryptionKey", err)
+		p.standby.closeUnsafe()
+		return false
+	}
+
+	if p.conn != nil {
+		p.conn.Close()
+	}
+	p.conn = p.standby.conn
+	p.client = p.standby.client
+	p.connected = true
+	p.backoffAttempt = 0
+	p.backoff.Reset()
+	p.errorCount.Store(0)
+	p.setActiveSocket(p.standby.socket)
+	p.metadata = p.standby.metadata
+	p.setDeviceCount(p.standby.metadata.DeviceCount)
+	p.metadataFetchedAt = time.Now()
+	p.metadataSocket = p.standby.socket
+	p.parameters = parameters
+
+	p.standby.socket = ""
+	p.standby.conn = nil
+	p.standby.client = nil
+	p.standby.metadata = nil
+
+	klog.Infof("Promoted standby connection of %s (socket: %s)", p.name, p.activeSocket)
+	return true
+}
+
+// promoteStandby swaps in the standby connection if the active one is lost.
+func (p *ExternalMonitorProxy) promoteStandby() bool {
+	if !p.config.PluginConfig.WarmStandby {
+		return false
+	}
+
+	p.connectionMutex.Lock()
+	defer p.connectionMutex.Unlock()
+
+	if p.connectedUnsafe() {
+		return true
+	}
+	return p.promoteStandbyUnsafe()
+}
+
+// close closes the standby connection.
+func (s *warmStandby) close() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.closeUnsafe()
+}
+
+// closeUnsafe closes the standby connection. Must be called with mutex held.
+func (s *warmStandby) closeUnsafe() {
+	if s.conn != nil {
+		s.conn.Close()
+	}
+	s.socket = ""
+	s.conn = nil
+	s.client = nil
+	s.metadata = nil
+}
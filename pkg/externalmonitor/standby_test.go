@@ -0,0 +1,70 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/npd-ext/pkg/externalmonitor/types"
+)
+
+func TestWarmStandbyPromotion(t *testing.T) {
+	primaryPlugin, standbyPlugin := &fakePlugin{}, &fakePlugin{}
+	primary := startFakePlugin(t, primaryPlugin, nil)
+	standby := startFakePlugin(t, standbyPlugin, nil)
+	p := connectedTestProxy(t, "", func(config *types.ExternalMonitorConfig) {
+		config.PluginConfig.SocketAddresses = []string{primary.socket, standby.socket}
+		config.PluginConfig.WarmStandby = true
+	})
+	t.Cleanup(p.standby.close)
+
+	p.maintainStandby()
+	p.standby.mutex.Lock()
+	standbySocket := p.standby.socket
+	p.standby.mutex.Unlock()
+	if standbySocket != standby.socket {
+		t.Fatalf("standby socket %q, want %s", standbySocket, standby.socket)
+	}
+
+	// Losing the primary promotes the standby without dialing or fetching
+	// metadata again
+	primary.stop()
+	waitFor(t, 5*time.Second, "a check on the standby", func() bool {
+		p.checkHealth(nil)
+		return standbyPlugin.checks.Load() > 0
+	})
+
+	p.connectionMutex.RLock()
+	active, metadataSocket := p.activeSocket, p.metadataSocket
+	p.connectionMutex.RUnlock()
+	if active != standby.socket || metadataSocket != standby.socket {
+		t.Errorf("active socket %s with metadata of %s, want both %s", active, metadataSocket, standby.socket)
+	}
+	if n := standby.accepted.Load(); n != 1 {
+		t.Errorf("standby accepted %d connections, want only the warm one", n)
+	}
+	if n := standbyPlugin.metadataCalls.Load(); n != 1 {
+		t.Errorf("standby GetMetadata called %d times, want only by the warm connection", n)
+	}
+	p.standby.mutex.Lock()
+	promoted := p.standby.conn == nil
+	p.standby.mutex.Unlock()
+	if !promoted {
+		t.Error("standby connection kept after its promotion")
+	}
+}
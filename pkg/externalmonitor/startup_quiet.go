@@ -0,0 +1,67 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"fmt"
+	"time"
+
+	npdt "k8s.io/node-problem-detector/pkg/types"
+)
+
+// startupQuietReason is the reason of conditions masked during the startup
+// quiet period.
+const startupQuietReason = "StartupQuietPeriod"
+
+// inStartupQuietPeriod returns true while the startup quiet period lasts.
+func (p *ExternalMonitorProxy) inStartupQuietPeriod() bool {
+	period := p.config.PluginConfig.StartupQuietPeriod
+	return period > 0 && time.Since(p.startedAt) < period
+}
+
+// applyStartupQuietPeriod reports True conditions as Unknown during the
+// startup quiet period. Events are left untouched.
+func (p *ExternalMonitorProxy) applyStartupQuietPeriod(status *npdt.Status) {
+	if !p.inStartupQuietPeriod() {
+		return
+	}
+
+	remaining := (p.config.PluginConfig.StartupQuietPeriod - time.Since(p.startedAt)).Round(time.Second)
+	for i, condition := range status.Conditions {
+		if condition.Status != npdt.True {
+			continue
+		}
+
+		// Keep the transition time while the quiet period continues
+		if p.lastStatus != nil {
+			if committed, ok := findCondition(p.lastStatus.Conditions, condition.Type); ok &&
+				committed.Reason == startupQuietReason && committed.Status == npdt.Unknown {
+				status.Conditions[i] = committed
+				continue
+			}
+		}
+
+		p.logf(4, "Masking %s from %s for another %v of the startup quiet period", condition.Type, p.name, remaining)
+		status.Conditions[i] = npdt.Condition{
+			Type:       condition.Type,
+			Status:     npdt.Unknown,
+			Transition: time.Now(),
+			Reason:     startupQuietReason,
+			Message:    fmt.Sprintf("%s is not reported during the startup quiet period: %s", condition.Type, condition.Message),
+		}
+	}
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"k8s.io/klog/v2"
+	npdt "k8s.io/node-problem-detector/pkg/types"
+)
+
+// persistedStatus is the on-disk form of the last status.
+type persistedStatus struct {
+	SavedAt time.Time   `json:"savedAt"`
+	Status  npdt.Status `json:"status"`
+}
+
+// persistStatus writes the conditions of a status to the status file. Events
+// are not persisted since they are stale after a restart.
+func (p *ExternalMonitorProxy) persistStatus(status *npdt.Status) {
+	path := p.config.PluginConfig.StatusFile
+	if path == "" {
+		return
+	}
+
+	data, err := json.Marshal(persistedStatus{
+		SavedAt: time.Now(),
+		Status: npdt.Status{
+			Source:     status.Source,
+			Conditions: status.Conditions,
+		},
+	})
+	if err == nil {
+		err = writeFileAtomic(path, data)
+	}
+	if err != nil {
+		klog.Warningf("Failed to persist status for %s to %s: %v", p.name, path, err)
+	}
+}
+
+// restoreStatus loads the persisted status, discarding it when it is older
+// than StatusFileMaxAge or belongs to another source.
+func (p *ExternalMonitorProxy) restoreStatus() *npdt.Status {
+	path := p.config.PluginConfig.StatusFile
+	if path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		klog.Warningf("Failed to read persisted status for %s from %s: %v", p.name, path, err)
+		return nil
+	}
+
+	var persisted persistedStatus
+	if err := json.Unmarshal(data, &persisted); err != nil {
+		klog.Warningf("Failed to parse persisted status for %s from %s: %v", p.name, path, err)
+		return nil
+	}
+	if persisted.Status.Source != p.config.Source {
+		klog.Warningf("Ignoring persisted status in %s from source %q, expected %q",
+			path, persisted.Status.Source, p.config.Source)
+		return nil
+	}
+	if age := time.Since(persisted.SavedAt); age > p.config.PluginConfig.StatusFileMaxAge {
+		klog.Infof("Discarding persisted status for %s, saved %v ago", p.name, age.Round(time.Second))
+		return nil
+	}
+
+	klog.Infof("Restored %d conditions for %s from %s", len(persisted.Status.Conditions), p.name, path)
+	return &persisted.Status
+}
+
+// writeFileAtomic writes data to a temporary file and renames it over path.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace status file: %v", err)
+	}
+	return nil
+}
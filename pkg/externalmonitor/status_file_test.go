@@ -0,0 +1,129 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalmonitor
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	npdt "k8s.io/node-problem-detector/pkg/types"
+
+	pb "k8s.io/npd-ext/api/services/external/v1"
+	"k8s.io/npd-ext/pkg/externalmonitor/types"
+)
+
+func TestStatusFileRestoredOnRestart(t *testing.T) {
+	plugin := &fakePlugin{checkHealth: func(context.Context, *pb.HealthCheckRequest) (*pb.Status, error) {
+		status := healthyStatus("fake")
+		status.Conditions[0].Status = pb.ConditionStatus_CONDITION_STATUS_TRUE
+		status.Conditions[0].Reason = "FakeBroken"
+		status.Events = []*pb.Event{{Severity: pb.Severity_SEVERITY_WARN, Reason: "FakeHiccup", Message: "hiccup"}}
+		return status, nil
+	}}
+	server := startFakePlugin(t, plugin, nil)
+	statusFile := filepath.Join(t.TempDir(), "status.json")
+	configure := func(config *types.ExternalMonitorConfig) {
+		config.PluginConfig.StatusFile = statusFile
+	}
+
+	p := connectedTestProxy(t, server.socket, configure)
+	p.checkHealth(nil)
+
+	// A restarted monitor starts from the persisted conditions instead of
+	// the configured initial status
+	restarted := newTestProxy(t, testConfig(t, server.socket, configure))
+	restarted.sendInitialStatus()
+	select {
+	case status := <-restarted.statusChan:
+		if len(status.Conditions) != 1 || status.Conditions[0].Reason != "FakeBroken" || status.Conditions[0].Status != npdt.True {
+			t.Errorf("initial conditions %+v, want the persisted FakeBroken", status.Conditions)
+		}
+		if len(status.Events) != 0 {
+			t.Errorf("restored events %+v, want none", status.Events)
+		}
+	default:
+		t.Fatal("no initial status sent")
+	}
+}
+
+func TestRestoreStatusDiscards(t *testing.T) {
+	persisted := func(source string, savedAt time.Time) string {
+		data, err := json.Marshal(persistedStatus{
+			SavedAt: savedAt,
+			Status: npdt.Status{
+				Source:     source,
+				Conditions: []npdt.Condition{{Type: "Fake", Status: npdt.True, Reason: "FakeBroken"}},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	testCases := []struct {
+		name string
+		// content of the status file, none if empty
+		content     string
+		wantRestore bool
+	}{
+		{
+			name:        "fresh",
+			content:     persisted("fake", time.Now().Add(-time.Minute)),
+			wantRestore: true,
+		},
+		{
+			name: "missing",
+		},
+		{
+			name:    "corrupt",
+			content: `{"savedAt": `,
+		},
+		{
+			name:    "other source",
+			content: persisted("other", time.Now().Add(-time.Minute)),
+		},
+		{
+			name:    "too old",
+			content: persisted("fake", time.Now().Add(-2*time.Hour)),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			statusFile := filepath.Join(t.TempDir(), "status.json")
+			if tc.content != "" {
+				if err := os.WriteFile(statusFile, []byte(tc.content), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			p := newTestProxy(t, testConfig(t, "/run/fake.sock", func(config *types.ExternalMonitorConfig) {
+				config.PluginConfig.StatusFile = statusFile
+				config.PluginConfig.StatusFileMaxAge = time.Hour
+			}))
+
+			status := p.restoreStatus()
+			if restored := status != nil; restored != tc.wantRestore {
+				t.Errorf("restored = %v, want %v", restored, tc.wantRestore)
+			}
+		})
+	}
+}
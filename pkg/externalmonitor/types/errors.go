@@ -0,0 +1,87 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ConfigReadError is returned when the configuration source cannot be read.
+type ConfigReadError struct {
+	// Path is the configuration path that was read.
+	Path string
+	Err  error
+}
+
+func (e *ConfigReadError) Error() string {
+	return fmt.Sprintf("failed to read config file %s: %v", e.Path, e.Err)
+}
+
+func (e *ConfigReadError) Unwrap() error {
+	return e.Err
+}
+
+// ConfigParseError is returned when the configuration is not valid JSON for
+// ExternalMonitorConfig.
+type ConfigParseError struct {
+	Err error
+}
+
+func (e *ConfigParseError) Error() string {
+	return fmt.Sprintf("failed to parse configuration: %v", e.Err)
+}
+
+func (e *ConfigParseError) Unwrap() error {
+	return e.Err
+}
+
+// ConfigUnknownFieldError is returned when the configuration sets keys that
+// match no configuration field, typically because they are misspelled.
+type ConfigUnknownFieldError struct {
+	// Path is the configuration path that sets the keys.
+	Path string
+
+	// Fields are the JSON paths of the unknown keys, e.g. "pluginConfig.timout".
+	Fields []string
+}
+
+func (e *ConfigUnknownFieldError) Error() string {
+	return fmt.Sprintf("unknown configuration fields in %s: %s, check their spelling or remove them",
+		e.Path, strings.Join(e.Fields, ", "))
+}
+
+// ConfigValidationError is returned when a configuration value is invalid.
+type ConfigValidationError struct {
+	// Field is the JSON path of the invalid field, e.g. "retryPolicy.maxAttempts".
+	Field string
+
+	// Message describes the problem.
+	Message string
+}
+
+func (e *ConfigValidationError) Error() string {
+	return e.Message
+}
+
+// validationErrorf returns a ConfigValidationError for field with a formatted message.
+func validationErrorf(field, format string, args ...interface{}) error {
+	return &ConfigValidationError{
+		Field:   field,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
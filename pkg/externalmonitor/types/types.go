@@ -18,7 +18,27 @@ package types
 
 import (
 	"fmt"
+	"strings"
 	"time"
+
+	"google.golang.org/grpc/codes"
+)
+
+// knownOperations are the plugin RPCs that configuration may refer to.
+var knownOperations = map[string]bool{
+	"CheckHealth": true,
+	"GetMetadata": true,
+	"SelfTest":    true,
+	"Stop":        true,
+}
+
+const (
+	// minHealthCheckInterval is the smallest allowed health check interval.
+	minHealthCheckInterval = time.Second
+
+	// maxInvokeToHealthCheckRatio is how many health checks may run per invoke
+	// interval before the configuration is considered out of proportion.
+	maxInvokeToHealthCheckRatio = 30
 )
 
 // ExternalMonitorConfig contains configuration for external monitor plugins.
@@ -35,32 +55,394 @@ type ExternalMonitorConfig struct {
 	// MetricsReporting enables metrics reporting for this monitor.
 	MetricsReporting bool `json:"metricsReporting,omitempty"`
 
+	// MetricLabels bounds the condition label values of per-condition metrics.
+	MetricLabels MetricLabelsConfig `json:"metricLabels,omitempty"`
+
 	// Conditions define the possible conditions this monitor can report.
 	Conditions []ConditionDefinition `json:"conditions,omitempty"`
+
+	// LivenessCritical makes OverallHealth unhealthy when this monitor gives up
+	// reconnecting. Defaults to true.
+	LivenessCritical *bool `json:"livenessCritical,omitempty"`
+
+	// ReportVersionCondition reports an informational ExternalMonitorVersion
+	// condition carrying the plugin version.
+	ReportVersionCondition bool `json:"reportVersionCondition,omitempty"`
+
+	// ConditionNameMap renames condition types reported by the plugin to the
+	// names reported to NPD. Unmapped types pass through unchanged.
+	ConditionNameMap map[string]string `json:"conditionNameMap,omitempty"`
+
+	// LogLevel overrides the global klog verbosity for this monitor's logs.
+	// When unset, the global verbosity applies.
+	LogLevel *int `json:"logLevel,omitempty"`
+
+	// ConditionAggregation combines conditions of the same type reported by
+	// every monitor that sets an aggregation rule. The rollup is emitted once,
+	// by the first of those monitors by source that reports the type; the
+	// others leave the type out of their statuses. Empty disables aggregation.
+	ConditionAggregation string `json:"conditionAggregation,omitempty"`
+
+	// DerivedConditions are extra conditions computed from the conditions
+	// reported by this and other external monitors.
+	DerivedConditions []DerivedConditionConfig `json:"derivedConditions,omitempty"`
+}
+
+// MetricLabelsConfig bounds the condition label values of per-condition
+// metrics, so a plugin reporting many condition types, e.g. with a PID in the
+// type, can't explode metric cardinality. Per-device conditions match the
+// lists by their definition type, e.g. "GPUHealthy" for "GPUHealthy[0]".
+type MetricLabelsConfig struct {
+	// AllowConditions, if not empty, lists the only condition types recorded
+	// under their own label value.
+	AllowConditions []string `json:"allowConditions,omitempty"`
+
+	// DenyConditions lists condition types never recorded under their own
+	// label value.
+	DenyConditions []string `json:"denyConditions,omitempty"`
+
+	// MaxConditionValues caps the distinct condition label values recorded
+	// for the monitor. Defaults to 50.
+	MaxConditionValues int `json:"maxConditionValues,omitempty"`
+
+	// Overflow decides what happens to condition types that are not allowed,
+	// denied or beyond MaxConditionValues: "drop" (the default) doesn't
+	// record them, "bucket" records them under the label value "other".
+	Overflow string `json:"overflow,omitempty"`
+}
+
+const (
+	// MetricLabelOverflowDrop doesn't record overflowing condition types.
+	MetricLabelOverflowDrop = "drop"
+
+	// MetricLabelOverflowBucket records overflowing condition types under
+	// the label value "other".
+	MetricLabelOverflowBucket = "bucket"
+)
+
+// DerivedConditionConfig defines a condition that combines the conditions
+// reported by one or more external monitors.
+type DerivedConditionConfig struct {
+	// Type of the derived condition.
+	Type string `json:"type"`
+
+	// Operator is "or" for True when any input is True, or "and" for True
+	// when every input is True.
+	Operator string `json:"operator"`
+
+	// Inputs are the conditions combined.
+	Inputs []DerivedConditionInput `json:"inputs"`
+
+	// Reason and Message of the derived condition while it is True.
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+
+	// DebouncePeriod commits a change of the derived status only once the
+	// inputs have combined to the new status for this long, so an input
+	// briefly Unknown while its monitor reconnects doesn't flip the derived
+	// condition. Zero commits changes immediately.
+	DebouncePeriod time.Duration `json:"debouncePeriod,omitempty"`
+}
+
+// DerivedConditionInput identifies a condition reported by an external monitor.
+type DerivedConditionInput struct {
+	// Source of the external monitor reporting the condition.
+	Source string `json:"source"`
+
+	// Type of the condition.
+	Type string `json:"type"`
 }
 
 // ExternalPluginConfig contains external plugin specific settings.
 type ExternalPluginConfig struct {
-	// SocketAddress is the Unix socket address for gRPC communication.
+	// SocketAddress is the Unix socket address for gRPC communication. A
+	// "host:port" or "dns:///host:port" address dials a network target
+	// instead, resolved through DNS with calls balanced round robin across
+	// the resolved endpoints, e.g. a Kubernetes Service. Network connections
+	// are not encrypted.
 	SocketAddress string `json:"socketAddress"`
 
+	// SocketAddresses lists Unix socket addresses in order of preference.
+	// The first reachable socket is used, failing over to the next one on
+	// disconnect. Mutually exclusive with SocketAddress.
+	SocketAddresses []string `json:"socketAddresses,omitempty"`
+
+	// LoadBalance is "failover" (the default) to use one socket at a time, or
+	// "weighted-random" to spread CheckHealth calls across all healthy
+	// sockets in proportion to SocketWeights.
+	LoadBalance string `json:"loadBalance,omitempty"`
+
+	// SocketWeights maps socket addresses to their weight under
+	// weighted-random load balancing. Unlisted sockets have weight 1.
+	SocketWeights map[string]int `json:"socketWeights,omitempty"`
+
+	// WarmStandby keeps a second connection to the next available socket
+	// dialed, with its metadata fetched, so losing the active connection
+	// fails over without reconnecting. Requires socketAddresses with at least
+	// two sockets and the failover load balance policy.
+	WarmStandby bool `json:"warmStandby,omitempty"`
+
 	// InvokeInterval is how often to call CheckHealth.
 	InvokeInterval time.Duration `json:"invoke_interval"`
 
+	// AdoptRecommendedInterval calls CheckHealth at the interval recommended
+	// in the plugin metadata instead of InvokeInterval, clamped to
+	// MinInvokeInterval and MaxInvokeInterval. Without it a recommendation
+	// far from InvokeInterval is only logged.
+	AdoptRecommendedInterval bool `json:"adoptRecommendedInterval,omitempty"`
+
+	// MinInvokeInterval and MaxInvokeInterval bound an adopted recommended
+	// interval. They default to half and twice InvokeInterval.
+	MinInvokeInterval time.Duration `json:"minInvokeInterval,omitempty"`
+	MaxInvokeInterval time.Duration `json:"maxInvokeInterval,omitempty"`
+
 	// Timeout for each gRPC call.
 	Timeout time.Duration `json:"timeout"`
 
+	// TimeoutPolicy decides whether timeout may reach invoke_interval. With
+	// "reject" (the default) such configurations are invalid. With "allow" they
+	// are accepted, and a check that is due while the previous one is still
+	// running is skipped.
+	TimeoutPolicy string `json:"timeoutPolicy,omitempty"`
+
+	// MaxTimeout bounds the per-condition and per-call (TimeoutParameter)
+	// timeout overrides. Overrides above it are clamped. Defaults to timeout, so it must be raised for overrides
+	// to extend a call.
+	MaxTimeout time.Duration `json:"maxTimeout,omitempty"`
+
+	// DialTimeout bounds how long establishing a connection may take.
+	DialTimeout time.Duration `json:"dialTimeout,omitempty"`
+
+	// IdleTimeout closes the connection after each check when invoke_interval
+	// is at least this long, and dials again just before the next check, so a
+	// plugin polled rarely doesn't hold a connection open in between. Zero
+	// keeps the connection open. Cannot be combined with warmStandby or
+	// weighted-random load balancing.
+	IdleTimeout time.Duration `json:"idleTimeout,omitempty"`
+
 	// SkipInitialStatus skips sending initial status.
 	SkipInitialStatus bool `json:"skip_initial_status,omitempty"`
 
+	// InitialStatusDelay waits up to this long for a real status before
+	// sending the initial status from configuration. Zero sends it immediately.
+	InitialStatusDelay time.Duration `json:"initialStatusDelay,omitempty"`
+
+	// ClampTransitions corrects condition transition times stamped by a
+	// plugin whose clock is skewed from the node's: transitions more than
+	// TransitionSkewTolerance in the future, or before the condition's
+	// previous transition, are replaced with the proxy's time.
+	ClampTransitions bool `json:"clampTransitions,omitempty"`
+
+	// TransitionSkewTolerance is how far in the future a transition may be
+	// before ClampTransitions clamps it. Defaults to 5 seconds.
+	TransitionSkewTolerance time.Duration `json:"transitionSkewTolerance,omitempty"`
+
+	// StartupQuietPeriod reports problem conditions as Unknown instead of
+	// True for this long after the monitor starts, while hardware settles.
+	// Events are still reported. Zero disables the quiet period.
+	StartupQuietPeriod time.Duration `json:"startupQuietPeriod,omitempty"`
+
+	// StatusFile is where the last status is persisted so that conditions
+	// survive NPD restarts. Empty disables persistence.
+	StatusFile string `json:"statusFile,omitempty"`
+
+	// StatusFileMaxAge is how old a persisted status may be and still be
+	// restored on start. Defaults to 10 minutes.
+	StatusFileMaxAge time.Duration `json:"statusFileMaxAge,omitempty"`
+
+	// ConditionHeartbeatInterval re-sends unchanged conditions at this interval.
+	// Zero disables heartbeats.
+	ConditionHeartbeatInterval time.Duration `json:"conditionHeartbeatInterval,omitempty"`
+
+	// SendEveryStatus sends the status of every check, even when nothing
+	// changed. Meant for diagnostics and consumers that deduplicate
+	// themselves, as it increases status channel traffic.
+	SendEveryStatus bool `json:"sendEveryStatus,omitempty"`
+
+	// StaleAfterMissedChecks reports a configured condition as Unknown once
+	// this many consecutive statuses omit it. Until then the omitted condition
+	// keeps its last value. Zero keeps the last value indefinitely. Conditions
+	// can override it.
+	StaleAfterMissedChecks int `json:"staleAfterMissedChecks,omitempty"`
+
+	// MaxSendBlock is how long to wait for room on a full status channel
+	// before dropping a status, on every path sending from the monitor loops.
+	// Zero drops immediately. Proxy events raised outside the loops, such as
+	// by a forced reconnection, are never waited for.
+	MaxSendBlock time.Duration `json:"maxSendBlock,omitempty"`
+
+	// MaxEventDetailsBytes bounds the size of event details appended to event messages.
+	MaxEventDetailsBytes int `json:"maxEventDetailsBytes,omitempty"`
+
+	// MaxEventsPerStatus caps the events forwarded from one status. Further
+	// events are replaced by a single EventsSuppressed event.
+	MaxEventsPerStatus int `json:"maxEventsPerStatus,omitempty"`
+
+	// MaxConditionsPerStatus caps the condition types accepted from one
+	// status. Conditions of further types are dropped.
+	MaxConditionsPerStatus int `json:"maxConditionsPerStatus,omitempty"`
+
+	// MaxMessageBytes bounds the message of each condition and event reported
+	// by the plugin. Longer messages are truncated.
+	MaxMessageBytes int `json:"maxMessageBytes,omitempty"`
+
+	// MinEventSeverity drops events below this severity: "info" (the
+	// default) keeps all events, "warn" only warnings. Conditions are not
+	// affected.
+	MinEventSeverity string `json:"minEventSeverity,omitempty"`
+
+	// MetadataMaxAge is how long fetched plugin metadata is trusted before it
+	// is refreshed.
+	MetadataMaxAge time.Duration `json:"metadataMaxAge,omitempty"`
+
+	// MetadataCacheTTL reuses metadata fetched within the TTL when reconnecting
+	// to the same socket instead of calling GetMetadata again. Zero fetches
+	// metadata on every connection.
+	MetadataCacheTTL time.Duration `json:"metadataCacheTTL,omitempty"`
+
+	// EventDedupWindow suppresses repeats of the same event within the window.
+	// Zero disables deduplication.
+	EventDedupWindow time.Duration `json:"eventDedupWindow,omitempty"`
+
+	// MalformedStatusThreshold is the number of consecutive statuses that fail
+	// conversion before the PluginMalformedStatus condition is reported.
+	MalformedStatusThreshold int `json:"malformedStatusThreshold,omitempty"`
+
+	// MetadataFailureThreshold is the number of consecutive failed metadata
+	// fetches before the PluginMetadataUnavailable condition is reported.
+	MetadataFailureThreshold int `json:"metadataFailureThreshold,omitempty"`
+
+	// DuplicateConditions decides which condition is kept when a status
+	// reports the same type more than once: "last" (the default) or "worst".
+	DuplicateConditions string `json:"duplicateConditions,omitempty"`
+
+	// DegradedLatencyFactor reports the PluginDegradedLatency condition when
+	// recent CheckHealth latency exceeds this multiple of its long-term
+	// average. Zero disables the condition.
+	DegradedLatencyFactor float64 `json:"degradedLatencyFactor,omitempty"`
+
 	// RetryPolicy defines reconnection behavior.
 	RetryPolicy RetryPolicy `json:"retryPolicy,omitempty"`
 
 	// HealthCheck defines health checking behavior.
 	HealthCheck HealthCheckConfig `json:"healthCheck,omitempty"`
 
+	// BenignErrorCodes lists, per operation (e.g. "CheckHealth"), gRPC status
+	// codes such as "FAILED_PRECONDITION" that are expected and should not
+	// count towards the error threshold.
+	BenignErrorCodes map[string][]codes.Code `json:"benignErrorCodes,omitempty"`
+
+	// Headers are gRPC metadata headers sent on every call, e.g. an auth token.
+	Headers map[string]string `json:"headers,omitempty"`
+
 	// PluginParameters are passed to the external plugin.
 	PluginParameters map[string]string `json:"pluginParameters,omitempty"`
+
+	// ParameterPrecedence decides whether PluginParameters or the plugin's own
+	// defaults win. With "config" (the default) every PluginParameter is sent
+	// and overrides the plugin. With "plugin" only parameters that differ from
+	// the defaults advertised in the plugin metadata are sent.
+	ParameterPrecedence string `json:"parameterPrecedence,omitempty"`
+
+	// SensitiveParameters names PluginParameters, e.g. credentials, that are
+	// only sent encrypted to the key the plugin advertises in its metadata.
+	// They are left out of requests to a plugin advertising no key.
+	SensitiveParameters []string `json:"sensitiveParameters,omitempty"`
+
+	// ReportParameterRejection reports the PluginParameterRejected condition
+	// while PluginParameters are rejected, by the plugin's parameter specs or
+	// by the plugin failing CheckHealth with InvalidArgument.
+	ReportParameterRejection bool `json:"reportParameterRejection,omitempty"`
+
+	// EmitInternalEvents sends connection lifecycle events, such as losing
+	// and regaining the plugin connection, as events of the monitor source so
+	// they show up next to plugin events. They are only logged otherwise.
+	EmitInternalEvents bool `json:"emitInternalEvents,omitempty"`
+
+	// OccurrenceEvents sends an event when a declared condition turns True
+	// after being False or not reported yet, carrying the condition reason
+	// and message, so every problem has an event even if the plugin sends none.
+	OccurrenceEvents bool `json:"occurrenceEvents,omitempty"`
+
+	// OccurrenceEventSeverity is the severity of occurrence events, "warn"
+	// (the default) or "info". Conditions can override it by reason.
+	OccurrenceEventSeverity string `json:"occurrenceEventSeverity,omitempty"`
+
+	// NodeConditions lists node condition types to include in each health
+	// check request. Empty disables sending node conditions.
+	NodeConditions []string `json:"nodeConditions,omitempty"`
+
+	// StrictSourceCheck fails the connection when the name in the plugin
+	// metadata differs from the configured source. Otherwise a warning is logged.
+	StrictSourceCheck bool `json:"strictSourceCheck,omitempty"`
+
+	// Maintenance suppresses conditions during maintenance windows.
+	Maintenance MaintenanceConfig `json:"maintenance,omitempty"`
+
+	// PeerCredentials restricts which processes may serve the plugin socket.
+	PeerCredentials PeerCredentialsConfig `json:"peerCredentials,omitempty"`
+}
+
+// MaintenanceConfig lists conditions reported as False while a maintenance
+// window is active. A window is active between its start and end, or while
+// maintenance is enabled through the debug endpoint.
+type MaintenanceConfig struct {
+	// Conditions lists the condition types to suppress.
+	Conditions []string `json:"conditions,omitempty"`
+
+	// Windows lists scheduled maintenance windows.
+	Windows []MaintenanceWindow `json:"windows,omitempty"`
+}
+
+// MaintenanceWindow is a scheduled maintenance period.
+type MaintenanceWindow struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// Active returns true if any window contains now.
+func (config MaintenanceConfig) Active(now time.Time) bool {
+	for _, window := range config.Windows {
+		if !now.Before(window.Start) && now.Before(window.End) {
+			return true
+		}
+	}
+	return false
+}
+
+// PeerCredentialsConfig defines an allowlist for the process serving the plugin socket.
+// When both lists are empty, the peer is not verified.
+type PeerCredentialsConfig struct {
+	// AllowedUIDs lists user IDs the peer process may run as.
+	AllowedUIDs []uint32 `json:"allowedUIDs,omitempty"`
+
+	// AllowedGIDs lists group IDs the peer process may run as.
+	AllowedGIDs []uint32 `json:"allowedGIDs,omitempty"`
+}
+
+// Enabled returns true if peer credential verification is configured.
+func (config PeerCredentialsConfig) Enabled() bool {
+	return len(config.AllowedUIDs) > 0 || len(config.AllowedGIDs) > 0
+}
+
+// Allows returns true if the given peer uid and gid match the allowlist.
+func (config PeerCredentialsConfig) Allows(uid, gid uint32) bool {
+	if len(config.AllowedUIDs) > 0 && !containsID(config.AllowedUIDs, uid) {
+		return false
+	}
+	if len(config.AllowedGIDs) > 0 && !containsID(config.AllowedGIDs, gid) {
+		return false
+	}
+	return true
+}
+
+func containsID(ids []uint32, id uint32) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
 }
 
 // RetryPolicy defines how to handle connection failures.
@@ -76,8 +458,95 @@ type RetryPolicy struct {
 
 	// InitialBackoff is the initial backoff duration.
 	InitialBackoff time.Duration `json:"initialBackoff,omitempty"`
+
+	// Strategy selects how backoff delays are computed. Defaults to "exponential".
+	Strategy string `json:"strategy,omitempty"`
+
+	// ReconnectRate limits reconnection attempts to this many per second on
+	// average, after the backoff delay. Zero disables the limit.
+	ReconnectRate float64 `json:"reconnectRate,omitempty"`
+
+	// ReconnectBurst is how many reconnection attempts may run back to back
+	// under ReconnectRate. Defaults to 1.
+	ReconnectBurst int `json:"reconnectBurst,omitempty"`
 }
 
+const (
+	// BackoffStrategyExponential multiplies the backoff by BackoffMultiplier on each attempt.
+	BackoffStrategyExponential = "exponential"
+
+	// BackoffStrategyConstant always waits InitialBackoff between attempts.
+	BackoffStrategyConstant = "constant"
+
+	// BackoffStrategyDecorrelatedJitter randomizes the backoff based on the previous delay.
+	BackoffStrategyDecorrelatedJitter = "decorrelated-jitter"
+)
+
+const (
+	// ConditionAggregationWorstWins reports the most severe status, preferring
+	// True over Unknown over False.
+	ConditionAggregationWorstWins = "worst-wins"
+
+	// ConditionAggregationLatestWins reports the most recently transitioned condition.
+	ConditionAggregationLatestWins = "latest-wins"
+)
+
+const (
+	// TimeoutPolicyReject rejects a timeout that is not less than invoke_interval.
+	TimeoutPolicyReject = "reject"
+
+	// TimeoutPolicyAllow accepts any timeout and skips checks that would overlap.
+	TimeoutPolicyAllow = "allow"
+)
+
+const (
+	// DerivedOperatorOr is True when any input condition is True.
+	DerivedOperatorOr = "or"
+
+	// DerivedOperatorAnd is True when every input condition is True.
+	DerivedOperatorAnd = "and"
+)
+
+const (
+	// EventSeverityInfo is the severity of informational events.
+	EventSeverityInfo = "info"
+
+	// EventSeverityWarn is the severity of warning events.
+	EventSeverityWarn = "warn"
+)
+
+const (
+	// DuplicateConditionsLast keeps the last condition of a duplicated type.
+	DuplicateConditionsLast = "last"
+
+	// DuplicateConditionsWorst keeps the most severe condition of a duplicated
+	// type, preferring the last on ties.
+	DuplicateConditionsWorst = "worst"
+)
+
+const (
+	// LoadBalanceFailover uses the first reachable socket.
+	LoadBalanceFailover = "failover"
+
+	// LoadBalanceWeightedRandom spreads CheckHealth calls across healthy
+	// sockets by weight.
+	LoadBalanceWeightedRandom = "weighted-random"
+)
+
+const (
+	// ParameterPrecedenceConfig sends all PluginParameters on every request.
+	ParameterPrecedenceConfig = "config"
+
+	// ParameterPrecedencePlugin sends only PluginParameters that differ from
+	// the plugin's advertised defaults.
+	ParameterPrecedencePlugin = "plugin"
+)
+
+// TimeoutParameter is the request parameter overriding the gRPC call timeout
+// of the call it is sent with, as a duration such as "5m". The override is
+// clamped to maxTimeout and is still passed to the plugin.
+const TimeoutParameter = "_timeout"
+
 // HealthCheckConfig defines health checking parameters.
 type HealthCheckConfig struct {
 	// Interval between health checks.
@@ -88,6 +557,15 @@ type HealthCheckConfig struct {
 
 	// ErrorThreshold defines when to consider plugin unhealthy.
 	ErrorThreshold int `json:"errorThreshold,omitempty"`
+
+	// ErrorRateWindow is the sliding window over which the share of failed
+	// CheckHealth calls is measured. Zero disables the error rate check.
+	ErrorRateWindow time.Duration `json:"errorRateWindow,omitempty"`
+
+	// ErrorRateThreshold is the share of failed calls, between 0 and 1, at
+	// which the plugin is considered unhealthy even without consecutive errors.
+	// Defaults to 0.5 when ErrorRateWindow is set.
+	ErrorRateThreshold float64 `json:"errorRateThreshold,omitempty"`
 }
 
 // ConditionDefinition defines a condition that the monitor can report.
@@ -95,17 +573,164 @@ type ConditionDefinition struct {
 	Type    string `json:"type"`
 	Reason  string `json:"reason"`
 	Message string `json:"message"`
+
+	// DebounceCount is the number of consecutive checks a status change must
+	// be observed for before it is forwarded. Zero or one disables debouncing.
+	DebounceCount int `json:"debounceCount,omitempty"`
+
+	// InvokeInterval checks this condition on its own schedule instead of
+	// with every CheckHealth call. Zero uses the monitor invoke_interval.
+	InvokeInterval time.Duration `json:"invokeInterval,omitempty"`
+
+	// Timeout overrides the gRPC call timeout for checks of this condition on
+	// its own schedule, clamped to maxTimeout. The regular check skips while
+	// such a call is in flight. Zero uses the monitor timeout.
+	Timeout time.Duration `json:"timeout,omitempty"`
+
+	// Parameters are added to the request parameters of checks of this
+	// condition on its own schedule, overriding pluginParameters of the same
+	// name. A TimeoutParameter entry overrides the timeout of these calls.
+	// They are sent as is, so they must not name SensitiveParameters.
+	Parameters map[string]string `json:"parameters,omitempty"`
+
+	// StaleAfterMissedChecks reports this condition as Unknown once this many
+	// consecutive statuses from checks of the condition omit it. Zero uses
+	// the monitor staleAfterMissedChecks.
+	StaleAfterMissedChecks int `json:"staleAfterMissedChecks,omitempty"`
+
+	// PerDevice expands this definition into one condition per device the
+	// plugin advertises in its metadata, typed Type[0], Type[1] and so on.
+	// Without an advertised device count the condition keeps Type.
+	PerDevice bool `json:"perDevice,omitempty"`
+
+	// ClearMargin adds hysteresis: once True, the condition only clears when
+	// the plugin reports it False with a breach magnitude of -clearMargin or
+	// less, i.e. clearMargin below the threshold relative to it. A magnitude
+	// of zero counts as not reported and clears as usual. Zero disables it.
+	ClearMargin float64 `json:"clearMargin,omitempty"`
+
+	// AutoResolveAfter resolves this condition to False once it has been True
+	// without the plugin reporting it True for this long, so a plugin that
+	// forgets a problem can't leave it set. Zero disables it.
+	AutoResolveAfter time.Duration `json:"autoResolveAfter,omitempty"`
+
+	// OccurrenceSeverities maps condition reasons to the severity of the
+	// occurrence event sent when this condition turns True with that reason,
+	// "info" or "warn". Other reasons use occurrenceEventSeverity.
+	OccurrenceSeverities map[string]string `json:"occurrenceSeverities,omitempty"`
+}
+
+// IsLivenessCritical returns true unless LivenessCritical is set to false.
+func (config *ExternalMonitorConfig) IsLivenessCritical() bool {
+	return config.LivenessCritical == nil || *config.LivenessCritical
+}
+
+// ParseTimeoutParameter returns the TimeoutParameter override among
+// parameters, or zero without one.
+func ParseTimeoutParameter(parameters map[string]string) (time.Duration, error) {
+	value, ok := parameters[TimeoutParameter]
+	if !ok {
+		return 0, nil
+	}
+	timeout, err := time.ParseDuration(strings.TrimSpace(value))
+	if err != nil || timeout <= 0 {
+		return 0, fmt.Errorf("%s must be a positive duration, got %q", TimeoutParameter, value)
+	}
+	return timeout, nil
 }
 
-// ApplyConfiguration applies default values and parses duration strings.
+// IsNetworkAddress returns true if a socket address is a network target,
+// either "dns://[authority]/host:port" or a bare "host:port", rather than a
+// Unix socket path or "unix://" address.
+func IsNetworkAddress(address string) bool {
+	switch {
+	case strings.HasPrefix(address, "dns:"):
+		return true
+	case strings.HasPrefix(address, "/"), strings.HasPrefix(address, "unix:"):
+		return false
+	default:
+		return strings.Contains(address, ":")
+	}
+}
+
+// Sockets returns the configured socket addresses in order of preference.
+func (config *ExternalPluginConfig) Sockets() []string {
+	if len(config.SocketAddresses) > 0 {
+		return config.SocketAddresses
+	}
+	if config.SocketAddress != "" {
+		return []string{config.SocketAddress}
+	}
+	return nil
+}
+
+// ApplyConfiguration applies default values. Duration strings are parsed when
+// the configuration is loaded.
 func (config *ExternalMonitorConfig) ApplyConfiguration() error {
 	// Set default values
 	if config.PluginConfig.InvokeInterval == 0 {
 		config.PluginConfig.InvokeInterval = 30 * time.Second
 	}
+	if config.PluginConfig.MinInvokeInterval == 0 {
+		config.PluginConfig.MinInvokeInterval = max(config.PluginConfig.InvokeInterval/2, time.Second)
+	}
+	if config.PluginConfig.MaxInvokeInterval == 0 {
+		config.PluginConfig.MaxInvokeInterval = config.PluginConfig.InvokeInterval * 2
+	}
 	if config.PluginConfig.Timeout == 0 {
 		config.PluginConfig.Timeout = 10 * time.Second
 	}
+	if config.PluginConfig.MaxTimeout == 0 {
+		config.PluginConfig.MaxTimeout = config.PluginConfig.Timeout
+	}
+	if config.PluginConfig.TimeoutPolicy == "" {
+		config.PluginConfig.TimeoutPolicy = TimeoutPolicyReject
+	}
+	if config.PluginConfig.DuplicateConditions == "" {
+		config.PluginConfig.DuplicateConditions = DuplicateConditionsLast
+	}
+	if config.PluginConfig.MalformedStatusThreshold == 0 {
+		config.PluginConfig.MalformedStatusThreshold = 3
+	}
+	if config.PluginConfig.MetadataFailureThreshold == 0 {
+		config.PluginConfig.MetadataFailureThreshold = 3
+	}
+	if config.PluginConfig.MinEventSeverity == "" {
+		config.PluginConfig.MinEventSeverity = EventSeverityInfo
+	}
+	if config.PluginConfig.TransitionSkewTolerance == 0 {
+		config.PluginConfig.TransitionSkewTolerance = 5 * time.Second
+	}
+	if config.PluginConfig.OccurrenceEventSeverity == "" {
+		config.PluginConfig.OccurrenceEventSeverity = EventSeverityWarn
+	}
+	if config.PluginConfig.MaxEventDetailsBytes == 0 {
+		config.PluginConfig.MaxEventDetailsBytes = 4096
+	}
+	if config.PluginConfig.MaxEventsPerStatus == 0 {
+		config.PluginConfig.MaxEventsPerStatus = 100
+	}
+	if config.PluginConfig.MaxConditionsPerStatus == 0 {
+		config.PluginConfig.MaxConditionsPerStatus = 100
+	}
+	if config.PluginConfig.MaxMessageBytes == 0 {
+		config.PluginConfig.MaxMessageBytes = 1024
+	}
+	if config.PluginConfig.DialTimeout == 0 {
+		config.PluginConfig.DialTimeout = 5 * time.Second
+	}
+	if config.PluginConfig.ParameterPrecedence == "" {
+		config.PluginConfig.ParameterPrecedence = ParameterPrecedenceConfig
+	}
+	if config.PluginConfig.LoadBalance == "" {
+		config.PluginConfig.LoadBalance = LoadBalanceFailover
+	}
+	if config.PluginConfig.StatusFileMaxAge == 0 {
+		config.PluginConfig.StatusFileMaxAge = 10 * time.Minute
+	}
+	if config.PluginConfig.MetadataMaxAge == 0 {
+		config.PluginConfig.MetadataMaxAge = 1 * time.Hour
+	}
 
 	// Set retry policy defaults
 	if config.PluginConfig.RetryPolicy.MaxAttempts == 0 {
@@ -120,6 +745,12 @@ func (config *ExternalMonitorConfig) ApplyConfiguration() error {
 	if config.PluginConfig.RetryPolicy.InitialBackoff == 0 {
 		config.PluginConfig.RetryPolicy.InitialBackoff = 1 * time.Second
 	}
+	if config.PluginConfig.RetryPolicy.Strategy == "" {
+		config.PluginConfig.RetryPolicy.Strategy = BackoffStrategyExponential
+	}
+	if config.PluginConfig.RetryPolicy.ReconnectBurst == 0 {
+		config.PluginConfig.RetryPolicy.ReconnectBurst = 1
+	}
 
 	// Set health check defaults
 	if config.PluginConfig.HealthCheck.Interval == 0 {
@@ -131,67 +762,484 @@ func (config *ExternalMonitorConfig) ApplyConfiguration() error {
 	if config.PluginConfig.HealthCheck.ErrorThreshold == 0 {
 		config.PluginConfig.HealthCheck.ErrorThreshold = 3
 	}
+	if config.PluginConfig.HealthCheck.ErrorRateWindow > 0 && config.PluginConfig.HealthCheck.ErrorRateThreshold == 0 {
+		config.PluginConfig.HealthCheck.ErrorRateThreshold = 0.5
+	}
 
 	// Default metrics reporting to true
 	if !config.MetricsReporting {
 		config.MetricsReporting = true
 	}
+	if config.MetricLabels.MaxConditionValues == 0 {
+		config.MetricLabels.MaxConditionValues = 50
+	}
+	if config.MetricLabels.Overflow == "" {
+		config.MetricLabels.Overflow = MetricLabelOverflowDrop
+	}
 
 	return nil
 }
 
-// Validate checks the configuration for correctness.
+// Validate checks the configuration for correctness and returns the first
+// problem found. Errors are a *ConfigValidationError naming the invalid field.
 func (config *ExternalMonitorConfig) Validate() error {
+	if errs := config.ValidateAll(); len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// Warnings returns the problems of a valid configuration that are worth
+// reporting but don't make it invalid, such as intervals out of proportion.
+func (config *ExternalMonitorConfig) Warnings() []string {
+	var warnings []string
+
+	healthCheckInterval := config.PluginConfig.HealthCheck.Interval
+	if healthCheckInterval >= minHealthCheckInterval &&
+		healthCheckInterval*maxInvokeToHealthCheckRatio < config.PluginConfig.InvokeInterval {
+		warnings = append(warnings, fmt.Sprintf("healthCheck.interval %v is much smaller than invoke_interval %v for %s",
+			healthCheckInterval, config.PluginConfig.InvokeInterval, config.Source))
+	}
+
+	return warnings
+}
+
+// ValidateAll checks the configuration for correctness and returns every
+// problem found, in the order Validate checks them, so that a configuration
+// can be fixed in one pass. Errors are a *ConfigValidationError naming the
+// invalid field.
+func (config *ExternalMonitorConfig) ValidateAll() []error {
+	var errs []error
+
 	if config.Plugin != "external" {
-		return fmt.Errorf("plugin must be \"external\", got %q", config.Plugin)
+		errs = append(errs, validationErrorf("plugin", "plugin must be \"external\", got %q", config.Plugin))
 	}
 
 	if config.Source == "" {
-		return fmt.Errorf("source is required")
+		errs = append(errs, validationErrorf("source", "source is required"))
+	}
+
+	if config.LogLevel != nil && *config.LogLevel < 0 {
+		errs = append(errs, validationErrorf("logLevel", "logLevel must not be negative"))
+	}
+
+	if config.PluginConfig.SocketAddress != "" && len(config.PluginConfig.SocketAddresses) > 0 {
+		errs = append(errs, validationErrorf("socketAddresses", "socketAddress and socketAddresses are mutually exclusive"))
+	}
+
+	if len(config.PluginConfig.Sockets()) == 0 {
+		errs = append(errs, validationErrorf("socketAddress", "socketAddress is required"))
+	}
+
+	for i, socket := range config.PluginConfig.SocketAddresses {
+		if socket == "" {
+			errs = append(errs, validationErrorf(fmt.Sprintf("socketAddresses[%d]", i), "socketAddresses[%d] must not be empty", i))
+		}
+	}
+
+	if config.PluginConfig.PeerCredentials.Enabled() {
+		for _, socket := range config.PluginConfig.Sockets() {
+			if IsNetworkAddress(socket) {
+				errs = append(errs, validationErrorf("peerCredentials", "peerCredentials requires Unix sockets, %s is a network address", socket))
+			}
+		}
 	}
 
-	if config.PluginConfig.SocketAddress == "" {
-		return fmt.Errorf("socketAddress is required")
+	switch config.PluginConfig.LoadBalance {
+	case LoadBalanceFailover, LoadBalanceWeightedRandom:
+	default:
+		errs = append(errs, validationErrorf("loadBalance", "loadBalance must be %q or %q, got %q",
+			LoadBalanceFailover, LoadBalanceWeightedRandom, config.PluginConfig.LoadBalance))
+	}
+
+	if config.PluginConfig.WarmStandby {
+		if len(config.PluginConfig.Sockets()) < 2 {
+			errs = append(errs, validationErrorf("warmStandby", "warmStandby requires at least two socketAddresses"))
+		}
+		if config.PluginConfig.LoadBalance != LoadBalanceFailover {
+			errs = append(errs, validationErrorf("warmStandby", "warmStandby requires loadBalance %q", LoadBalanceFailover))
+		}
+	}
+
+	for socket, weight := range config.PluginConfig.SocketWeights {
+		field := fmt.Sprintf("socketWeights[%s]", socket)
+		known := false
+		for _, s := range config.PluginConfig.Sockets() {
+			if s == socket {
+				known = true
+				break
+			}
+		}
+		if !known {
+			errs = append(errs, validationErrorf(field, "socketWeights references unknown socket %q", socket))
+			continue
+		}
+		if weight <= 0 {
+			errs = append(errs, validationErrorf(field, "socketWeights[%s] must be positive", socket))
+		}
 	}
 
 	if config.PluginConfig.InvokeInterval < time.Second {
-		return fmt.Errorf("invoke_interval must be at least 1 second")
+		errs = append(errs, validationErrorf("invoke_interval", "invoke_interval must be at least 1 second"))
 	}
 
 	if config.PluginConfig.Timeout < time.Second {
-		return fmt.Errorf("timeout must be at least 1 second")
+		errs = append(errs, validationErrorf("timeout", "timeout must be at least 1 second"))
+	}
+
+	if config.PluginConfig.MinInvokeInterval < time.Second {
+		errs = append(errs, validationErrorf("minInvokeInterval", "minInvokeInterval must be at least 1 second"))
+	}
+
+	if config.PluginConfig.MaxInvokeInterval < config.PluginConfig.MinInvokeInterval {
+		errs = append(errs, validationErrorf("maxInvokeInterval", "maxInvokeInterval must be at least minInvokeInterval"))
+	}
+
+	switch config.PluginConfig.TimeoutPolicy {
+	case TimeoutPolicyReject:
+		if config.PluginConfig.Timeout >= config.PluginConfig.InvokeInterval {
+			errs = append(errs, validationErrorf("timeout", "timeout must be less than invoke_interval"))
+		}
+		if config.PluginConfig.AdoptRecommendedInterval && config.PluginConfig.Timeout >= config.PluginConfig.MinInvokeInterval {
+			errs = append(errs, validationErrorf("minInvokeInterval", "minInvokeInterval must be greater than timeout with adoptRecommendedInterval"))
+		}
+	case TimeoutPolicyAllow:
+	default:
+		errs = append(errs, validationErrorf("timeoutPolicy", "timeoutPolicy must be %q or %q, got %q",
+			TimeoutPolicyReject, TimeoutPolicyAllow, config.PluginConfig.TimeoutPolicy))
+	}
+
+	if config.PluginConfig.MaxTimeout != 0 && config.PluginConfig.MaxTimeout < config.PluginConfig.Timeout {
+		errs = append(errs, validationErrorf("maxTimeout", "maxTimeout must be at least timeout"))
+	}
+
+	if config.PluginConfig.DialTimeout <= 0 {
+		errs = append(errs, validationErrorf("dialTimeout", "dialTimeout must be positive"))
+	}
+
+	if config.PluginConfig.IdleTimeout < 0 {
+		errs = append(errs, validationErrorf("idleTimeout", "idleTimeout must not be negative"))
+	}
+	if config.PluginConfig.IdleTimeout > 0 {
+		if config.PluginConfig.WarmStandby {
+			errs = append(errs, validationErrorf("idleTimeout", "idleTimeout cannot be combined with warmStandby"))
+		}
+		if config.PluginConfig.LoadBalance != LoadBalanceFailover {
+			errs = append(errs, validationErrorf("idleTimeout", "idleTimeout requires loadBalance %q", LoadBalanceFailover))
+		}
+	}
+
+	if config.PluginConfig.TransitionSkewTolerance < 0 {
+		errs = append(errs, validationErrorf("transitionSkewTolerance", "transitionSkewTolerance must not be negative"))
+	}
+
+	if config.PluginConfig.ConditionHeartbeatInterval < 0 {
+		errs = append(errs, validationErrorf("conditionHeartbeatInterval", "conditionHeartbeatInterval must not be negative"))
+	}
+
+	if config.PluginConfig.MaxSendBlock < 0 {
+		errs = append(errs, validationErrorf("maxSendBlock", "maxSendBlock must not be negative"))
+	}
+
+	if config.PluginConfig.MaxEventDetailsBytes < 0 {
+		errs = append(errs, validationErrorf("maxEventDetailsBytes", "maxEventDetailsBytes must not be negative"))
+	}
+
+	if config.PluginConfig.StaleAfterMissedChecks < 0 {
+		errs = append(errs, validationErrorf("staleAfterMissedChecks", "staleAfterMissedChecks must not be negative"))
+	}
+
+	if config.PluginConfig.MaxEventsPerStatus < 1 {
+		errs = append(errs, validationErrorf("maxEventsPerStatus", "maxEventsPerStatus must be at least 1"))
+	}
+
+	if config.PluginConfig.MaxConditionsPerStatus < 1 {
+		errs = append(errs, validationErrorf("maxConditionsPerStatus", "maxConditionsPerStatus must be at least 1"))
+	}
+
+	if config.PluginConfig.MaxMessageBytes < 1 {
+		errs = append(errs, validationErrorf("maxMessageBytes", "maxMessageBytes must be at least 1"))
+	}
+
+	switch config.PluginConfig.MinEventSeverity {
+	case EventSeverityInfo, EventSeverityWarn:
+	default:
+		errs = append(errs, validationErrorf("minEventSeverity", "minEventSeverity must be %q or %q, got %q",
+			EventSeverityInfo, EventSeverityWarn, config.PluginConfig.MinEventSeverity))
+	}
+
+	switch config.PluginConfig.OccurrenceEventSeverity {
+	case EventSeverityInfo, EventSeverityWarn:
+	default:
+		errs = append(errs, validationErrorf("occurrenceEventSeverity", "occurrenceEventSeverity must be %q or %q, got %q",
+			EventSeverityInfo, EventSeverityWarn, config.PluginConfig.OccurrenceEventSeverity))
+	}
+
+	if config.PluginConfig.MetadataMaxAge < time.Second {
+		errs = append(errs, validationErrorf("metadataMaxAge", "metadataMaxAge must be at least 1 second"))
+	}
+
+	if config.PluginConfig.EventDedupWindow < 0 {
+		errs = append(errs, validationErrorf("eventDedupWindow", "eventDedupWindow must not be negative"))
+	}
+
+	if config.PluginConfig.MalformedStatusThreshold < 1 {
+		errs = append(errs, validationErrorf("malformedStatusThreshold", "malformedStatusThreshold must be at least 1"))
+	}
+
+	if config.PluginConfig.MetadataFailureThreshold < 1 {
+		errs = append(errs, validationErrorf("metadataFailureThreshold", "metadataFailureThreshold must be at least 1"))
+	}
+
+	switch config.PluginConfig.DuplicateConditions {
+	case DuplicateConditionsLast, DuplicateConditionsWorst:
+	default:
+		errs = append(errs, validationErrorf("duplicateConditions", "duplicateConditions must be %q or %q, got %q",
+			DuplicateConditionsLast, DuplicateConditionsWorst, config.PluginConfig.DuplicateConditions))
+	}
+
+	if config.PluginConfig.DegradedLatencyFactor != 0 && config.PluginConfig.DegradedLatencyFactor <= 1 {
+		errs = append(errs, validationErrorf("degradedLatencyFactor", "degradedLatencyFactor must be greater than 1"))
+	}
+
+	if config.PluginConfig.MetadataCacheTTL < 0 {
+		errs = append(errs, validationErrorf("metadataCacheTTL", "metadataCacheTTL must not be negative"))
+	}
+
+	if config.PluginConfig.InitialStatusDelay < 0 {
+		errs = append(errs, validationErrorf("initialStatusDelay", "initialStatusDelay must not be negative"))
 	}
 
-	if config.PluginConfig.Timeout >= config.PluginConfig.InvokeInterval {
-		return fmt.Errorf("timeout must be less than invoke_interval")
+	if config.PluginConfig.StartupQuietPeriod < 0 {
+		errs = append(errs, validationErrorf("startupQuietPeriod", "startupQuietPeriod must not be negative"))
+	}
+
+	if config.PluginConfig.StatusFileMaxAge < 0 {
+		errs = append(errs, validationErrorf("statusFileMaxAge", "statusFileMaxAge must not be negative"))
 	}
 
 	// Validate retry policy
 	if config.PluginConfig.RetryPolicy.MaxAttempts < 1 {
-		return fmt.Errorf("retryPolicy.maxAttempts must be at least 1")
+		errs = append(errs, validationErrorf("retryPolicy.maxAttempts", "retryPolicy.maxAttempts must be at least 1"))
 	}
 
 	if config.PluginConfig.RetryPolicy.BackoffMultiplier < 1.0 {
-		return fmt.Errorf("retryPolicy.backoffMultiplier must be at least 1.0")
+		errs = append(errs, validationErrorf("retryPolicy.backoffMultiplier", "retryPolicy.backoffMultiplier must be at least 1.0"))
+	}
+
+	switch config.PluginConfig.RetryPolicy.Strategy {
+	case BackoffStrategyExponential, BackoffStrategyConstant, BackoffStrategyDecorrelatedJitter:
+	default:
+		errs = append(errs, validationErrorf("retryPolicy.strategy", "retryPolicy.strategy must be one of %q, %q or %q, got %q",
+			BackoffStrategyExponential, BackoffStrategyConstant, BackoffStrategyDecorrelatedJitter,
+			config.PluginConfig.RetryPolicy.Strategy))
+	}
+
+	if config.PluginConfig.RetryPolicy.ReconnectRate < 0 {
+		errs = append(errs, validationErrorf("retryPolicy.reconnectRate", "retryPolicy.reconnectRate must not be negative"))
+	}
+
+	if config.PluginConfig.RetryPolicy.ReconnectBurst < 1 {
+		errs = append(errs, validationErrorf("retryPolicy.reconnectBurst", "retryPolicy.reconnectBurst must be at least 1"))
+	}
+
+	switch config.PluginConfig.ParameterPrecedence {
+	case ParameterPrecedenceConfig, ParameterPrecedencePlugin:
+	default:
+		errs = append(errs, validationErrorf("parameterPrecedence", "parameterPrecedence must be %q or %q, got %q",
+			ParameterPrecedenceConfig, ParameterPrecedencePlugin, config.PluginConfig.ParameterPrecedence))
+	}
+
+	if _, err := ParseTimeoutParameter(config.PluginConfig.PluginParameters); err != nil {
+		errs = append(errs, validationErrorf("pluginParameters", "pluginParameters: %v", err))
+	}
+
+	for i, name := range config.PluginConfig.SensitiveParameters {
+		if _, ok := config.PluginConfig.PluginParameters[name]; !ok {
+			errs = append(errs, validationErrorf(fmt.Sprintf("sensitiveParameters[%d]", i),
+				"sensitiveParameters[%d] %q is not one of pluginParameters", i, name))
+		}
 	}
 
 	// Validate health check
+	if config.PluginConfig.HealthCheck.Interval < minHealthCheckInterval {
+		errs = append(errs, validationErrorf("healthCheck.interval", "healthCheck.interval must be at least %v", minHealthCheckInterval))
+	}
+
+	if config.PluginConfig.HealthCheck.Timeout < time.Second {
+		errs = append(errs, validationErrorf("healthCheck.timeout", "healthCheck.timeout must be at least 1 second"))
+	}
+
 	if config.PluginConfig.HealthCheck.ErrorThreshold < 1 {
-		return fmt.Errorf("healthCheck.errorThreshold must be at least 1")
+		errs = append(errs, validationErrorf("healthCheck.errorThreshold", "healthCheck.errorThreshold must be at least 1"))
+	}
+
+	if config.PluginConfig.HealthCheck.ErrorRateWindow < 0 {
+		errs = append(errs, validationErrorf("healthCheck.errorRateWindow", "healthCheck.errorRateWindow must not be negative"))
+	}
+
+	if rate := config.PluginConfig.HealthCheck.ErrorRateThreshold; rate < 0 || rate > 1 {
+		errs = append(errs, validationErrorf("healthCheck.errorRateThreshold", "healthCheck.errorRateThreshold must be between 0 and 1"))
 	}
 
 	// Validate conditions
 	for i, condition := range config.Conditions {
 		if condition.Type == "" {
-			return fmt.Errorf("condition[%d].type is required", i)
+			errs = append(errs, validationErrorf(fmt.Sprintf("condition[%d].type", i), "condition[%d].type is required", i))
 		}
 		if condition.Reason == "" {
-			return fmt.Errorf("condition[%d].reason is required", i)
+			errs = append(errs, validationErrorf(fmt.Sprintf("condition[%d].reason", i), "condition[%d].reason is required", i))
 		}
 		if condition.Message == "" {
-			return fmt.Errorf("condition[%d].message is required", i)
+			errs = append(errs, validationErrorf(fmt.Sprintf("condition[%d].message", i), "condition[%d].message is required", i))
+		}
+		if condition.DebounceCount < 0 {
+			errs = append(errs, validationErrorf(fmt.Sprintf("condition[%d].debounceCount", i), "condition[%d].debounceCount must not be negative", i))
+		}
+		if condition.InvokeInterval < 0 {
+			errs = append(errs, validationErrorf(fmt.Sprintf("condition[%d].invokeInterval", i), "condition[%d].invokeInterval must not be negative", i))
+		}
+		if condition.StaleAfterMissedChecks < 0 {
+			errs = append(errs, validationErrorf(fmt.Sprintf("condition[%d].staleAfterMissedChecks", i), "condition[%d].staleAfterMissedChecks must not be negative", i))
+		}
+		if condition.Timeout < 0 {
+			errs = append(errs, validationErrorf(fmt.Sprintf("condition[%d].timeout", i), "condition[%d].timeout must not be negative", i))
+		}
+		if condition.Timeout > 0 && condition.InvokeInterval == 0 {
+			errs = append(errs, validationErrorf(fmt.Sprintf("condition[%d].timeout", i), "condition[%d].timeout requires invokeInterval", i))
+		}
+		if len(condition.Parameters) > 0 && condition.InvokeInterval == 0 {
+			errs = append(errs, validationErrorf(fmt.Sprintf("condition[%d].parameters", i), "condition[%d].parameters requires invokeInterval", i))
+		}
+		if _, err := ParseTimeoutParameter(condition.Parameters); err != nil {
+			errs = append(errs, validationErrorf(fmt.Sprintf("condition[%d].parameters", i), "condition[%d].parameters: %v", i, err))
+		}
+		for _, name := range config.PluginConfig.SensitiveParameters {
+			if _, ok := condition.Parameters[name]; ok {
+				errs = append(errs, validationErrorf(fmt.Sprintf("condition[%d].parameters[%s]", i, name),
+					"condition[%d].parameters[%s] is a sensitive parameter, which is only sent encrypted from pluginParameters", i, name))
+			}
+		}
+		if condition.ClearMargin < 0 {
+			errs = append(errs, validationErrorf(fmt.Sprintf("condition[%d].clearMargin", i), "condition[%d].clearMargin must not be negative", i))
+		}
+		if condition.AutoResolveAfter < 0 {
+			errs = append(errs, validationErrorf(fmt.Sprintf("condition[%d].autoResolveAfter", i), "condition[%d].autoResolveAfter must not be negative", i))
+		}
+		for reason, severity := range condition.OccurrenceSeverities {
+			if severity != EventSeverityInfo && severity != EventSeverityWarn {
+				errs = append(errs, validationErrorf(fmt.Sprintf("condition[%d].occurrenceSeverities[%s]", i, reason),
+					"condition[%d].occurrenceSeverities[%s] must be %q or %q, got %q", i, reason, EventSeverityInfo, EventSeverityWarn, severity))
+			}
 		}
 	}
 
-	return nil
+	// Validate benign error codes
+	for operation := range config.PluginConfig.BenignErrorCodes {
+		if !knownOperations[operation] {
+			errs = append(errs, validationErrorf("benignErrorCodes", "benignErrorCodes has unknown operation %q", operation))
+		}
+	}
+
+	// Validate maintenance windows
+	for i, window := range config.PluginConfig.Maintenance.Windows {
+		if !window.End.After(window.Start) {
+			errs = append(errs, validationErrorf(fmt.Sprintf("maintenance.windows[%d]", i),
+				"maintenance.windows[%d].end must be after start", i))
+		}
+	}
+	for i, conditionType := range config.PluginConfig.Maintenance.Conditions {
+		if conditionType == "" {
+			errs = append(errs, validationErrorf(fmt.Sprintf("maintenance.conditions[%d]", i),
+				"maintenance.conditions[%d] must not be empty", i))
+		}
+	}
+
+	// Validate headers
+	for key := range config.PluginConfig.Headers {
+		if key == "" {
+			errs = append(errs, validationErrorf("headers", "headers keys must not be empty"))
+		}
+		if strings.HasPrefix(strings.ToLower(key), "grpc-") {
+			errs = append(errs, validationErrorf(fmt.Sprintf("headers[%q]", key), "headers[%q] uses the reserved grpc- prefix", key))
+		}
+	}
+
+	// Validate condition name mapping
+	for from, to := range config.ConditionNameMap {
+		if from == "" {
+			errs = append(errs, validationErrorf("conditionNameMap", "conditionNameMap keys must not be empty"))
+		}
+		if to == "" {
+			errs = append(errs, validationErrorf(fmt.Sprintf("conditionNameMap[%q]", from), "conditionNameMap[%q] must not be empty", from))
+		}
+	}
+
+	// Validate condition aggregation
+	switch config.ConditionAggregation {
+	case "", ConditionAggregationWorstWins, ConditionAggregationLatestWins:
+	default:
+		errs = append(errs, validationErrorf("conditionAggregation", "conditionAggregation must be %q or %q, got %q",
+			ConditionAggregationWorstWins, ConditionAggregationLatestWins, config.ConditionAggregation))
+	}
+
+	// Validate metric label bounds
+	if config.MetricLabels.MaxConditionValues < 1 {
+		errs = append(errs, validationErrorf("metricLabels.maxConditionValues", "metricLabels.maxConditionValues must be at least 1"))
+	}
+	switch config.MetricLabels.Overflow {
+	case MetricLabelOverflowDrop, MetricLabelOverflowBucket:
+	default:
+		errs = append(errs, validationErrorf("metricLabels.overflow", "metricLabels.overflow must be %q or %q, got %q",
+			MetricLabelOverflowDrop, MetricLabelOverflowBucket, config.MetricLabels.Overflow))
+	}
+	for i, conditionType := range config.MetricLabels.AllowConditions {
+		if conditionType == "" {
+			errs = append(errs, validationErrorf(fmt.Sprintf("metricLabels.allowConditions[%d]", i), "metricLabels.allowConditions[%d] must not be empty", i))
+		}
+	}
+	for i, conditionType := range config.MetricLabels.DenyConditions {
+		if conditionType == "" {
+			errs = append(errs, validationErrorf(fmt.Sprintf("metricLabels.denyConditions[%d]", i), "metricLabels.denyConditions[%d] must not be empty", i))
+		}
+	}
+
+	// Validate derived conditions
+	derivedTypes := make(map[string]bool)
+	for _, condDef := range config.Conditions {
+		derivedTypes[condDef.Type] = true
+	}
+	for i, derived := range config.DerivedConditions {
+		field := fmt.Sprintf("derivedConditions[%d]", i)
+		if derived.Type == "" {
+			errs = append(errs, validationErrorf(field+".type", "%s.type must not be empty", field))
+		}
+		if derivedTypes[derived.Type] {
+			errs = append(errs, validationErrorf(field+".type", "%s.type %q duplicates another condition", field, derived.Type))
+		}
+		derivedTypes[derived.Type] = true
+
+		switch derived.Operator {
+		case DerivedOperatorOr, DerivedOperatorAnd:
+		default:
+			errs = append(errs, validationErrorf(field+".operator", "%s.operator must be %q or %q, got %q",
+				field, DerivedOperatorOr, DerivedOperatorAnd, derived.Operator))
+		}
+
+		if len(derived.Inputs) == 0 {
+			errs = append(errs, validationErrorf(field+".inputs", "%s.inputs must not be empty", field))
+		}
+		for j, input := range derived.Inputs {
+			if input.Source == "" || input.Type == "" {
+				errs = append(errs, validationErrorf(fmt.Sprintf("%s.inputs[%d]", field, j),
+					"%s.inputs[%d] must set source and type", field, j))
+			}
+		}
+
+		if derived.DebouncePeriod < 0 {
+			errs = append(errs, validationErrorf(field+".debouncePeriod", "%s.debouncePeriod must not be negative", field))
+		}
+	}
+
+	return errs
 }
\ No newline at end of file
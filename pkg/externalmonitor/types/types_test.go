@@ -0,0 +1,187 @@
+/*
+Copyright 2024 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// validConfig returns a valid configuration with defaults applied after
+// configure.
+func validConfig(t *testing.T, configure func(*ExternalMonitorConfig)) *ExternalMonitorConfig {
+	t.Helper()
+
+	config := &ExternalMonitorConfig{
+		Plugin: "external",
+		Source: "test",
+		PluginConfig: ExternalPluginConfig{
+			SocketAddress:  "/run/test.sock",
+			InvokeInterval: 30 * time.Second,
+			Timeout:        5 * time.Second,
+		},
+		Conditions: []ConditionDefinition{{Type: "Test", Reason: "TestOK", Message: "ok"}},
+	}
+	if configure != nil {
+		configure(config)
+	}
+	if err := config.ApplyConfiguration(); err != nil {
+		t.Fatalf("ApplyConfiguration: %v", err)
+	}
+	return config
+}
+
+func TestValidateHealthCheck(t *testing.T) {
+	testCases := []struct {
+		name        string
+		healthCheck HealthCheckConfig
+		wantField   string
+	}{
+		{
+			name:        "defaults",
+			healthCheck: HealthCheckConfig{},
+		},
+		{
+			name:        "interval at the floor",
+			healthCheck: HealthCheckConfig{Interval: time.Second, Timeout: time.Second},
+		},
+		{
+			name:        "interval below the floor",
+			healthCheck: HealthCheckConfig{Interval: 100 * time.Millisecond},
+			wantField:   "healthCheck.interval",
+		},
+		{
+			name:        "timeout below a second",
+			healthCheck: HealthCheckConfig{Timeout: 500 * time.Millisecond},
+			wantField:   "healthCheck.timeout",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := validConfig(t, func(config *ExternalMonitorConfig) {
+				config.PluginConfig.HealthCheck = tc.healthCheck
+			})
+
+			err := config.Validate()
+			if tc.wantField == "" {
+				if err != nil {
+					t.Errorf("Validate: %v", err)
+				}
+				return
+			}
+			var validationErr *ConfigValidationError
+			if !errors.As(err, &validationErr) || validationErr.Field != tc.wantField {
+				t.Errorf("Validate = %v, want an error for %s", err, tc.wantField)
+			}
+		})
+	}
+}
+
+func TestWarnings(t *testing.T) {
+	testCases := []struct {
+		name           string
+		invokeInterval time.Duration
+		interval       time.Duration
+		wantWarning    string
+	}{
+		{
+			name:           "in proportion",
+			invokeInterval: 30 * time.Second,
+			interval:       time.Second,
+		},
+		{
+			name:           "out of proportion",
+			invokeInterval: 10 * time.Minute,
+			interval:       time.Second,
+			wantWarning:    "healthCheck.interval 1s is much smaller than invoke_interval 10m0s",
+		},
+		{
+			name:           "invalid interval",
+			invokeInterval: 10 * time.Minute,
+			interval:       100 * time.Millisecond,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := validConfig(t, func(config *ExternalMonitorConfig) {
+				config.PluginConfig.InvokeInterval = tc.invokeInterval
+				config.PluginConfig.HealthCheck.Interval = tc.interval
+			})
+
+			warnings := config.Warnings()
+			if tc.wantWarning == "" {
+				if len(warnings) != 0 {
+					t.Errorf("Warnings = %q, want none", warnings)
+				}
+				return
+			}
+			if len(warnings) != 1 || !strings.Contains(warnings[0], tc.wantWarning) {
+				t.Errorf("Warnings = %q, want one containing %q", warnings, tc.wantWarning)
+			}
+		})
+	}
+}
+
+func TestValidateConditionParameters(t *testing.T) {
+	testCases := []struct {
+		name       string
+		parameters map[string]string
+		wantField  string
+	}{
+		{
+			name:       "plain parameters",
+			parameters: map[string]string{"mode": "full", TimeoutParameter: "1m"},
+		},
+		{
+			name:       "invalid timeout",
+			parameters: map[string]string{TimeoutParameter: "-1s"},
+			wantField:  "condition[0].parameters",
+		},
+		{
+			name:       "sensitive parameter",
+			parameters: map[string]string{"token": "plaintext"},
+			wantField:  "condition[0].parameters[token]",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := validConfig(t, func(config *ExternalMonitorConfig) {
+				config.PluginConfig.PluginParameters = map[string]string{"token": "secret"}
+				config.PluginConfig.SensitiveParameters = []string{"token"}
+				config.Conditions[0].InvokeInterval = time.Minute
+				config.Conditions[0].Parameters = tc.parameters
+			})
+
+			err := config.Validate()
+			if tc.wantField == "" {
+				if err != nil {
+					t.Errorf("Validate: %v", err)
+				}
+				return
+			}
+			var validationErr *ConfigValidationError
+			if !errors.As(err, &validationErr) || validationErr.Field != tc.wantField {
+				t.Errorf("Validate = %v, want an error for %s", err, tc.wantField)
+			}
+		})
+	}
+}